@@ -3,10 +3,13 @@ package main
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
+	"sync"
 	"syscall"
 	"time"
 
@@ -15,8 +18,17 @@ import (
 	"monolith/internal/auth"
 	"monolith/internal/config"
 	"monolith/internal/database"
+	"monolith/internal/events"
+	"monolith/internal/health"
+	"monolith/internal/idempotency"
+	"monolith/internal/jobs"
 	"monolith/internal/logger"
 	"monolith/internal/login"
+	"monolith/internal/metrics"
+	"monolith/internal/realtime"
+	"monolith/internal/scheduler"
+	"monolith/internal/tracing"
+	"monolith/internal/webhook"
 	"monolith/migrations"
 
 	"github.com/jackc/pgx/v5/stdlib"
@@ -27,52 +39,154 @@ func main() {
 
 	slog.SetDefault(logger.New(logger.Config{
 		Level: cfg.Logging.Level,
+		Redact: logger.RedactConfig{
+			Keys:       slices.Concat(logger.DefaultRedactKeys, cfg.Logging.RedactKeys),
+			MaskEmails: cfg.Logging.RedactEmails,
+		},
 	}))
 
-	db, err := database.New(cfg.Database.URL)
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		slog.Error("Failed to set up tracing", "error", err)
+		panic("Tracing setup error")
+	}
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if shutdownErr := shutdownTracing(shutdownCtx); shutdownErr != nil {
+			slog.Warn("Failed to flush traces", "error", shutdownErr)
+		}
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	db, err := database.New(ctx, cfg.Database)
 	if err != nil {
 		slog.Error("Failed to connect to database", "error", err)
 		panic("Database connection error")
 	}
 	defer db.Close()
 
-	migrations.Up(stdlib.OpenDBFromPool(db.PgxPool()))
+	migrationDB, err := database.OpenMigrationDB(cfg.Database)
+	if err != nil {
+		slog.Error("Failed to open the database for migrations", "error", err)
+		panic("Database connection error")
+	}
+	migrations.Up(migrationDB)
+	_ = migrationDB.Close()
 
-	accountService := account.NewService(db)
-	loginService := login.NewService(db, accountService)
-	authService := auth.NewService(db, cfg.Security)
+	sqlDB := stdlib.OpenDBFromPool(db.PgxPool())
 
-	srv := api.NewHTTPServer(db, cfg, accountService, loginService, authService)
-	srv.Setup()
+	var authCache *auth.AuthContextCache
+	if cfg.AuthCache.Enabled {
+		authCache = auth.NewAuthContextCache(cfg.AuthCache)
+	}
+	sessionStore, err := newSessionStore(db, cfg.Security.SessionStore)
+	if err != nil {
+		slog.Error("Failed to set up the session store", "error", err)
+		panic("Session store setup error")
+	}
+	authService := auth.NewService(sessionStore, cfg.Security, authCache)
+	accountService := account.NewService(db, account.NewPostgresRepository(db), authService)
+	loginService := login.NewService(accountService)
+	webhookService := webhook.NewService(db, cfg.Webhook)
+	jobQueue := jobs.NewQueue(db, cfg.Jobs)
+	accountService.RegisterJobs(jobQueue)
+	idempotencyStore := idempotency.NewStore(db, cfg.Server.IdempotencyKeyTTL)
+	taskScheduler := scheduler.NewScheduler(db, cfg.Scheduler)
+	broker := realtime.NewBroker(db)
+	scheduleErr := registerScheduledTasks(taskScheduler, authService, idempotencyStore, webhookService)
+	if scheduleErr != nil {
+		slog.Error("Failed to register scheduled tasks", "error", scheduleErr)
+		panic("Scheduler setup error")
+	}
 
-	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
-	defer stop()
+	if cfg.Metrics.Enabled {
+		metrics.RegisterDBPool("primary", db.PgxPool())
+		if replica := db.ReplicaPgxPool(); replica != nil {
+			metrics.RegisterDBPool("replica", replica)
+		}
+		metrics.RegisterActiveSessions(ctx, authService.CountActiveSessions)
+	}
+
+	healthRegistry := health.NewRegistry()
+	healthRegistry.Register("database", health.CheckerFunc(db.Ping))
+	migrationsChecker, err := migrations.NewAppliedChecker(sqlDB)
+	if err != nil {
+		slog.Error("Failed to read the migrations", "error", err)
+		panic("Migrations error")
+	}
+	healthRegistry.Register("migrations", migrationsChecker)
+	healthRegistry.Register("scheduler", taskScheduler)
 
-	startSessionCleanup(ctx, authService, time.Hour)
+	srv := api.NewHTTPServer(
+		db,
+		cfg,
+		accountService,
+		loginService,
+		authService,
+		healthRegistry,
+		idempotencyStore,
+		webhookService,
+		jobQueue,
+		taskScheduler,
+		broker,
+	)
+	if setupErr := srv.Setup(); setupErr != nil {
+		slog.Error("Failed to set up HTTP server", "error", setupErr)
+		panic("HTTP server setup error")
+	}
+
+	eventDispatcher := events.NewDispatcher(db, cfg.Events)
+	eventDispatcher.Subscribe("webhooks", webhookService.HandleEvent)
+	eventDispatcher.Subscribe("realtime", broker.HandleEvent, realtime.SourceTypes...)
+	go eventDispatcher.Run(ctx)
+	go webhookService.Run(ctx)
+	go broker.Run(ctx)
+	if authCache != nil {
+		go authCache.Listen(ctx, db)
+	}
+	var background sync.WaitGroup
+	background.Go(func() {
+		jobQueue.Run(ctx)
+	})
+	background.Go(func() {
+		taskScheduler.Run(ctx)
+	})
 
 	if startErr := srv.Start(ctx); startErr != nil && !errors.Is(startErr, http.ErrServerClosed) {
 		slog.Error("Server failed to start", "error", startErr)
+		stop()
 	}
+
+	// let running jobs and scheduled tasks finish before the database is closed
+	background.Wait()
 }
 
-func startSessionCleanup(ctx context.Context, authService *auth.Service, interval time.Duration) {
-	go func() {
-		if err := authService.CleanupSessions(ctx); err != nil {
-			slog.Warn("Failed to cleanup sessions", "error", err)
-		}
+// registerScheduledTasks schedules the periodic maintenance work. Each run happens on one instance only.
+func registerScheduledTasks(
+	taskScheduler *scheduler.Scheduler,
+	authService *auth.Service,
+	idempotencyStore *idempotency.Store,
+	webhookService *webhook.Service,
+) error {
+	return errors.Join(
+		taskScheduler.Register("session_cleanup", "@hourly", authService.CleanupSessions),
+		taskScheduler.Register("idempotency_key_cleanup", "@hourly", idempotencyStore.DeleteExpired),
+		taskScheduler.Register("webhook_delivery_cleanup", "@hourly", webhookService.PruneDeliveries),
+	)
+}
 
-		ticker := time.NewTicker(interval)
-		defer ticker.Stop()
-
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case <-ticker.C:
-				if err := authService.CleanupSessions(ctx); err != nil {
-					slog.Warn("Failed to cleanup sessions", "error", err)
-				}
-			}
-		}
-	}()
+// newSessionStore returns the session store selected by name. The in-memory store still reads accounts from
+// the database.
+func newSessionStore(db *database.DB, name string) (auth.SessionStore, error) {
+	switch name {
+	case "postgres":
+		return auth.NewPostgresSessionStore(db), nil
+	case "memory":
+		return auth.NewMemorySessionStore(auth.NewPostgresSessionStore(db).LookupAccount), nil
+	default:
+		return nil, fmt.Errorf("unknown session store %q", name)
+	}
 }
@@ -2,23 +2,37 @@ package account
 
 import (
 	"context"
-	"fmt"
 	"strings"
 
 	"monolith/internal/database"
+	"monolith/internal/events"
+	"monolith/internal/jobs"
+	"monolith/internal/logger"
 
-	"github.com/georgysavva/scany/v2/pgxscan"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// SessionRevoker revokes the sessions of an account, as auth.Service does.
+type SessionRevoker interface {
+	RevokeAllUserSessions(ctx context.Context, accountID uuid.UUID) error
+}
+
 type Service struct {
-	db *database.DB
+	db       *database.DB
+	repo     Repository
+	sessions SessionRevoker
+	// jobs runs imports, once RegisterJobs is called.
+	jobs *jobs.Queue
 }
 
-func NewService(db *database.DB) *Service {
+// NewService returns a Service keeping accounts in repo. db is used by bulk updates, imports and exports, and
+// sessions revokes the sessions of accounts a bulk update disables or deletes.
+func NewService(db *database.DB, repo Repository, sessions SessionRevoker) *Service {
 	return &Service{
-		db: db,
+		db:       db,
+		repo:     repo,
+		sessions: sessions,
 	}
 }
 
@@ -27,14 +41,7 @@ func (s *Service) ValidatePassword(hashedPassword, password string) error {
 }
 
 func (s *Service) UserExists(ctx context.Context, email, username string) (bool, error) {
-	var existingAccount Account
-	err := pgxscan.Get(ctx, s.db.Pool, &existingAccount, `
-		SELECT id FROM account WHERE email = $1 OR username = $2
-	`, email, username)
-	if err != nil {
-		return false, err
-	}
-	return true, nil
+	return s.repo.Exists(ctx, email, username)
 }
 
 func (s *Service) Register(ctx context.Context, req RegisterRequest) (*Account, error) {
@@ -50,71 +57,38 @@ func (s *Service) Register(ctx context.Context, req RegisterRequest) (*Account,
 		return nil, ErrUserAlreadyExists
 	}
 
-	hashedPassword, err := hashPassword(req.Password)
+	hashedPassword, err := HashPassword(req.Password)
 	if err != nil {
 		return nil, err
 	}
 
-	tx, err := s.db.Pool.Begin(ctx)
-	if err != nil {
-		return nil, err
-	}
-	defer func() {
-		_ = tx.Rollback(ctx)
-	}()
-
-	var account Account
-	err = pgxscan.Get(ctx, tx, &account, `
-		INSERT INTO account (username, email, name, password, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, NOW(), NOW())
-		RETURNING id, username, email, name, is_admin, language, theme, timezone,
-		          last_seen_at, status, created_at, updated_at
-	`, req.Username, req.Email, req.Name, hashedPassword)
+	// registered accounts stay disabled until an admin enables them
+	account, err := s.repo.Create(ctx, NewAccount{
+		Username:     req.Username,
+		Email:        req.Email,
+		Name:         &req.Name,
+		PasswordHash: &hashedPassword,
+		Status:       StatusDisabled,
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	err = tx.Commit(ctx)
-	if err != nil {
-		return nil, err
-	}
+	logger.FromContext(ctx).Info("Account registered", "new_account_id", account.ID, "username", account.Username)
 
-	return &account, nil
+	return account, nil
 }
 
 func (s *Service) GetAccountByLogin(ctx context.Context, login string) (*Account, error) {
-	var account Account
-	err := pgxscan.Get(ctx, s.db.Pool, &account, `
-		SELECT id, username, email, name, password, is_admin, language, theme, timezone,
-		       last_seen_at, status, created_at, updated_at
-		FROM account
-		WHERE (email = $1 OR username = $1) AND status = 'active'
-	`, login)
-	if err != nil {
-		return nil, err
-	}
-	return &account, nil
+	return s.repo.GetByLogin(ctx, login)
 }
 
 func (s *Service) GetAccountByID(ctx context.Context, accountID uuid.UUID) (*Account, error) {
-	var account Account
-	err := pgxscan.Get(ctx, s.db.Pool, &account, `
-		SELECT id, username, email, name, is_admin, language, theme, timezone,
-		       last_seen_at, status, created_at, updated_at
-		FROM account
-		WHERE id = $1 AND status = 'active'
-	`, accountID)
-	if err != nil {
-		return nil, err
-	}
-	return &account, nil
+	return s.repo.Get(ctx, accountID)
 }
 
 func (s *Service) UpdateLastSeen(ctx context.Context, accountID uuid.UUID) error {
-	_, err := s.db.Pool.Exec(ctx, `
-		UPDATE account SET last_seen_at = NOW() WHERE id = $1
-	`, accountID)
-	return err
+	return s.repo.UpdateLastSeen(ctx, accountID)
 }
 
 func (s *Service) UpdatePreferences(
@@ -122,94 +96,50 @@ func (s *Service) UpdatePreferences(
 	accountID uuid.UUID,
 	req UpdatePreferencesRequest,
 ) (*Account, error) {
-	var account Account
-	err := pgxscan.Get(ctx, s.db.Pool, &account, `
-		UPDATE account
-		SET language = $1, theme = $2, timezone = $3, updated_at = NOW()
-		WHERE id = $4 AND status = 'active'
-		RETURNING id, username, email, name, is_admin, language, theme, timezone,
-		          last_seen_at, status, created_at, updated_at
-	`, req.Language, req.Theme, req.Timezone, accountID)
-	if err != nil {
-		return nil, err
-	}
-	return &account, nil
+	return s.repo.UpdatePreferences(ctx, accountID, req)
 }
 
-func (s *Service) GetAccounts(ctx context.Context) ([]Account, error) {
-	var accounts []Account
-	err := pgxscan.Select(ctx, s.db.Pool, &accounts, `
-		SELECT id, username, email, name, avatar, is_admin, language, theme, timezone,
-		       last_seen_at, status, created_at, updated_at
-		FROM account
-		ORDER BY created_at DESC
-	`)
-	if err != nil {
-		return nil, err
-	}
-	return accounts, nil
+func (s *Service) GetAccounts(ctx context.Context, filter AccountFilter) ([]Account, error) {
+	return s.repo.List(ctx, filter)
 }
 
 func (s *Service) GetAccount(ctx context.Context, id uuid.UUID) (*Account, error) {
-	var account Account
-	err := pgxscan.Get(ctx, s.db.Pool, &account, `
-		SELECT id, username, email, name, avatar, is_admin, language, theme, timezone,
-		       last_seen_at, status, created_at, updated_at
-		FROM account
-		WHERE id = $1 AND status = 'active'
-	`, id)
-	if err != nil {
-		return nil, err
-	}
-	return &account, nil
+	return s.repo.Get(ctx, id)
 }
 
 func (s *Service) CreateAccount(ctx context.Context, req CreateAccountRequest) (*Account, error) {
-	var account Account
-	var hashedPassword *string
-	status := "pending"
-	isAdmin := false
+	newAccount := NewAccount{
+		Username: req.Username,
+		Email:    req.Email,
+		Name:     &req.Name,
+		Status:   StatusPending,
+	}
 
 	if req.Password != nil && *req.Password != "" {
-		hashed, err := hashPassword(*req.Password)
+		hashed, err := HashPassword(*req.Password)
 		if err != nil {
 			return nil, err
 		}
-		hashedPassword = &hashed
-		status = "active"
+		newAccount.PasswordHash = &hashed
+		newAccount.Status = StatusActive
 	}
 
 	if req.IsAdmin != nil {
-		isAdmin = *req.IsAdmin
+		newAccount.IsAdmin = *req.IsAdmin
 	}
 
-	tx, err := s.db.Pool.Begin(ctx)
-	if err != nil {
-		return nil, err
-	}
-	defer func() {
-		_ = tx.Rollback(ctx)
-	}()
-
-	err = pgxscan.Get(ctx, tx, &account, `
-		INSERT INTO account (username, name, email, password, is_admin, status, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, NOW(), NOW())
-		RETURNING id, username, email, name, avatar, is_admin, language, theme, timezone,
-		          last_seen_at, status, created_at, updated_at
-	`, req.Username, req.Name, req.Email, hashedPassword, isAdmin, status)
+	account, err := s.repo.Create(ctx, newAccount)
 	if err != nil {
 		return nil, err
 	}
 
-	err = tx.Commit(ctx)
-	if err != nil {
-		return nil, err
-	}
+	logger.FromContext(ctx).Info("Account created", "new_account_id", account.ID, "status", account.Status)
 
-	return &account, nil
+	return account, nil
 }
 
 func (s *Service) InviteUsers(ctx context.Context, req InviteUsersRequest) (*InviteUsersResponse, error) {
+	log := logger.FromContext(ctx)
 	response := &InviteUsersResponse{
 		Success: []Account{},
 		Failed:  []InviteUserFailure{},
@@ -218,12 +148,8 @@ func (s *Service) InviteUsers(ctx context.Context, req InviteUsersRequest) (*Inv
 	for _, email := range req.Emails {
 		username := deriveUsernameFromEmail(email)
 
-		var existingAccount Account
-		err := pgxscan.Get(ctx, s.db.Pool, &existingAccount, `
-			SELECT id FROM account WHERE email = $1 OR username = $2
-		`, email, username)
-
-		if err == nil {
+		exists, err := s.repo.Exists(ctx, email, username)
+		if err == nil && exists {
 			response.Failed = append(response.Failed, InviteUserFailure{
 				Email:  email,
 				Reason: "User already exists",
@@ -231,65 +157,76 @@ func (s *Service) InviteUsers(ctx context.Context, req InviteUsersRequest) (*Inv
 			continue
 		}
 
-		var account Account
-		err = pgxscan.Get(ctx, s.db.Pool, &account, `
-			INSERT INTO account (username, email, name, is_admin, status, created_at, updated_at)
-			VALUES ($1, $2, $3, $4, 'pending', NOW(), NOW())
-			RETURNING id, username, email, name, avatar, is_admin, language, theme, timezone,
-			          last_seen_at, status, created_at, updated_at
-		`, username, email, username, req.IsAdmin)
+		account, err := s.repo.Create(ctx, NewAccount{
+			Username: username,
+			Email:    email,
+			Name:     &username,
+			IsAdmin:  req.IsAdmin,
+			Status:   StatusPending,
+			Invited:  true,
+		})
 		if err != nil {
+			log.Warn("Failed to invite user", "username", username, "error", err)
 			response.Failed = append(response.Failed, InviteUserFailure{
 				Email:  email,
-				Reason: fmt.Sprintf("Failed to create user: %v", err),
+				Reason: "Failed to create user",
 			})
 			continue
 		}
 
-		response.Success = append(response.Success, account)
+		response.Success = append(response.Success, *account)
 	}
 
+	log.Info("Users invited", "invited", len(response.Success), "failed", len(response.Failed))
+
 	return response, nil
 }
 
 func (s *Service) UpdateAccount(ctx context.Context, id uuid.UUID, req UpdateAccountRequest) (*Account, error) {
-	var account Account
-	err := pgxscan.Get(ctx, s.db.Pool, &account, `
-		UPDATE account
-		SET username = $1, name = $2, email = $3, updated_at = NOW()
-		WHERE id = $4 AND status = 'active'
-		RETURNING id, username, email, name, avatar, is_admin, language, theme, timezone,
-		          last_seen_at, status, created_at, updated_at
-	`, req.Username, req.Name, req.Email, id)
-	if err != nil {
-		return nil, err
-	}
-	return &account, nil
+	return s.repo.Update(ctx, id, req)
 }
 
 func (s *Service) DisableAccount(ctx context.Context, id uuid.UUID) error {
-	_, err := s.db.Pool.Exec(ctx, `
-		UPDATE account SET status = 'disabled', updated_at = NOW() WHERE id = $1
-	`, id)
-	return err
+	if err := s.repo.Disable(ctx, id); err != nil {
+		return err
+	}
+
+	logger.FromContext(ctx).Info("Account disabled", "target_account_id", id)
+	return nil
 }
 
 func (s *Service) EnableAccount(ctx context.Context, id uuid.UUID) error {
-	_, err := s.db.Pool.Exec(ctx, `
-		UPDATE account SET status = 'active', updated_at = NOW() WHERE id = $1
-	`, id)
-	return err
+	if err := s.repo.Enable(ctx, id); err != nil {
+		return err
+	}
+
+	logger.FromContext(ctx).Info("Account enabled", "target_account_id", id)
+	return nil
 }
 
 // TODO: this should trigger deleting other stuff
 func (s *Service) DeleteAccount(ctx context.Context, id uuid.UUID) error {
-	_, err := s.db.Pool.Exec(ctx, `
-		DELETE FROM account WHERE id = $1
-	`, id)
-	return err
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	logger.FromContext(ctx).Info("Account deleted", "target_account_id", id)
+	return nil
 }
 
-func hashPassword(password string) (string, error) {
+func accountCreated(account *Account) events.AccountCreated {
+	return events.AccountCreated{
+		AccountID: account.ID,
+		Username:  account.Username,
+		Email:     account.Email,
+		Name:      account.Name,
+		IsAdmin:   account.IsAdmin,
+		Status:    account.Status,
+	}
+}
+
+// HashPassword returns the bcrypt hash stored for password.
+func HashPassword(password string) (string, error) {
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), 12)
 	if err != nil {
 		return "", err
@@ -313,27 +250,25 @@ func (s *Service) ChangePassword(ctx context.Context, accountID uuid.UUID, req C
 		return ErrPasswordTooShort
 	}
 
-	var account Account
-	err := pgxscan.Get(ctx, s.db.Pool, &account, `
-		SELECT id, password
-		FROM account
-		WHERE id = $1 AND status = 'active'
-	`, accountID)
+	currentHash, err := s.repo.GetPasswordHash(ctx, accountID)
 	if err != nil {
 		return err
 	}
 
-	if err := s.ValidatePassword(account.Password, req.CurrentPassword); err != nil {
+	if err := s.ValidatePassword(currentHash, req.CurrentPassword); err != nil {
+		logger.FromContext(ctx).Warn("Password change rejected: current password mismatch")
 		return ErrInvalidPassword
 	}
 
-	hashedPassword, err := hashPassword(req.NewPassword)
+	hashedPassword, err := HashPassword(req.NewPassword)
 	if err != nil {
 		return err
 	}
 
-	_, err = s.db.Pool.Exec(ctx, `
-		UPDATE account SET password = $1, updated_at = NOW() WHERE id = $2
-	`, hashedPassword, accountID)
-	return err
+	if err := s.repo.SetPassword(ctx, accountID, hashedPassword); err != nil {
+		return err
+	}
+
+	logger.FromContext(ctx).Info("Password changed")
+	return nil
 }
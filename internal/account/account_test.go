@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"monolith/internal/database"
+	"monolith/internal/events"
 
 	"github.com/google/uuid"
 	"github.com/pashagolub/pgxmock/v4"
@@ -49,7 +50,7 @@ func TestService_ValidatePassword(t *testing.T) {
 			defer mock.Close()
 
 			db := &database.DB{Pool: mock}
-			s := NewService(db)
+			s := NewService(db, NewPostgresRepository(db), nil)
 
 			err := s.ValidatePassword(tt.hashedPassword, tt.password)
 			if tt.wantErr {
@@ -94,7 +95,7 @@ func TestService_UserExists(t *testing.T) {
 					WillReturnError(database.ErrNoRows)
 			},
 			want:    false,
-			wantErr: true,
+			wantErr: false,
 		},
 	}
 
@@ -106,7 +107,7 @@ func TestService_UserExists(t *testing.T) {
 			tt.setupMock(mock)
 
 			db := &database.DB{Pool: mock}
-			s := NewService(db)
+			s := NewService(db, NewPostgresRepository(db), nil)
 
 			got, err := s.UserExists(context.Background(), tt.email, tt.username)
 			if tt.wantErr {
@@ -176,7 +177,7 @@ func TestService_Register(t *testing.T) {
 			tt.setupMock(mock)
 
 			db := &database.DB{Pool: mock}
-			s := NewService(db)
+			s := NewService(db, NewPostgresRepository(db), nil)
 
 			account, err := s.Register(context.Background(), tt.req)
 			if tt.wantErr != nil {
@@ -274,7 +275,7 @@ func TestService_GetAccountByLogin(t *testing.T) {
 			tt.setupMock(mock)
 
 			db := &database.DB{Pool: mock}
-			s := NewService(db)
+			s := NewService(db, NewPostgresRepository(db), nil)
 
 			got, err := s.GetAccountByLogin(context.Background(), tt.login)
 			if tt.wantErr {
@@ -340,7 +341,7 @@ func TestService_GetAccountByID(t *testing.T) {
 			tt.setupMock(mock)
 
 			db := &database.DB{Pool: mock}
-			s := NewService(db)
+			s := NewService(db, NewPostgresRepository(db), nil)
 
 			got, err := s.GetAccountByID(context.Background(), tt.accountID)
 			if tt.wantErr {
@@ -440,7 +441,7 @@ func TestService_ChangePassword(t *testing.T) {
 			tt.setupMock(mock)
 
 			db := &database.DB{Pool: mock}
-			s := NewService(db)
+			s := NewService(db, NewPostgresRepository(db), nil)
 
 			err := s.ChangePassword(context.Background(), tt.accountID, tt.req)
 			if tt.wantErr != nil {
@@ -511,7 +512,7 @@ func TestService_UpdatePreferences(t *testing.T) {
 			tt.setupMock(mock)
 
 			db := &database.DB{Pool: mock}
-			s := NewService(db)
+			s := NewService(db, NewPostgresRepository(db), nil)
 
 			got, err := s.UpdatePreferences(context.Background(), tt.accountID, tt.req)
 			if tt.wantErr {
@@ -526,3 +527,69 @@ func TestService_UpdatePreferences(t *testing.T) {
 		})
 	}
 }
+
+func TestService_DisableAccount(t *testing.T) {
+	accountID := uuid.New()
+
+	tests := []struct {
+		name      string
+		setupMock func(mock pgxmock.PgxPoolIface)
+		wantErr   error
+	}{
+		{
+			name: "success records the event with the change",
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectExec(`UPDATE account SET status = 'disabled', updated_at = NOW\(\) WHERE id = \$1`).
+					WithArgs(accountID).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				expectEvent(mock, events.TypeAccountDisabled)
+				mock.ExpectCommit()
+				mock.ExpectRollback()
+			},
+		},
+		{
+			name: "account not found",
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectExec(`UPDATE account SET status = 'disabled'`).
+					WithArgs(accountID).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+				mock.ExpectRollback()
+			},
+			wantErr: database.ErrNoRows,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, _ := pgxmock.NewPool()
+			defer mock.Close()
+
+			tt.setupMock(mock)
+
+			s := newPostgresService(mock)
+			err := s.DisableAccount(context.Background(), accountID)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// expectEvent expects an event of the given type to be written to the outbox.
+func expectEvent(mock pgxmock.PgxPoolIface, eventType events.Type) {
+	mock.ExpectExec(`INSERT INTO outbox`).
+		WithArgs(pgxmock.AnyArg(), string(eventType), pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+}
+
+// newPostgresService returns a Service whose queries go to mock.
+func newPostgresService(mock pgxmock.PgxPoolIface) *Service {
+	db := &database.DB{Pool: mock}
+	return NewService(db, NewPostgresRepository(db), nil)
+}
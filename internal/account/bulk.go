@@ -0,0 +1,253 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"monolith/internal/database"
+	"monolith/internal/logger"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/google/uuid"
+)
+
+// MaxBulkAccounts bounds the number of accounts a single bulk request may target.
+const MaxBulkAccounts = 1000
+
+// BulkUpdateAccounts applies req.Action to the accounts req targets on behalf of actorID. Accounts that
+// don't exist or are the actor's own (for actions that would lock the actor out) are reported as failed.
+// In transaction mode any failure leaves every account untouched. Disabled and deleted accounts have
+// their sessions revoked.
+//
+// Returns:
+// - ErrInvalidBulkTarget if the request has neither or both of IDs and a filter
+// - ErrEmptyBulkFilter if the filter has no criteria, so a bulk request never targets every account by accident
+// - ErrTooManyBulkAccounts if the filter matches more than MaxBulkAccounts accounts
+func (s *Service) BulkUpdateAccounts(
+	ctx context.Context,
+	actorID uuid.UUID,
+	req BulkAccountsRequest,
+) (*BulkAccountsResponse, error) {
+	if (len(req.IDs) == 0) == (req.Filter == nil) {
+		return nil, ErrInvalidBulkTarget
+	}
+	if req.Filter != nil && req.Filter.empty() {
+		return nil, ErrEmptyBulkFilter
+	}
+	mode := req.Mode
+	if mode == "" {
+		mode = BulkModePerItem
+	}
+
+	ids, err := s.bulkTargets(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := s.existingAccountIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &BulkAccountsResponse{
+		Action:  req.Action,
+		Mode:    mode,
+		DryRun:  req.DryRun,
+		Success: []uuid.UUID{},
+		Failed:  []BulkAccountFailure{},
+	}
+
+	var targets []uuid.UUID
+	for _, id := range ids {
+		switch {
+		case !existing[id]:
+			response.Failed = append(response.Failed, BulkAccountFailure{ID: id, Reason: "Account not found"})
+		case id == actorID && locksOutActor(req.Action):
+			response.Failed = append(response.Failed, BulkAccountFailure{
+				ID:     id,
+				Reason: "Cannot " + string(req.Action) + " your own account",
+			})
+		default:
+			targets = append(targets, id)
+		}
+	}
+
+	switch {
+	case mode == BulkModeTransaction && len(response.Failed) > 0:
+		response.Failed = append(response.Failed, notApplied(targets)...)
+	case req.DryRun:
+		response.Success = append(response.Success, targets...)
+	case mode == BulkModeTransaction:
+		err = s.bulkApplyInTx(ctx, req.Action, targets, response)
+	default:
+		s.bulkApplyPerItem(ctx, req.Action, targets, response)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	logger.FromContext(ctx).Info("Bulk account action applied",
+		"action", req.Action,
+		"mode", mode,
+		"dry_run", req.DryRun,
+		"succeeded", len(response.Success),
+		"failed", len(response.Failed),
+	)
+
+	return response, nil
+}
+
+// bulkTargets resolves the IDs a request targets, without duplicates and in request order.
+func (s *Service) bulkTargets(ctx context.Context, req BulkAccountsRequest) ([]uuid.UUID, error) {
+	if req.Filter == nil {
+		seen := make(map[uuid.UUID]bool, len(req.IDs))
+		ids := make([]uuid.UUID, 0, len(req.IDs))
+		for _, id := range req.IDs {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+		return ids, nil
+	}
+
+	where, args := req.Filter.where()
+	var ids []uuid.UUID
+	err := pgxscan.Select(ctx, s.db.Conn(ctx), &ids, `
+		SELECT id FROM account
+		WHERE `+where+`
+		ORDER BY created_at
+		LIMIT `+strconv.Itoa(MaxBulkAccounts+1), args...)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) > MaxBulkAccounts {
+		return nil, ErrTooManyBulkAccounts
+	}
+	return ids, nil
+}
+
+func (s *Service) existingAccountIDs(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]bool, error) {
+	var found []uuid.UUID
+	err := pgxscan.Select(ctx, s.db.Conn(ctx), &found, `
+		SELECT id FROM account WHERE id = ANY($1)
+	`, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make(map[uuid.UUID]bool, len(found))
+	for _, id := range found {
+		existing[id] = true
+	}
+	return existing, nil
+}
+
+func (s *Service) bulkApplyPerItem(
+	ctx context.Context,
+	action BulkAction,
+	ids []uuid.UUID,
+	response *BulkAccountsResponse,
+) {
+	for _, id := range ids {
+		if err := s.bulkApplyOne(ctx, action, id); err != nil {
+			logger.FromContext(ctx).Warn("Bulk account action failed", "target_account_id", id, "error", err)
+			response.Failed = append(response.Failed, BulkAccountFailure{
+				ID:     id,
+				Reason: bulkFailureReason(action, err),
+			})
+			continue
+		}
+		response.Success = append(response.Success, id)
+	}
+}
+
+func (s *Service) bulkApplyOne(ctx context.Context, action BulkAction, id uuid.UUID) error {
+	return s.db.WithTx(ctx, func(ctx context.Context) error {
+		return s.applyBulkAction(ctx, action, id)
+	})
+}
+
+// errBulkRolledBack makes WithTx roll back a bulk transaction whose failure is already in the response.
+var errBulkRolledBack = errors.New("bulk action rolled back")
+
+func (s *Service) bulkApplyInTx(
+	ctx context.Context,
+	action BulkAction,
+	ids []uuid.UUID,
+	response *BulkAccountsResponse,
+) error {
+	err := s.db.WithTx(ctx, func(ctx context.Context) error {
+		for i, id := range ids {
+			if err := s.applyBulkAction(ctx, action, id); err != nil {
+				logger.FromContext(ctx).Warn("Bulk account action failed, rolling back",
+					"target_account_id", id,
+					"error", err,
+				)
+				response.Failed = append(response.Failed, notApplied(ids[:i])...)
+				response.Failed = append(response.Failed, BulkAccountFailure{
+					ID:     id,
+					Reason: bulkFailureReason(action, err),
+				})
+				response.Failed = append(response.Failed, notApplied(ids[i+1:])...)
+				return errBulkRolledBack
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, errBulkRolledBack) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	response.Success = append(response.Success, ids...)
+	return nil
+}
+
+// applyBulkAction applies action to the account through the repository. Disabled and deleted accounts have
+// their sessions revoked through the session revoker, so the revocation is announced like any other.
+func (s *Service) applyBulkAction(ctx context.Context, action BulkAction, id uuid.UUID) error {
+	switch action {
+	case BulkActionDisable:
+		if err := s.repo.Disable(ctx, id); err != nil {
+			return err
+		}
+		return s.sessions.RevokeAllUserSessions(ctx, id)
+	case BulkActionEnable:
+		return s.repo.Enable(ctx, id)
+	case BulkActionDelete:
+		// revoked first, since the sessions go with the account
+		if err := s.sessions.RevokeAllUserSessions(ctx, id); err != nil {
+			return err
+		}
+		return s.repo.Delete(ctx, id)
+	case BulkActionPromote:
+		return s.repo.SetAdmin(ctx, id, true)
+	case BulkActionDemote:
+		return s.repo.SetAdmin(ctx, id, false)
+	default:
+		return fmt.Errorf("unknown bulk action %q", action)
+	}
+}
+
+// locksOutActor reports whether applying action to the actor's own account would take away their access.
+func locksOutActor(action BulkAction) bool {
+	return action == BulkActionDisable || action == BulkActionDelete || action == BulkActionDemote
+}
+
+func bulkFailureReason(action BulkAction, err error) string {
+	if errors.Is(err, database.ErrNoRows) {
+		return "Account not found"
+	}
+	return "Failed to " + string(action) + " account"
+}
+
+func notApplied(ids []uuid.UUID) []BulkAccountFailure {
+	failures := make([]BulkAccountFailure, 0, len(ids))
+	for _, id := range ids {
+		failures = append(failures, BulkAccountFailure{ID: id, Reason: "Not applied, another account failed"})
+	}
+	return failures
+}
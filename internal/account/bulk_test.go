@@ -0,0 +1,235 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"monolith/internal/database"
+	"monolith/internal/events"
+
+	"github.com/google/uuid"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_BulkUpdateAccounts(t *testing.T) {
+	actorID := uuid.New()
+	first := uuid.New()
+	second := uuid.New()
+	missing := uuid.New()
+
+	expectExisting := func(mock pgxmock.PgxPoolIface, ids []uuid.UUID, existing ...uuid.UUID) {
+		rows := pgxmock.NewRows([]string{"id"})
+		for _, id := range existing {
+			rows.AddRow(id)
+		}
+		mock.ExpectQuery(`SELECT id FROM account WHERE id = ANY`).WithArgs(ids).WillReturnRows(rows)
+	}
+
+	tests := []struct {
+		name        string
+		req         BulkAccountsRequest
+		setupMock   func(mock pgxmock.PgxPoolIface)
+		wantErr     error
+		wantSuccess []uuid.UUID
+		wantFailed  []BulkAccountFailure
+		wantRevoked []uuid.UUID
+	}{
+		{
+			name:      "neither ids nor filter",
+			req:       BulkAccountsRequest{Action: BulkActionEnable},
+			setupMock: func(mock pgxmock.PgxPoolIface) {},
+			wantErr:   ErrInvalidBulkTarget,
+		},
+		{
+			name: "both ids and filter",
+			req: BulkAccountsRequest{
+				Action: BulkActionEnable,
+				IDs:    []uuid.UUID{first},
+				Filter: &AccountFilter{Status: "pending"},
+			},
+			setupMock: func(mock pgxmock.PgxPoolIface) {},
+			wantErr:   ErrInvalidBulkTarget,
+		},
+		{
+			name:      "filter without criteria",
+			req:       BulkAccountsRequest{Action: BulkActionDelete, Filter: &AccountFilter{}},
+			setupMock: func(mock pgxmock.PgxPoolIface) {},
+			wantErr:   ErrEmptyBulkFilter,
+		},
+		{
+			name: "per item disable revokes sessions and reports failures",
+			req: BulkAccountsRequest{
+				Action: BulkActionDisable,
+				IDs:    []uuid.UUID{first, missing, actorID, first},
+			},
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				expectExisting(mock, []uuid.UUID{first, missing, actorID}, first, actorID)
+				mock.ExpectBegin()
+				mock.ExpectBegin()
+				mock.ExpectExec(`UPDATE account SET status = 'disabled'`).
+					WithArgs(first).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				expectEvent(mock, events.TypeAccountDisabled)
+				mock.ExpectCommit()
+				mock.ExpectRollback()
+				mock.ExpectCommit()
+				mock.ExpectRollback()
+			},
+			wantSuccess: []uuid.UUID{first},
+			wantRevoked: []uuid.UUID{first},
+			wantFailed: []BulkAccountFailure{
+				{ID: missing, Reason: "Account not found"},
+				{ID: actorID, Reason: "Cannot disable your own account"},
+			},
+		},
+		{
+			name: "per item continues after a failure",
+			req: BulkAccountsRequest{
+				Action: BulkActionPromote,
+				IDs:    []uuid.UUID{first, second},
+			},
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				expectExisting(mock, []uuid.UUID{first, second}, first, second)
+				mock.ExpectBegin()
+				mock.ExpectExec(`UPDATE account SET is_admin = \$1`).
+					WithArgs(true, first).
+					WillReturnError(errors.New("connection reset"))
+				mock.ExpectRollback()
+				mock.ExpectBegin()
+				mock.ExpectExec(`UPDATE account SET is_admin = \$1`).
+					WithArgs(true, second).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				mock.ExpectCommit()
+				mock.ExpectRollback()
+			},
+			wantSuccess: []uuid.UUID{second},
+			wantFailed:  []BulkAccountFailure{{ID: first, Reason: "Failed to promote account"}},
+		},
+		{
+			name: "transaction rolls back every account",
+			req: BulkAccountsRequest{
+				Action: BulkActionEnable,
+				IDs:    []uuid.UUID{first, second},
+				Mode:   BulkModeTransaction,
+			},
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				expectExisting(mock, []uuid.UUID{first, second}, first, second)
+				mock.ExpectBegin()
+				mock.ExpectBegin()
+				mock.ExpectExec(`UPDATE account SET status = 'active'`).
+					WithArgs(first).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				expectEvent(mock, events.TypeAccountEnabled)
+				mock.ExpectCommit()
+				mock.ExpectRollback()
+				mock.ExpectBegin()
+				mock.ExpectExec(`UPDATE account SET status = 'active'`).
+					WithArgs(second).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+				mock.ExpectRollback()
+				mock.ExpectRollback()
+			},
+			wantSuccess: []uuid.UUID{},
+			wantFailed: []BulkAccountFailure{
+				{ID: first, Reason: "Not applied, another account failed"},
+				{ID: second, Reason: "Account not found"},
+			},
+		},
+		{
+			name: "transaction commits when every account succeeds",
+			req: BulkAccountsRequest{
+				Action: BulkActionDelete,
+				IDs:    []uuid.UUID{first},
+				Mode:   BulkModeTransaction,
+			},
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				expectExisting(mock, []uuid.UUID{first}, first)
+				mock.ExpectBegin()
+				mock.ExpectBegin()
+				mock.ExpectExec(`DELETE FROM account`).
+					WithArgs(first).
+					WillReturnResult(pgxmock.NewResult("DELETE", 1))
+				expectEvent(mock, events.TypeAccountDeleted)
+				mock.ExpectCommit()
+				mock.ExpectRollback()
+				mock.ExpectCommit()
+				mock.ExpectRollback()
+			},
+			wantSuccess: []uuid.UUID{first},
+			wantRevoked: []uuid.UUID{first},
+			wantFailed:  []BulkAccountFailure{},
+		},
+		{
+			name: "transaction with a precheck failure changes nothing",
+			req: BulkAccountsRequest{
+				Action: BulkActionDemote,
+				IDs:    []uuid.UUID{first, actorID},
+				Mode:   BulkModeTransaction,
+			},
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				expectExisting(mock, []uuid.UUID{first, actorID}, first, actorID)
+			},
+			wantSuccess: []uuid.UUID{},
+			wantFailed: []BulkAccountFailure{
+				{ID: actorID, Reason: "Cannot demote your own account"},
+				{ID: first, Reason: "Not applied, another account failed"},
+			},
+		},
+		{
+			name: "dry run by filter",
+			req: BulkAccountsRequest{
+				Action: BulkActionEnable,
+				Filter: &AccountFilter{Status: "pending", Search: "a_b"},
+				DryRun: true,
+			},
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery(`SELECT id FROM account\s+WHERE TRUE AND status = \$1 AND \(username ILIKE \$2`).
+					WithArgs("pending", `%a\_b%`).
+					WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(first).AddRow(second))
+				expectExisting(mock, []uuid.UUID{first, second}, first, second)
+			},
+			wantSuccess: []uuid.UUID{first, second},
+			wantFailed:  []BulkAccountFailure{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mock.Close()
+
+			tt.setupMock(mock)
+
+			sessions := &fakeSessionRevoker{}
+			db := &database.DB{Pool: mock}
+			s := NewService(db, NewPostgresRepository(db), sessions)
+
+			response, err := s.BulkUpdateAccounts(context.Background(), actorID, tt.req)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, response)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantSuccess, response.Success)
+				assert.Equal(t, tt.wantFailed, response.Failed)
+				assert.Equal(t, tt.req.DryRun, response.DryRun)
+			}
+			assert.Equal(t, tt.wantRevoked, sessions.revoked)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// fakeSessionRevoker records the accounts whose sessions were revoked.
+type fakeSessionRevoker struct {
+	revoked []uuid.UUID
+}
+
+func (f *fakeSessionRevoker) RevokeAllUserSessions(_ context.Context, accountID uuid.UUID) error {
+	f.revoked = append(f.revoked, accountID)
+	return nil
+}
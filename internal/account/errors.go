@@ -3,7 +3,15 @@ package account
 import "errors"
 
 var (
-	ErrPasswordTooShort  = errors.New("password must be at least 8 characters")
-	ErrUserAlreadyExists = errors.New("user already exists")
-	ErrInvalidPassword   = errors.New("current password is incorrect")
+	ErrPasswordTooShort    = errors.New("password must be at least 8 characters")
+	ErrUserAlreadyExists   = errors.New("user already exists")
+	ErrInvalidPassword     = errors.New("current password is incorrect")
+	ErrInvalidBulkTarget   = errors.New("bulk request must target either ids or a filter")
+	ErrTooManyBulkAccounts = errors.New("bulk request targets too many accounts")
+	ErrEmptyBulkFilter     = errors.New("bulk filter must have at least one criterion")
+	ErrInvalidImportFile   = errors.New("invalid import file")
+	ErrTooManyImportRows   = errors.New("import file has too many rows")
+	ErrImportInProgress    = errors.New("import is still running")
+	ErrUnknownExportColumn = errors.New("unknown export column")
+	ErrInvalidTimezone     = errors.New("invalid time zone")
 )
@@ -0,0 +1,276 @@
+package account
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"monolith/internal/logger"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+)
+
+// ExportFormat is the encoding of an account export.
+type ExportFormat string
+
+const (
+	ExportFormatCSV    ExportFormat = "csv"
+	ExportFormatJSON   ExportFormat = "json"
+	ExportFormatNDJSON ExportFormat = "ndjson"
+)
+
+// ContentType returns the media type of the export.
+func (f ExportFormat) ContentType() string {
+	switch f {
+	case ExportFormatCSV:
+		return "text/csv; charset=utf-8"
+	case ExportFormatNDJSON:
+		return "application/x-ndjson"
+	case ExportFormatJSON:
+		return "application/json"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// exportColumn is a column callers can select. Timestamps are rendered in the requested location.
+type exportColumn struct {
+	name  string
+	value func(a *Account, loc *time.Location) any
+}
+
+var exportColumns = []exportColumn{
+	{"id", func(a *Account, _ *time.Location) any { return a.ID.String() }},
+	{"username", func(a *Account, _ *time.Location) any { return a.Username }},
+	{"email", func(a *Account, _ *time.Location) any { return a.Email }},
+	{"name", func(a *Account, _ *time.Location) any { return a.Name }},
+	{"isAdmin", func(a *Account, _ *time.Location) any { return a.IsAdmin }},
+	{"status", func(a *Account, _ *time.Location) any { return a.Status }},
+	{"language", func(a *Account, _ *time.Location) any { return a.Language }},
+	{"theme", func(a *Account, _ *time.Location) any { return a.Theme }},
+	{"timezone", func(a *Account, _ *time.Location) any { return a.Timezone }},
+	{"lastSeenAt", func(a *Account, loc *time.Location) any { return formatExportTime(a.LastSeenAt, loc) }},
+	{"createdAt", func(a *Account, loc *time.Location) any { return formatExportTime(&a.CreatedAt, loc) }},
+	{"updatedAt", func(a *Account, loc *time.Location) any { return formatExportTime(&a.UpdatedAt, loc) }},
+}
+
+// ExportColumnNames lists the columns an export may select, in their default order.
+func ExportColumnNames() []string {
+	names := make([]string, 0, len(exportColumns))
+	for _, column := range exportColumns {
+		names = append(names, column.name)
+	}
+	return names
+}
+
+// ExportAccounts streams the accounts matched by req's filter to w, row by row from the database cursor,
+// so exports of any size use constant memory. Once the first row is written a failure can only truncate
+// the output, which leaves CSV short and JSON unterminated. It reads from the read replica when there is one.
+//
+// Returns:
+// - ErrUnknownExportColumn if req selects a column that doesn't exist
+// - ErrInvalidTimezone if req.Timezone isn't an IANA time zone name
+func (s *Service) ExportAccounts(ctx context.Context, req ExportAccountsRequest, w io.Writer) error {
+	columns, err := selectExportColumns(req.Columns)
+	if err != nil {
+		return err
+	}
+	loc := time.UTC
+	if req.Timezone != "" {
+		if loc, err = time.LoadLocation(req.Timezone); err != nil {
+			return fmt.Errorf("%w: %q", ErrInvalidTimezone, req.Timezone)
+		}
+	}
+
+	where, args := req.where()
+	rows, err := s.db.Reader(ctx).Query(ctx, `
+		SELECT id, username, email, name, avatar, is_admin, language, theme, timezone,
+		       last_seen_at, status, created_at, updated_at
+		FROM account
+		WHERE `+where+`
+		ORDER BY created_at DESC
+	`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	encoder := newExportEncoder(req.Format, w, columns, loc)
+	if err := encoder.begin(); err != nil {
+		return err
+	}
+
+	scanner := pgxscan.NewRowScanner(rows)
+	count := 0
+	for rows.Next() {
+		var account Account
+		if err := scanner.Scan(&account); err != nil {
+			return err
+		}
+		if err := encoder.write(&account); err != nil {
+			return err
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if err := encoder.end(); err != nil {
+		return err
+	}
+
+	logger.FromContext(ctx).Info("Accounts exported", "format", req.Format, "rows", count)
+	return nil
+}
+
+// selectExportColumns resolves a comma separated list of column names; empty selects every column.
+func selectExportColumns(selection string) ([]exportColumn, error) {
+	if strings.TrimSpace(selection) == "" {
+		return exportColumns, nil
+	}
+
+	var columns []exportColumn
+	for name := range strings.SplitSeq(selection, ",") {
+		name = strings.TrimSpace(name)
+		found := false
+		for _, column := range exportColumns {
+			if strings.EqualFold(column.name, name) {
+				columns = append(columns, column)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownExportColumn, name)
+		}
+	}
+	return columns, nil
+}
+
+func formatExportTime(t *time.Time, loc *time.Location) *string {
+	if t == nil {
+		return nil
+	}
+	formatted := t.In(loc).Format(time.RFC3339)
+	return &formatted
+}
+
+// exportEncoder writes accounts in one export format.
+type exportEncoder struct {
+	format  ExportFormat
+	w       io.Writer
+	csv     *csv.Writer
+	columns []exportColumn
+	loc     *time.Location
+	rows    int
+}
+
+func newExportEncoder(format ExportFormat, w io.Writer, columns []exportColumn, loc *time.Location) *exportEncoder {
+	encoder := &exportEncoder{format: format, w: w, columns: columns, loc: loc}
+	if format == ExportFormatCSV {
+		encoder.csv = csv.NewWriter(w)
+	}
+	return encoder
+}
+
+func (e *exportEncoder) begin() error {
+	switch e.format {
+	case ExportFormatCSV:
+		header := make([]string, 0, len(e.columns))
+		for _, column := range e.columns {
+			header = append(header, column.name)
+		}
+		return e.csv.Write(header)
+	case ExportFormatJSON:
+		_, err := io.WriteString(e.w, "[")
+		return err
+	case ExportFormatNDJSON:
+		return nil
+	default:
+		return fmt.Errorf("unsupported export format %q", e.format)
+	}
+}
+
+func (e *exportEncoder) write(a *Account) error {
+	defer func() { e.rows++ }()
+
+	if e.format == ExportFormatCSV {
+		record := make([]string, 0, len(e.columns))
+		for _, column := range e.columns {
+			record = append(record, csvValue(column.value(a, e.loc)))
+		}
+		return e.csv.Write(record)
+	}
+
+	var b strings.Builder
+	if e.format == ExportFormatJSON && e.rows > 0 {
+		b.WriteString(",")
+	}
+	b.WriteString("{")
+	for i, column := range e.columns {
+		value, err := json.Marshal(column.value(a, e.loc))
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString(strconv.Quote(column.name))
+		b.WriteString(":")
+		b.Write(value)
+	}
+	b.WriteString("}")
+	if e.format == ExportFormatNDJSON {
+		b.WriteString("\n")
+	}
+
+	_, err := io.WriteString(e.w, b.String())
+	return err
+}
+
+func (e *exportEncoder) end() error {
+	switch e.format {
+	case ExportFormatCSV:
+		e.csv.Flush()
+		return e.csv.Error()
+	case ExportFormatJSON:
+		_, err := io.WriteString(e.w, "]\n")
+		return err
+	case ExportFormatNDJSON:
+		return nil
+	default:
+		return nil
+	}
+}
+
+func csvValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return csvText(v)
+	case *string:
+		if v == nil {
+			return ""
+		}
+		return csvText(*v)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// csvText prefixes text a spreadsheet would read as a formula with a quote, so opening an export can't run
+// a formula a user put in their name or email.
+func csvText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
@@ -0,0 +1,110 @@
+package account
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_ExportAccounts(t *testing.T) {
+	firstID := uuid.MustParse("6f1c1a7e-3d4b-4c59-9d1e-8f8b1c2d3e4f")
+	secondID := uuid.MustParse("0b7e4d52-1f2a-4e3c-8a9b-6c5d4e3f2a1b")
+	created := time.Date(2026, time.March, 1, 22, 30, 0, 0, time.UTC)
+	columns := []string{
+		"id", "username", "email", "name", "avatar", "is_admin", "language", "theme", "timezone",
+		"last_seen_at", "status", "created_at", "updated_at",
+	}
+	isAdmin := true
+
+	expectAccounts := func(mock pgxmock.PgxPoolIface, args ...any) {
+		mock.ExpectQuery(`SELECT .+ FROM account\s+WHERE .+ ORDER BY created_at DESC`).
+			WithArgs(args...).
+			WillReturnRows(pgxmock.NewRows(columns).
+				AddRow(firstID, "jane", "jane@example.com", new("Jane, Admin"), nil, true, nil, nil, nil,
+					(*time.Time)(nil), "active", created, created).
+				AddRow(secondID, "john", "john@example.com", new("=1+2"), nil, false, new("tr"), nil, nil,
+					&created, "pending", created, created))
+	}
+
+	tests := []struct {
+		name      string
+		req       ExportAccountsRequest
+		setupMock func(mock pgxmock.PgxPoolIface)
+		want      string
+		wantErr   error
+	}{
+		{
+			name: "csv with selected columns in a time zone",
+			req: ExportAccountsRequest{
+				Format:   ExportFormatCSV,
+				Columns:  "username, name,createdAt",
+				Timezone: "Europe/Istanbul",
+			},
+			setupMock: func(mock pgxmock.PgxPoolIface) { expectAccounts(mock) },
+			want: "username,name,createdAt\n" +
+				"jane,\"Jane, Admin\",2026-03-02T01:30:00+03:00\n" +
+				"john,'=1+2,2026-03-02T01:30:00+03:00\n",
+		},
+		{
+			name: "json with filters",
+			req: ExportAccountsRequest{
+				AccountFilter: AccountFilter{Status: "active", IsAdmin: &isAdmin},
+				Format:        ExportFormatJSON,
+				Columns:       "id,isAdmin,lastSeenAt",
+			},
+			setupMock: func(mock pgxmock.PgxPoolIface) { expectAccounts(mock, "active", true) },
+			want: `[{"id":"` + firstID.String() + `","isAdmin":true,"lastSeenAt":null},` +
+				`{"id":"` + secondID.String() + `","isAdmin":false,"lastSeenAt":"2026-03-01T22:30:00Z"}]` + "\n",
+		},
+		{
+			name: "ndjson",
+			req: ExportAccountsRequest{
+				Format:  ExportFormatNDJSON,
+				Columns: "username,language",
+			},
+			setupMock: func(mock pgxmock.PgxPoolIface) { expectAccounts(mock) },
+			want: `{"username":"jane","language":null}` + "\n" +
+				`{"username":"john","language":"tr"}` + "\n",
+		},
+		{
+			name:      "unknown column",
+			req:       ExportAccountsRequest{Format: ExportFormatCSV, Columns: "username,password"},
+			setupMock: func(mock pgxmock.PgxPoolIface) {},
+			wantErr:   ErrUnknownExportColumn,
+		},
+		{
+			name:      "invalid time zone",
+			req:       ExportAccountsRequest{Format: ExportFormatCSV, Timezone: "Mars/Olympus"},
+			setupMock: func(mock pgxmock.PgxPoolIface) {},
+			wantErr:   ErrInvalidTimezone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mock.Close()
+
+			tt.setupMock(mock)
+			s := newPostgresService(mock)
+
+			var out bytes.Buffer
+			err = s.ExportAccounts(context.Background(), tt.req, &out)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				assert.Empty(t, out.String())
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, out.String())
+			}
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package account
+
+import (
+	"strconv"
+	"strings"
+)
+
+// empty reports whether f has no criteria, and so matches every account.
+func (f AccountFilter) empty() bool {
+	return f.Status == "" && f.IsAdmin == nil && f.Search == ""
+}
+
+// where returns the SQL condition selecting the accounts matched by f and its arguments.
+func (f AccountFilter) where() (string, []any) {
+	conditions := []string{"TRUE"}
+	var args []any
+
+	if f.Status != "" {
+		args = append(args, f.Status)
+		conditions = append(conditions, "status = $"+strconv.Itoa(len(args)))
+	}
+	if f.IsAdmin != nil {
+		args = append(args, *f.IsAdmin)
+		conditions = append(conditions, "is_admin = $"+strconv.Itoa(len(args)))
+	}
+	if f.Search != "" {
+		args = append(args, "%"+escapeLike(f.Search)+"%")
+		n := "$" + strconv.Itoa(len(args))
+		conditions = append(conditions, "(username ILIKE "+n+" OR email ILIKE "+n+" OR name ILIKE "+n+")")
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+// matches reports whether f matches account, in the same way as the condition returned by where.
+func (f AccountFilter) matches(account *Account) bool {
+	if f.Status != "" && account.Status != f.Status {
+		return false
+	}
+	if f.IsAdmin != nil && account.IsAdmin != *f.IsAdmin {
+		return false
+	}
+	if f.Search == "" {
+		return true
+	}
+	search := strings.ToLower(f.Search)
+	return strings.Contains(strings.ToLower(account.Username), search) ||
+		strings.Contains(strings.ToLower(account.Email), search) ||
+		(account.Name != nil && strings.Contains(strings.ToLower(*account.Name), search))
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
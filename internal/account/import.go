@@ -0,0 +1,471 @@
+package account
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"slices"
+	"strconv"
+	"strings"
+
+	"monolith/internal/events"
+	"monolith/internal/jobs"
+	"monolith/internal/logger"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/google/uuid"
+)
+
+// MaxImportRows bounds the number of accounts a single import file may contain.
+const MaxImportRows = 5000
+
+// ImportFormat is the encoding of an import file.
+type ImportFormat string
+
+const (
+	ImportFormatCSV  ImportFormat = "csv"
+	ImportFormatJSON ImportFormat = "json"
+)
+
+var importStatuses = []string{"active", "pending", "disabled"}
+
+// ParseImportFile reads the rows of an import file. CSV files need a header row naming the columns
+// (username, name, email, is_admin, status; only email is required); JSON files hold an array of objects
+// with the ImportRow fields.
+//
+// Returns:
+// - ErrInvalidImportFile if the file can't be decoded
+// - ErrTooManyImportRows if the file has more than MaxImportRows rows
+func ParseImportFile(r io.Reader, format ImportFormat) ([]ImportRow, error) {
+	var rows []ImportRow
+	var err error
+	switch format {
+	case ImportFormatCSV:
+		rows, err = parseImportCSV(r)
+	case ImportFormatJSON:
+		rows, err = parseImportJSON(r)
+	default:
+		return nil, fmt.Errorf("%w: unsupported format %q", ErrInvalidImportFile, format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) > MaxImportRows {
+		return nil, ErrTooManyImportRows
+	}
+	return rows, nil
+}
+
+func parseImportCSV(r io.Reader) ([]ImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: reading header: %w", ErrInvalidImportFile, err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[normalizeColumn(name)] = i
+	}
+	if _, ok := columns["email"]; !ok {
+		return nil, fmt.Errorf("%w: missing email column", ErrInvalidImportFile)
+	}
+
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var rows []ImportRow
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidImportFile, err)
+		}
+		line, _ := reader.FieldPos(0)
+
+		isAdmin, err := parseImportBool(field(record, "isadmin"))
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: is_admin must be true or false", ErrInvalidImportFile, line)
+		}
+		rows = append(rows, ImportRow{
+			Line:     line,
+			Username: field(record, "username"),
+			Name:     field(record, "name"),
+			Email:    field(record, "email"),
+			IsAdmin:  isAdmin,
+			Status:   field(record, "status"),
+		})
+		if len(rows) > MaxImportRows {
+			return nil, ErrTooManyImportRows
+		}
+	}
+	return rows, nil
+}
+
+func parseImportJSON(r io.Reader) ([]ImportRow, error) {
+	var rows []ImportRow
+	if err := json.NewDecoder(r).Decode(&rows); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidImportFile, err)
+	}
+	for i := range rows {
+		rows[i].Line = i + 1
+	}
+	return rows, nil
+}
+
+// normalizeColumn lets CSV headers use any case and "is_admin", "is admin" or "isAdmin" spellings.
+func normalizeColumn(name string) string {
+	return strings.NewReplacer("_", "", " ", "", "-", "").Replace(strings.ToLower(strings.TrimSpace(name)))
+}
+
+func parseImportBool(value string) (bool, error) {
+	if value == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(value)
+}
+
+// PreviewImport validates rows without creating anything. It reports invalid rows, rows conflicting with
+// existing accounts or with other rows of the file, and the usernames derived for rows without one.
+func (s *Service) PreviewImport(ctx context.Context, rows []ImportRow) (*ImportPreview, error) {
+	results := make([]ImportRowResult, 0, len(rows))
+	seenEmails := map[string]int{}
+	seenUsernames := map[string]int{}
+
+	for _, row := range rows {
+		result := validateImportRow(row)
+		if result.Result == ImportRowReady {
+			email := strings.ToLower(result.Email)
+			if line, ok := seenEmails[email]; ok {
+				result.conflict(fmt.Sprintf("Email is also used on line %d", line))
+			} else {
+				seenEmails[email] = row.Line
+			}
+			if line, ok := seenUsernames[result.Username]; ok {
+				result.conflict(fmt.Sprintf("Username is also used on line %d", line))
+			} else {
+				seenUsernames[result.Username] = row.Line
+			}
+		}
+		results = append(results, result)
+	}
+
+	if err := s.markExistingConflicts(ctx, results); err != nil {
+		return nil, err
+	}
+
+	preview := &ImportPreview{Total: len(results), Rows: results}
+	for _, result := range results {
+		switch result.Result {
+		case ImportRowReady:
+			preview.Ready++
+		case ImportRowInvalid:
+			preview.Invalid++
+		case ImportRowConflict:
+			preview.Conflicts++
+		case ImportRowCreated, ImportRowFailed:
+		}
+	}
+	return preview, nil
+}
+
+func validateImportRow(row ImportRow) ImportRowResult {
+	result := ImportRowResult{
+		Line:     row.Line,
+		Username: strings.TrimSpace(row.Username),
+		Name:     strings.TrimSpace(row.Name),
+		Email:    strings.TrimSpace(row.Email),
+		IsAdmin:  row.IsAdmin,
+		Status:   strings.ToLower(strings.TrimSpace(row.Status)),
+		Result:   ImportRowReady,
+	}
+
+	if result.Email == "" {
+		result.invalid("Email is required")
+	} else if address, err := mail.ParseAddress(result.Email); err != nil || address.Address != result.Email {
+		result.invalid("Email is invalid")
+	}
+
+	if result.Username == "" && result.Email != "" {
+		result.Username = deriveUsernameFromEmail(result.Email)
+		result.UsernameDerived = true
+	}
+	if result.Username == "" {
+		result.invalid("Username is required")
+	}
+	if result.Name == "" {
+		result.Name = result.Username
+	}
+
+	if result.Status == "" {
+		result.Status = "pending"
+	} else if !slices.Contains(importStatuses, result.Status) {
+		result.invalid("Status must be one of " + strings.Join(importStatuses, ", "))
+	}
+	return result
+}
+
+// markExistingConflicts flags ready rows whose email or username is already taken.
+func (s *Service) markExistingConflicts(ctx context.Context, results []ImportRowResult) error {
+	var emails, usernames []string
+	for _, result := range results {
+		if result.Result == ImportRowReady {
+			emails = append(emails, strings.ToLower(result.Email))
+			usernames = append(usernames, result.Username)
+		}
+	}
+	if len(emails) == 0 {
+		return nil
+	}
+
+	var existing []struct {
+		Email    string
+		Username string
+	}
+	err := pgxscan.Select(ctx, s.db.Conn(ctx), &existing, `
+		SELECT email, username FROM account WHERE LOWER(email) = ANY($1) OR username = ANY($2)
+	`, emails, usernames)
+	if err != nil {
+		return err
+	}
+
+	takenEmails := map[string]bool{}
+	takenUsernames := map[string]bool{}
+	for _, account := range existing {
+		takenEmails[strings.ToLower(account.Email)] = true
+		takenUsernames[account.Username] = true
+	}
+
+	for i := range results {
+		if results[i].Result != ImportRowReady {
+			continue
+		}
+		if takenEmails[strings.ToLower(results[i].Email)] {
+			results[i].conflict("Email already exists")
+		}
+		if takenUsernames[results[i].Username] {
+			results[i].conflict("Username already exists")
+		}
+	}
+	return nil
+}
+
+// importJob runs the import started by StartImport.
+type importJob struct {
+	ImportID uuid.UUID `json:"importId"`
+}
+
+func (importJob) Kind() string {
+	return "account_import"
+}
+
+// RegisterJobs makes queue run the account jobs, and StartImport queue imports on it. It must be called
+// before the queue runs.
+func (s *Service) RegisterJobs(queue *jobs.Queue) {
+	s.jobs = queue
+	jobs.Register(queue, s.runImport)
+}
+
+// StartImport validates rows and queues a job creating the ready ones. The returned import is running;
+// poll GetImport for its outcome.
+func (s *Service) StartImport(ctx context.Context, actorID uuid.UUID, rows []ImportRow) (*AccountImport, error) {
+	preview, err := s.PreviewImport(ctx, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	var accountImport AccountImport
+	err = s.db.WithTx(ctx, func(ctx context.Context) error {
+		err := pgxscan.Get(ctx, s.db.Conn(ctx), &accountImport, `
+			INSERT INTO account_import (created_by, status, total, skipped, results)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id, created_by, status, total, created, failed, skipped, results, created_at, finished_at
+		`, actorID, ImportStatusRunning, preview.Total, preview.Invalid+preview.Conflicts, preview.Rows)
+		if err != nil {
+			return err
+		}
+
+		// queued with the import, so a running import always has a job to finish it. A failed import isn't
+		// retried, since it is marked failed for the caller to start again.
+		_, err = s.jobs.Enqueue(ctx, importJob{ImportID: accountImport.ID}, jobs.EnqueueOptions{MaxAttempts: 1})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.FromContext(ctx).Info("Account import started",
+		"import_id", accountImport.ID,
+		"total", preview.Total,
+		"ready", preview.Ready,
+	)
+
+	return &accountImport, nil
+}
+
+// runImport creates the ready rows of a running import through the repository, one by one, and records the
+// outcome. Rows failing to insert don't stop the import. The accounts and the outcome are written in one
+// transaction, so an import whose worker died is redone from the start once the job's lease expires. An
+// import that can't be completed is marked failed.
+func (s *Service) runImport(ctx context.Context, job importJob) error {
+	log := logger.FromContext(ctx).With("import_id", job.ImportID)
+
+	accountImport, err := s.GetImport(ctx, job.ImportID)
+	if err != nil {
+		return err
+	}
+	if accountImport.Status != ImportStatusRunning {
+		// finished by an earlier run of the job
+		return nil
+	}
+
+	var created, failed int
+	err = s.db.WithTx(ctx, func(ctx context.Context) error {
+		results := slices.Clone(accountImport.Results)
+		created, failed = s.createImportRows(ctx, results)
+		return s.finishImport(ctx, accountImport, ImportStatusCompleted, created, failed, results)
+	})
+	if err != nil {
+		log.Error("Account import failed", "error", err)
+		// recorded even when the job was canceled
+		ctx = context.WithoutCancel(ctx)
+		markErr := s.finishImport(ctx, accountImport, ImportStatusFailed, 0, 0, accountImport.Results)
+		if markErr != nil {
+			log.Error("Failed to mark account import failed", "error", markErr)
+		}
+		return err
+	}
+
+	log.Info("Account import finished", "created", created, "failed", failed)
+	return nil
+}
+
+// createImportRows creates the accounts of the ready results, updating each result with its outcome.
+func (s *Service) createImportRows(ctx context.Context, results []ImportRowResult) (created, failed int) {
+	for i := range results {
+		row := &results[i]
+		if row.Result != ImportRowReady {
+			continue
+		}
+
+		_, err := s.repo.Create(ctx, NewAccount{
+			Username: row.Username,
+			Email:    row.Email,
+			Name:     &row.Name,
+			IsAdmin:  row.IsAdmin,
+			Status:   row.Status,
+		})
+		switch {
+		case err == nil:
+			row.Result = ImportRowCreated
+			created++
+		case errors.Is(err, ErrUserAlreadyExists):
+			row.Result = ImportRowFailed
+			row.Reasons = append(row.Reasons, "User already exists")
+			failed++
+		default:
+			logger.FromContext(ctx).Warn("Failed to import account", "line", row.Line, "error", err)
+			row.Result = ImportRowFailed
+			row.Reasons = append(row.Reasons, "Failed to create user")
+			failed++
+		}
+	}
+	return created, failed
+}
+
+// finishImport records the outcome of an import and notifies the administrator who started it.
+func (s *Service) finishImport(
+	ctx context.Context,
+	accountImport *AccountImport,
+	status ImportStatus,
+	created, failed int,
+	results []ImportRowResult,
+) error {
+	notification := events.NotificationCreated{
+		AccountID: accountImport.CreatedBy,
+		Title:     "Account import finished",
+		Body:      fmt.Sprintf("%d accounts created, %d failed.", created, failed),
+	}
+	if status == ImportStatusFailed {
+		notification.Title = "Account import failed"
+		notification.Body = "No accounts were created."
+	}
+
+	return s.db.WithTx(ctx, func(ctx context.Context) error {
+		tx := s.db.Conn(ctx)
+		if _, err := tx.Exec(ctx, `
+			UPDATE account_import
+			SET status = $2, created = $3, failed = $4, results = $5, finished_at = NOW()
+			WHERE id = $1
+		`, accountImport.ID, status, created, failed, results); err != nil {
+			return err
+		}
+		return events.Record(ctx, tx, notification)
+	})
+}
+
+func (s *Service) GetImport(ctx context.Context, id uuid.UUID) (*AccountImport, error) {
+	var accountImport AccountImport
+	err := pgxscan.Get(ctx, s.db.Conn(ctx), &accountImport, `
+		SELECT id, created_by, status, total, created, failed, skipped, results, created_at, finished_at
+		FROM account_import
+		WHERE id = $1
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	return &accountImport, nil
+}
+
+// WriteImportReport writes the per-row outcome of a finished import as CSV.
+//
+// Returns:
+// - ErrImportInProgress if the import hasn't finished yet
+func (s *Service) WriteImportReport(ctx context.Context, id uuid.UUID, w io.Writer) error {
+	accountImport, err := s.GetImport(ctx, id)
+	if err != nil {
+		return err
+	}
+	if accountImport.Status == ImportStatusRunning {
+		return ErrImportInProgress
+	}
+
+	writer := csv.NewWriter(w)
+	_ = writer.Write([]string{"line", "email", "username", "username_derived", "result", "reasons"})
+	for _, row := range accountImport.Results {
+		_ = writer.Write([]string{
+			strconv.Itoa(row.Line),
+			csvText(row.Email),
+			csvText(row.Username),
+			strconv.FormatBool(row.UsernameDerived),
+			string(row.Result),
+			strings.Join(row.Reasons, "; "),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func (r *ImportRowResult) invalid(reason string) {
+	r.Result = ImportRowInvalid
+	r.Reasons = append(r.Reasons, reason)
+}
+
+// conflict marks the row conflicting unless it is already invalid.
+func (r *ImportRowResult) conflict(reason string) {
+	if r.Result != ImportRowInvalid {
+		r.Result = ImportRowConflict
+	}
+	r.Reasons = append(r.Reasons, reason)
+}
@@ -0,0 +1,335 @@
+package account
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"monolith/internal/config"
+	"monolith/internal/database"
+	"monolith/internal/events"
+	"monolith/internal/jobs"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseImportFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   ImportFormat
+		content  string
+		wantRows []ImportRow
+		wantErr  error
+	}{
+		{
+			name:   "csv with header variants",
+			format: ImportFormatCSV,
+			content: "Email,User Name,Is_Admin,status\n" +
+				"jane@example.com,jane,true,active\n" +
+				"john.doe@example.com,,,\n",
+			wantRows: []ImportRow{
+				{Line: 2, Username: "jane", Email: "jane@example.com", IsAdmin: true, Status: "active"},
+				{Line: 3, Email: "john.doe@example.com"},
+			},
+		},
+		{
+			name:    "csv without email column",
+			format:  ImportFormatCSV,
+			content: "username,name\njane,Jane\n",
+			wantErr: ErrInvalidImportFile,
+		},
+		{
+			name:    "csv with invalid admin flag",
+			format:  ImportFormatCSV,
+			content: "email,is_admin\njane@example.com,maybe\n",
+			wantErr: ErrInvalidImportFile,
+		},
+		{
+			name:    "json",
+			format:  ImportFormatJSON,
+			content: `[{"email":"jane@example.com","name":"Jane","isAdmin":true}]`,
+			wantRows: []ImportRow{
+				{Line: 1, Name: "Jane", Email: "jane@example.com", IsAdmin: true},
+			},
+		},
+		{
+			name:    "malformed json",
+			format:  ImportFormatJSON,
+			content: `{"email":"jane@example.com"}`,
+			wantErr: ErrInvalidImportFile,
+		},
+		{
+			name:    "unsupported format",
+			format:  "xml",
+			content: "<accounts/>",
+			wantErr: ErrInvalidImportFile,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := ParseImportFile(strings.NewReader(tt.content), tt.format)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantRows, rows)
+		})
+	}
+}
+
+func TestParseImportFile_TooManyRows(t *testing.T) {
+	var content strings.Builder
+	content.WriteString("email\n")
+	for range MaxImportRows + 1 {
+		content.WriteString("user@example.com\n")
+	}
+
+	_, err := ParseImportFile(strings.NewReader(content.String()), ImportFormatCSV)
+	require.ErrorIs(t, err, ErrTooManyImportRows)
+}
+
+func TestService_PreviewImport(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	mock.ExpectQuery(`SELECT email, username FROM account WHERE LOWER\(email\) = ANY\(\$1\) OR username = ANY\(\$2\)`).
+		WithArgs([]string{"john.doe@example.com", "taken@example.com"}, []string{"johndoe", "newname"}).
+		WillReturnRows(pgxmock.NewRows([]string{"email", "username"}).AddRow("Taken@example.com", "taken"))
+
+	s := newPostgresService(mock)
+	preview, err := s.PreviewImport(context.Background(), []ImportRow{
+		{Line: 2, Email: "john.doe@example.com"},
+		{Line: 3, Email: "not-an-email"},
+		{Line: 4, Email: "other@example.com", Username: "johndoe"},
+		{Line: 5, Email: "taken@example.com", Username: "newname", Status: "archived"},
+		{Line: 6, Email: "taken@example.com", Username: "newname"},
+	})
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	assert.Equal(t, 5, preview.Total)
+	assert.Equal(t, 1, preview.Ready)
+	assert.Equal(t, 2, preview.Invalid)
+	assert.Equal(t, 2, preview.Conflicts)
+
+	rows := preview.Rows
+	assert.Equal(t, ImportRowReady, rows[0].Result)
+	assert.Equal(t, "johndoe", rows[0].Username)
+	assert.True(t, rows[0].UsernameDerived)
+	assert.Equal(t, "pending", rows[0].Status)
+
+	assert.Equal(t, ImportRowInvalid, rows[1].Result)
+	assert.Equal(t, []string{"Email is invalid"}, rows[1].Reasons)
+
+	assert.Equal(t, ImportRowConflict, rows[2].Result)
+	assert.Equal(t, []string{"Username is also used on line 2"}, rows[2].Reasons)
+
+	assert.Equal(t, ImportRowInvalid, rows[3].Result)
+	assert.Equal(t, []string{"Status must be one of active, pending, disabled"}, rows[3].Reasons)
+
+	assert.Equal(t, ImportRowConflict, rows[4].Result)
+	assert.Equal(t, []string{"Email already exists"}, rows[4].Reasons)
+}
+
+func TestService_StartImport(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	actorID := uuid.New()
+	importID := uuid.New()
+	now := time.Now()
+
+	mock.ExpectQuery(`SELECT email, username FROM account`).
+		WithArgs([]string{"jane@example.com"}, []string{"jane"}).
+		WillReturnRows(pgxmock.NewRows([]string{"email", "username"}))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO account_import`).
+		WithArgs(actorID, ImportStatusRunning, 1, 0, pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows(importColumns).
+			AddRow(importID, actorID, ImportStatusRunning, 1, 0, 0, 0, []ImportRowResult{}, now, (*time.Time)(nil)))
+	mock.ExpectQuery(`INSERT INTO job`).
+		WithArgs("account_import", pgxmock.AnyArg(), (*string)(nil), 1, pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "kind", "status"}).
+			AddRow(uuid.New(), "account_import", jobs.StatusPending))
+	mock.ExpectCommit()
+	mock.ExpectRollback()
+
+	db := &database.DB{Pool: mock}
+	s := NewService(db, NewPostgresRepository(db), nil)
+	s.RegisterJobs(jobs.NewQueue(db, config.JobsConfig{MaxAttempts: 3}))
+
+	accountImport, err := s.StartImport(context.Background(), actorID, []ImportRow{
+		{Line: 2, Username: "jane", Email: "jane@example.com"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, importID, accountImport.ID)
+	assert.Equal(t, ImportStatusRunning, accountImport.Status)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestService_runImport(t *testing.T) {
+	importID := uuid.New()
+	now := time.Now()
+	results := []ImportRowResult{
+		{Line: 2, Username: "jane", Name: "Jane", Email: "jane@example.com", Status: "active", Result: ImportRowReady},
+		{Line: 3, Username: "bad", Email: "bad", Result: ImportRowInvalid, Reasons: []string{"Email is invalid"}},
+		{Line: 4, Username: "john", Name: "john", Email: "john@example.com", Status: "pending", Result: ImportRowReady},
+	}
+	expectImport := func(mock pgxmock.PgxPoolIface, status ImportStatus) {
+		mock.ExpectQuery(`SELECT .+ FROM account_import WHERE id = \$1`).
+			WithArgs(importID).
+			WillReturnRows(pgxmock.NewRows(importColumns).
+				AddRow(importID, uuid.New(), status, 3, 0, 0, 1, results, now, (*time.Time)(nil)))
+	}
+	expectRows := func(mock pgxmock.PgxPoolIface) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO account`).
+			WithArgs("jane", "jane@example.com", new("Jane"), (*string)(nil), false, "active").
+			WillReturnRows(pgxmock.NewRows([]string{"id", "username", "email", "status"}).
+				AddRow(uuid.New(), "jane", "jane@example.com", "active"))
+		expectEvent(mock, events.TypeAccountCreated)
+		mock.ExpectCommit()
+		mock.ExpectRollback()
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO account`).
+			WithArgs("john", "john@example.com", new("john"), (*string)(nil), false, "pending").
+			WillReturnError(&pgconn.PgError{Code: "23505"})
+		mock.ExpectRollback()
+	}
+
+	t.Run("creates the ready rows with the outcome", func(t *testing.T) {
+		mock, err := pgxmock.NewPool()
+		require.NoError(t, err)
+		defer mock.Close()
+
+		expectImport(mock, ImportStatusRunning)
+		mock.ExpectBegin()
+		expectRows(mock)
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE account_import`).
+			WithArgs(importID, ImportStatusCompleted, 1, 1, pgxmock.AnyArg()).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		expectEvent(mock, events.TypeNotificationCreated)
+		mock.ExpectCommit()
+		mock.ExpectRollback()
+		mock.ExpectCommit()
+		mock.ExpectRollback()
+
+		s := newPostgresService(mock)
+		require.NoError(t, s.runImport(context.Background(), importJob{ImportID: importID}))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("marks the import failed when it can't complete", func(t *testing.T) {
+		mock, err := pgxmock.NewPool()
+		require.NoError(t, err)
+		defer mock.Close()
+
+		expectImport(mock, ImportStatusRunning)
+		mock.ExpectBegin()
+		expectRows(mock)
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE account_import`).
+			WithArgs(importID, ImportStatusCompleted, 1, 1, pgxmock.AnyArg()).
+			WillReturnError(errors.New("connection reset"))
+		mock.ExpectRollback()
+		mock.ExpectRollback()
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE account_import`).
+			WithArgs(importID, ImportStatusFailed, 0, 0, results).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		expectEvent(mock, events.TypeNotificationCreated)
+		mock.ExpectCommit()
+		mock.ExpectRollback()
+
+		s := newPostgresService(mock)
+		require.Error(t, s.runImport(context.Background(), importJob{ImportID: importID}))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("skips a finished import", func(t *testing.T) {
+		mock, err := pgxmock.NewPool()
+		require.NoError(t, err)
+		defer mock.Close()
+
+		expectImport(mock, ImportStatusCompleted)
+
+		s := newPostgresService(mock)
+		require.NoError(t, s.runImport(context.Background(), importJob{ImportID: importID}))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestService_WriteImportReport(t *testing.T) {
+	importID := uuid.New()
+	now := time.Now()
+	results := []ImportRowResult{
+		{Line: 2, Username: "johndoe", UsernameDerived: true, Email: "john.doe@example.com", Result: ImportRowCreated},
+		{
+			Line:    3,
+			Email:   "=bad",
+			Result:  ImportRowInvalid,
+			Reasons: []string{"Email is invalid", "Username is required"},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		status     ImportStatus
+		wantReport string
+		wantErr    error
+	}{
+		{
+			name:   "finished import",
+			status: ImportStatusCompleted,
+			wantReport: "line,email,username,username_derived,result,reasons\n" +
+				"2,john.doe@example.com,johndoe,true,created,\n" +
+				"3,'=bad,,false,invalid,Email is invalid; Username is required\n",
+		},
+		{
+			name:    "running import",
+			status:  ImportStatusRunning,
+			wantErr: ErrImportInProgress,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mock.Close()
+
+			mock.ExpectQuery(`SELECT .+ FROM account_import WHERE id = \$1`).
+				WithArgs(importID).
+				WillReturnRows(pgxmock.NewRows(importColumns).
+					AddRow(importID, uuid.New(), tt.status, 2, 1, 0, 1, results, now, &now))
+
+			s := newPostgresService(mock)
+			var report bytes.Buffer
+			err = s.WriteImportReport(context.Background(), importID, &report)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantReport, report.String())
+			}
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// importColumns are the columns of an account import.
+var importColumns = []string{
+	"id", "created_by", "status", "total", "created", "failed", "skipped", "results", "created_at", "finished_at",
+}
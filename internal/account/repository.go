@@ -0,0 +1,59 @@
+package account
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Repository persists accounts for the Service. Lookups and changes of a single account return
+// database.ErrNoRows when there is no match, and writes that would duplicate an email or username return
+// ErrUserAlreadyExists.
+//
+// PostgresRepository records the matching domain events in the transaction of each change; other
+// repositories don't publish events. Bulk target lookups, import bookkeeping and exports work on the database
+// directly.
+type Repository interface {
+	// Exists reports whether an account uses the given email or username.
+	Exists(ctx context.Context, email, username string) (bool, error)
+	// Create stores a new account.
+	Create(ctx context.Context, account NewAccount) (*Account, error)
+	// GetByLogin returns the active account whose email or username is login, including its password hash.
+	GetByLogin(ctx context.Context, login string) (*Account, error)
+	// Get returns the active account with the given ID.
+	Get(ctx context.Context, id uuid.UUID) (*Account, error)
+	// GetPasswordHash returns the password hash of the active account with the given ID.
+	GetPasswordHash(ctx context.Context, id uuid.UUID) (string, error)
+	// List returns the accounts matching filter, most recently created first. It may miss the latest changes
+	// when reads go to a replica.
+	List(ctx context.Context, filter AccountFilter) ([]Account, error)
+	// Update changes the username, name and email of the active account with the given ID.
+	Update(ctx context.Context, id uuid.UUID, req UpdateAccountRequest) (*Account, error)
+	// UpdatePreferences changes the preferences of the active account with the given ID.
+	UpdatePreferences(ctx context.Context, id uuid.UUID, req UpdatePreferencesRequest) (*Account, error)
+	// UpdateLastSeen marks the account as seen now.
+	UpdateLastSeen(ctx context.Context, id uuid.UUID) error
+	// SetPassword replaces the password hash of the account.
+	SetPassword(ctx context.Context, id uuid.UUID, passwordHash string) error
+	// Disable sets the account's status to disabled.
+	Disable(ctx context.Context, id uuid.UUID) error
+	// Enable sets the account's status to active.
+	Enable(ctx context.Context, id uuid.UUID) error
+	// Delete deletes the account.
+	Delete(ctx context.Context, id uuid.UUID) error
+	// SetAdmin grants or takes away the account's admin rights.
+	SetAdmin(ctx context.Context, id uuid.UUID, isAdmin bool) error
+}
+
+// NewAccount is an account to be stored by Repository.Create.
+type NewAccount struct {
+	Username string
+	Email    string
+	Name     *string
+	// PasswordHash is nil for accounts that haven't set a password yet.
+	PasswordHash *string
+	IsAdmin      bool
+	Status       string
+	// Invited accounts are announced with an AccountInvited event rather than AccountCreated.
+	Invited bool
+}
@@ -0,0 +1,228 @@
+package account
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"monolith/internal/database"
+
+	"github.com/google/uuid"
+)
+
+// MemoryRepository keeps accounts in memory, for unit tests of the Service's rules.
+type MemoryRepository struct {
+	mu       sync.RWMutex
+	accounts map[uuid.UUID]*Account
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{accounts: map[uuid.UUID]*Account{}}
+}
+
+func (r *MemoryRepository) Exists(_ context.Context, email, username string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.taken(uuid.Nil, email, username), nil
+}
+
+func (r *MemoryRepository) Create(_ context.Context, newAccount NewAccount) (*Account, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.taken(uuid.Nil, newAccount.Email, newAccount.Username) {
+		return nil, ErrUserAlreadyExists
+	}
+
+	now := time.Now()
+	account := &Account{
+		ID:         uuid.New(),
+		Username:   newAccount.Username,
+		Email:      newAccount.Email,
+		Name:       newAccount.Name,
+		IsAdmin:    newAccount.IsAdmin,
+		LastSeenAt: &now,
+		Status:     newAccount.Status,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if newAccount.PasswordHash != nil {
+		account.Password = *newAccount.PasswordHash
+	}
+	r.accounts[account.ID] = account
+
+	return withoutPassword(account), nil
+}
+
+func (r *MemoryRepository) GetByLogin(_ context.Context, login string) (*Account, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, account := range r.accounts {
+		if (account.Email == login || account.Username == login) && account.Status == StatusActive {
+			found := *account
+			return &found, nil
+		}
+	}
+	return nil, database.ErrNoRows
+}
+
+func (r *MemoryRepository) Get(_ context.Context, id uuid.UUID) (*Account, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	account, err := r.active(id)
+	if err != nil {
+		return nil, err
+	}
+	return withoutPassword(account), nil
+}
+
+func (r *MemoryRepository) GetPasswordHash(_ context.Context, id uuid.UUID) (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	account, err := r.active(id)
+	if err != nil {
+		return "", err
+	}
+	return account.Password, nil
+}
+
+func (r *MemoryRepository) List(_ context.Context, filter AccountFilter) ([]Account, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var accounts []Account
+	for _, account := range r.accounts {
+		if filter.matches(account) {
+			accounts = append(accounts, *withoutPassword(account))
+		}
+	}
+	slices.SortFunc(accounts, func(a, b Account) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+	return accounts, nil
+}
+
+func (r *MemoryRepository) Update(_ context.Context, id uuid.UUID, req UpdateAccountRequest) (*Account, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	account, err := r.active(id)
+	if err != nil {
+		return nil, err
+	}
+	if r.taken(id, req.Email, req.Username) {
+		return nil, ErrUserAlreadyExists
+	}
+	account.Username = req.Username
+	account.Name = &req.Name
+	account.Email = req.Email
+	account.UpdatedAt = time.Now()
+	return withoutPassword(account), nil
+}
+
+func (r *MemoryRepository) UpdatePreferences(
+	_ context.Context,
+	id uuid.UUID,
+	req UpdatePreferencesRequest,
+) (*Account, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	account, err := r.active(id)
+	if err != nil {
+		return nil, err
+	}
+	account.Language = req.Language
+	account.Theme = req.Theme
+	account.Timezone = req.Timezone
+	account.UpdatedAt = time.Now()
+	return withoutPassword(account), nil
+}
+
+func (r *MemoryRepository) UpdateLastSeen(_ context.Context, id uuid.UUID) error {
+	return r.modify(id, func(account *Account) {
+		now := time.Now()
+		account.LastSeenAt = &now
+	})
+}
+
+func (r *MemoryRepository) SetPassword(_ context.Context, id uuid.UUID, passwordHash string) error {
+	return r.modify(id, func(account *Account) {
+		account.Password = passwordHash
+		account.UpdatedAt = time.Now()
+	})
+}
+
+func (r *MemoryRepository) Disable(_ context.Context, id uuid.UUID) error {
+	return r.modify(id, func(account *Account) {
+		account.Status = StatusDisabled
+		account.UpdatedAt = time.Now()
+	})
+}
+
+func (r *MemoryRepository) Enable(_ context.Context, id uuid.UUID) error {
+	return r.modify(id, func(account *Account) {
+		account.Status = StatusActive
+		account.UpdatedAt = time.Now()
+	})
+}
+
+func (r *MemoryRepository) Delete(_ context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.accounts[id]; !ok {
+		return database.ErrNoRows
+	}
+	delete(r.accounts, id)
+	return nil
+}
+
+func (r *MemoryRepository) SetAdmin(_ context.Context, id uuid.UUID, isAdmin bool) error {
+	return r.modify(id, func(account *Account) {
+		account.IsAdmin = isAdmin
+		account.UpdatedAt = time.Now()
+	})
+}
+
+func (r *MemoryRepository) modify(id uuid.UUID, change func(account *Account)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	account, ok := r.accounts[id]
+	if !ok {
+		return database.ErrNoRows
+	}
+	change(account)
+	return nil
+}
+
+// active must be called with mu held.
+func (r *MemoryRepository) active(id uuid.UUID) (*Account, error) {
+	account, ok := r.accounts[id]
+	if !ok || account.Status != StatusActive {
+		return nil, database.ErrNoRows
+	}
+	return account, nil
+}
+
+// taken reports whether an account other than except uses email or username. It must be called with mu held.
+func (r *MemoryRepository) taken(except uuid.UUID, email, username string) bool {
+	for _, account := range r.accounts {
+		if account.ID != except && (account.Email == email || account.Username == username) {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutPassword returns a copy of account that doesn't expose its password hash.
+func withoutPassword(account *Account) *Account {
+	copied := *account
+	copied.Password = ""
+	return &copied
+}
@@ -0,0 +1,130 @@
+package account
+
+import (
+	"context"
+	"testing"
+
+	"monolith/internal/database"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMemoryService() *Service {
+	return NewService(nil, NewMemoryRepository(), nil)
+}
+
+func TestService_RegisterRules(t *testing.T) {
+	ctx := context.Background()
+	s := newMemoryService()
+
+	_, err := s.Register(ctx, RegisterRequest{Username: "jane", Email: "jane@example.com", Password: "short"})
+	require.ErrorIs(t, err, ErrPasswordTooShort)
+
+	account, err := s.Register(ctx, RegisterRequest{
+		Username: "jane", Email: "jane@example.com", Password: "password123", Name: "Jane",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, StatusDisabled, account.Status)
+	assert.False(t, account.IsAdmin)
+	assert.Empty(t, account.Password)
+
+	// registered accounts can't log in until they are enabled
+	_, err = s.GetAccountByLogin(ctx, "jane")
+	require.ErrorIs(t, err, database.ErrNoRows)
+	require.NoError(t, s.EnableAccount(ctx, account.ID))
+	found, err := s.GetAccountByLogin(ctx, "jane@example.com")
+	require.NoError(t, err)
+	require.NoError(t, s.ValidatePassword(found.Password, "password123"))
+
+	_, err = s.Register(ctx, RegisterRequest{Username: "jane", Email: "other@example.com", Password: "password123"})
+	require.ErrorIs(t, err, ErrUserAlreadyExists)
+}
+
+func TestService_CreateAccountRules(t *testing.T) {
+	ctx := context.Background()
+	s := newMemoryService()
+
+	pending, err := s.CreateAccount(ctx, CreateAccountRequest{Username: "pat", Name: "Pat", Email: "pat@example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, StatusPending, pending.Status)
+	assert.False(t, pending.IsAdmin)
+
+	active, err := s.CreateAccount(ctx, CreateAccountRequest{
+		Username: "ada", Name: "Ada", Email: "ada@example.com", Password: new("password123"), IsAdmin: new(true),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, StatusActive, active.Status)
+	assert.True(t, active.IsAdmin)
+	found, err := s.GetAccountByLogin(ctx, "ada")
+	require.NoError(t, err)
+	require.NoError(t, s.ValidatePassword(found.Password, "password123"))
+
+	// an empty password is the same as none
+	empty, err := s.CreateAccount(ctx, CreateAccountRequest{
+		Username: "sam", Name: "Sam", Email: "sam@example.com", Password: new(""),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, StatusPending, empty.Status)
+
+	_, err = s.CreateAccount(ctx, CreateAccountRequest{Username: "pat", Name: "Pat", Email: "pat2@example.com"})
+	require.ErrorIs(t, err, ErrUserAlreadyExists)
+}
+
+func TestService_InviteUsersRules(t *testing.T) {
+	ctx := context.Background()
+	s := newMemoryService()
+	_, err := s.CreateAccount(ctx, CreateAccountRequest{Username: "taken", Name: "Taken", Email: "taken@example.com"})
+	require.NoError(t, err)
+
+	response, err := s.InviteUsers(ctx, InviteUsersRequest{
+		Emails: []string{
+			"John.Doe+work@example.com",
+			"taken@example.com",
+			// derives the same username as the first one
+			"johndoework@example.org",
+		},
+		IsAdmin: true,
+	})
+	require.NoError(t, err)
+
+	require.Len(t, response.Success, 1)
+	invited := response.Success[0]
+	assert.Equal(t, "johndoework", invited.Username)
+	assert.Equal(t, "johndoework", *invited.Name)
+	assert.Equal(t, StatusPending, invited.Status)
+	assert.True(t, invited.IsAdmin)
+
+	assert.Equal(t, []InviteUserFailure{
+		{Email: "taken@example.com", Reason: "User already exists"},
+		{Email: "johndoework@example.org", Reason: "User already exists"},
+	}, response.Failed)
+}
+
+func TestMemoryRepository_List(t *testing.T) {
+	ctx := context.Background()
+	r := NewMemoryRepository()
+	for _, username := range []string{"alice", "bob", "alina"} {
+		_, err := r.Create(ctx, NewAccount{Username: username, Email: username + "@example.com", Status: StatusActive})
+		require.NoError(t, err)
+	}
+	require.NoError(t, r.Disable(ctx, mustGetByLogin(t, r, "alina").ID))
+
+	accounts, err := r.List(ctx, AccountFilter{Status: StatusActive, Search: "AL"})
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	assert.Equal(t, "alice", accounts[0].Username)
+
+	accounts, err = r.List(ctx, AccountFilter{})
+	require.NoError(t, err)
+	require.Len(t, accounts, 3)
+	// most recently created first
+	assert.Equal(t, "alina", accounts[0].Username)
+}
+
+func mustGetByLogin(t *testing.T, r *MemoryRepository, login string) *Account {
+	t.Helper()
+	account, err := r.GetByLogin(context.Background(), login)
+	require.NoError(t, err)
+	return account
+}
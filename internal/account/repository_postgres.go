@@ -0,0 +1,241 @@
+package account
+
+import (
+	"context"
+	"errors"
+
+	"monolith/internal/database"
+	"monolith/internal/events"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/google/uuid"
+)
+
+// PostgresRepository keeps accounts in the account table.
+type PostgresRepository struct {
+	db *database.DB
+}
+
+func NewPostgresRepository(db *database.DB) *PostgresRepository {
+	return &PostgresRepository{db: db}
+}
+
+func (r *PostgresRepository) Exists(ctx context.Context, email, username string) (bool, error) {
+	var existingAccount Account
+	err := pgxscan.Get(ctx, r.db.Conn(ctx), &existingAccount, `
+		SELECT id FROM account WHERE email = $1 OR username = $2
+	`, email, username)
+	if err != nil {
+		// no matching account isn't an error, or Register would fail with not found
+		if errors.Is(err, database.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// Create runs in a transaction of its own, or in a savepoint of the caller's, so a duplicate doesn't abort
+// the caller's transaction.
+func (r *PostgresRepository) Create(ctx context.Context, newAccount NewAccount) (*Account, error) {
+	var account Account
+	err := r.db.WithTx(ctx, func(ctx context.Context) error {
+		tx := r.db.Conn(ctx)
+		err := pgxscan.Get(ctx, tx, &account, `
+			INSERT INTO account (username, email, name, password, is_admin, status, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, NOW(), NOW())
+			RETURNING id, username, email, name, avatar, is_admin, language, theme, timezone,
+			          last_seen_at, status, created_at, updated_at
+		`, newAccount.Username, newAccount.Email, newAccount.Name, newAccount.PasswordHash, newAccount.IsAdmin,
+			newAccount.Status)
+		if err != nil {
+			if database.IsUniqueViolation(err) {
+				return ErrUserAlreadyExists
+			}
+			return err
+		}
+
+		var event events.Event = accountCreated(&account)
+		if newAccount.Invited {
+			event = events.AccountInvited{
+				AccountID: account.ID,
+				Username:  account.Username,
+				Email:     account.Email,
+				IsAdmin:   account.IsAdmin,
+			}
+		}
+		return events.Record(ctx, tx, event)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+func (r *PostgresRepository) GetByLogin(ctx context.Context, login string) (*Account, error) {
+	var account Account
+	err := pgxscan.Get(ctx, r.db.Conn(ctx), &account, `
+		SELECT id, username, email, name, password, is_admin, language, theme, timezone,
+		       last_seen_at, status, created_at, updated_at
+		FROM account
+		WHERE (email = $1 OR username = $1) AND status = 'active'
+	`, login)
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+func (r *PostgresRepository) Get(ctx context.Context, id uuid.UUID) (*Account, error) {
+	var account Account
+	err := pgxscan.Get(ctx, r.db.Conn(ctx), &account, `
+		SELECT id, username, email, name, avatar, is_admin, language, theme, timezone,
+		       last_seen_at, status, created_at, updated_at
+		FROM account
+		WHERE id = $1 AND status = 'active'
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+func (r *PostgresRepository) GetPasswordHash(ctx context.Context, id uuid.UUID) (string, error) {
+	var account Account
+	err := pgxscan.Get(ctx, r.db.Conn(ctx), &account, `
+		SELECT id, password
+		FROM account
+		WHERE id = $1 AND status = 'active'
+	`, id)
+	if err != nil {
+		return "", err
+	}
+	return account.Password, nil
+}
+
+func (r *PostgresRepository) List(ctx context.Context, filter AccountFilter) ([]Account, error) {
+	where, args := filter.where()
+	var accounts []Account
+	err := pgxscan.Select(ctx, r.db.Reader(ctx), &accounts, `
+		SELECT id, username, email, name, avatar, is_admin, language, theme, timezone,
+		       last_seen_at, status, created_at, updated_at
+		FROM account
+		WHERE `+where+`
+		ORDER BY created_at DESC
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+func (r *PostgresRepository) Update(ctx context.Context, id uuid.UUID, req UpdateAccountRequest) (*Account, error) {
+	var account Account
+	err := r.db.WithTx(ctx, func(ctx context.Context) error {
+		tx := r.db.Conn(ctx)
+		err := pgxscan.Get(ctx, tx, &account, `
+			UPDATE account
+			SET username = $1, name = $2, email = $3, updated_at = NOW()
+			WHERE id = $4 AND status = 'active'
+			RETURNING id, username, email, name, avatar, is_admin, language, theme, timezone,
+			          last_seen_at, status, created_at, updated_at
+		`, req.Username, req.Name, req.Email, id)
+		if err != nil {
+			if database.IsUniqueViolation(err) {
+				return ErrUserAlreadyExists
+			}
+			return err
+		}
+
+		return events.Record(ctx, tx, events.AccountUpdated{
+			AccountID: account.ID,
+			Username:  account.Username,
+			Email:     account.Email,
+			Name:      account.Name,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+func (r *PostgresRepository) UpdatePreferences(
+	ctx context.Context,
+	id uuid.UUID,
+	req UpdatePreferencesRequest,
+) (*Account, error) {
+	var account Account
+	err := pgxscan.Get(ctx, r.db.Conn(ctx), &account, `
+		UPDATE account
+		SET language = $1, theme = $2, timezone = $3, updated_at = NOW()
+		WHERE id = $4 AND status = 'active'
+		RETURNING id, username, email, name, is_admin, language, theme, timezone,
+		          last_seen_at, status, created_at, updated_at
+	`, req.Language, req.Theme, req.Timezone, id)
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+func (r *PostgresRepository) UpdateLastSeen(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.Conn(ctx).Exec(ctx, `
+		UPDATE account SET last_seen_at = NOW() WHERE id = $1
+	`, id)
+	return err
+}
+
+func (r *PostgresRepository) SetPassword(ctx context.Context, id uuid.UUID, passwordHash string) error {
+	_, err := r.db.Conn(ctx).Exec(ctx, `
+		UPDATE account SET password = $1, updated_at = NOW() WHERE id = $2
+	`, passwordHash, id)
+	return err
+}
+
+func (r *PostgresRepository) Disable(ctx context.Context, id uuid.UUID) error {
+	return r.change(ctx, `
+		UPDATE account SET status = 'disabled', updated_at = NOW() WHERE id = $1
+	`, id, events.AccountDisabled{AccountID: id})
+}
+
+func (r *PostgresRepository) Enable(ctx context.Context, id uuid.UUID) error {
+	return r.change(ctx, `
+		UPDATE account SET status = 'active', updated_at = NOW() WHERE id = $1
+	`, id, events.AccountEnabled{AccountID: id})
+}
+
+func (r *PostgresRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.change(ctx, `
+		DELETE FROM account WHERE id = $1
+	`, id, events.AccountDeleted{AccountID: id})
+}
+
+func (r *PostgresRepository) SetAdmin(ctx context.Context, id uuid.UUID, isAdmin bool) error {
+	tag, err := r.db.Conn(ctx).Exec(ctx, `
+		UPDATE account SET is_admin = $1, updated_at = NOW() WHERE id = $2
+	`, isAdmin, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return database.ErrNoRows
+	}
+	return nil
+}
+
+// change runs query against the account with the given id and records event with it. It returns
+// database.ErrNoRows if there is no such account.
+func (r *PostgresRepository) change(ctx context.Context, query string, id uuid.UUID, event events.Event) error {
+	return r.db.WithTx(ctx, func(ctx context.Context) error {
+		tx := r.db.Conn(ctx)
+		tag, err := tx.Exec(ctx, query, id)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return database.ErrNoRows
+		}
+		return events.Record(ctx, tx, event)
+	})
+}
@@ -6,6 +6,13 @@ import (
 	"github.com/google/uuid"
 )
 
+// Account statuses
+const (
+	StatusActive   = "active"
+	StatusPending  = "pending"
+	StatusDisabled = "disabled"
+)
+
 type Account struct {
 	ID         uuid.UUID  `json:"id"`
 	Username   string     `json:"username"`
@@ -69,3 +76,131 @@ type ChangePasswordRequest struct {
 	CurrentPassword string `json:"currentPassword" validate:"required"`
 	NewPassword     string `json:"newPassword"     validate:"required,min=8"`
 }
+
+// AccountFilter selects accounts by their attributes. Zero fields match everything.
+type AccountFilter struct {
+	Status  string `json:"status"  query:"status"  validate:"omitempty,oneof=active pending disabled"`
+	IsAdmin *bool  `json:"isAdmin" query:"isAdmin"`
+	// Search matches a case-insensitive substring of the username, email or name.
+	Search string `json:"search" query:"search"`
+}
+
+// BulkAction is an operation applied to every account targeted by a bulk request.
+type BulkAction string
+
+const (
+	BulkActionDisable BulkAction = "disable"
+	BulkActionEnable  BulkAction = "enable"
+	BulkActionDelete  BulkAction = "delete"
+	BulkActionPromote BulkAction = "promote"
+	BulkActionDemote  BulkAction = "demote"
+)
+
+// BulkMode controls how failures of individual accounts affect the others.
+type BulkMode string
+
+const (
+	// BulkModePerItem applies the action to each account independently.
+	BulkModePerItem BulkMode = "per_item"
+	// BulkModeTransaction applies the action to all accounts or none of them.
+	BulkModeTransaction BulkMode = "transaction"
+)
+
+// BulkAccountsRequest targets accounts either by IDs or by a filter, never both.
+type BulkAccountsRequest struct {
+	Action BulkAction     `json:"action" validate:"required,oneof=disable enable delete promote demote"`
+	IDs    []uuid.UUID    `json:"ids"    validate:"max=1000"`
+	Filter *AccountFilter `json:"filter"`
+	Mode   BulkMode       `json:"mode"   validate:"omitempty,oneof=per_item transaction"`
+	// DryRun reports what would happen without changing anything.
+	DryRun bool `json:"dryRun"`
+}
+
+type BulkAccountsResponse struct {
+	Action  BulkAction           `json:"action"`
+	Mode    BulkMode             `json:"mode"`
+	DryRun  bool                 `json:"dryRun"`
+	Success []uuid.UUID          `json:"success"`
+	Failed  []BulkAccountFailure `json:"failed"`
+}
+
+type BulkAccountFailure struct {
+	ID     uuid.UUID `json:"id"`
+	Reason string    `json:"reason"`
+}
+
+// ImportRow is one account of an import file. An empty Username is derived from Email and an empty
+// Status defaults to pending.
+type ImportRow struct {
+	Line     int    `json:"-"`
+	Username string `json:"username"`
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	IsAdmin  bool   `json:"isAdmin"`
+	Status   string `json:"status"`
+}
+
+// ImportRowResult is the outcome of one import row. Rows are ready, invalid or conflicting in a preview,
+// and ready rows end up created or failed once the import runs.
+type ImportRowResult struct {
+	Line            int             `json:"line"`
+	Username        string          `json:"username"`
+	UsernameDerived bool            `json:"usernameDerived"`
+	Name            string          `json:"name"`
+	Email           string          `json:"email"`
+	IsAdmin         bool            `json:"isAdmin"`
+	Status          string          `json:"status"`
+	Result          ImportRowStatus `json:"result"`
+	Reasons         []string        `json:"reasons,omitempty"`
+}
+
+type ImportRowStatus string
+
+const (
+	ImportRowReady    ImportRowStatus = "ready"
+	ImportRowInvalid  ImportRowStatus = "invalid"
+	ImportRowConflict ImportRowStatus = "conflict"
+	ImportRowCreated  ImportRowStatus = "created"
+	ImportRowFailed   ImportRowStatus = "failed"
+)
+
+type ImportPreview struct {
+	Total     int               `json:"total"`
+	Ready     int               `json:"ready"`
+	Invalid   int               `json:"invalid"`
+	Conflicts int               `json:"conflicts"`
+	Rows      []ImportRowResult `json:"rows"`
+}
+
+type ImportStatus string
+
+const (
+	ImportStatusRunning   ImportStatus = "running"
+	ImportStatusCompleted ImportStatus = "completed"
+	ImportStatusFailed    ImportStatus = "failed"
+)
+
+// AccountImport tracks an import committed in the background. Results holds the per-row outcome and is
+// served as a downloadable report.
+type AccountImport struct {
+	ID         uuid.UUID         `json:"id"`
+	CreatedBy  uuid.UUID         `json:"createdBy"`
+	Status     ImportStatus      `json:"status"`
+	Total      int               `json:"total"`
+	Created    int               `json:"created"`
+	Failed     int               `json:"failed"`
+	Skipped    int               `json:"skipped"`
+	Results    []ImportRowResult `json:"-"`
+	CreatedAt  time.Time         `json:"createdAt"`
+	FinishedAt *time.Time        `json:"finishedAt"`
+}
+
+// ExportAccountsRequest selects the accounts, columns and time zone of an export.
+type ExportAccountsRequest struct {
+	AccountFilter
+	Format ExportFormat `query:"format" validate:"required,oneof=csv json ndjson"`
+	// Columns is a comma separated list of ExportColumnNames; empty exports every column.
+	Columns string `query:"columns"`
+	// Timezone is the IANA time zone timestamps are rendered in; empty means UTC.
+	Timezone string `query:"timezone"`
+}
@@ -1,8 +1,12 @@
 package api
 
 import (
-	"errors"
+	"bytes"
+	"context"
 	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
 
 	"monolith/internal/account"
 	"monolith/internal/auth"
@@ -12,26 +16,30 @@ import (
 )
 
 type AccountHandler struct {
-	accountService *account.Service
+	accountService AccountService
+	authService    AuthService
+	tx             Transactor
 }
 
-func NewAccountHandler(accountService *account.Service) *AccountHandler {
+func NewAccountHandler(accountService AccountService, authService AuthService, tx Transactor) *AccountHandler {
 	return &AccountHandler{
 		accountService: accountService,
+		authService:    authService,
+		tx:             tx,
 	}
 }
 
 func (h *AccountHandler) Profile(c *echo.Context) error {
 	user, ok := c.Get("user").(*auth.AuthUser)
 	if !ok {
-		return echo.NewHTTPError(http.StatusUnauthorized, "Invalid user")
+		return auth.ErrAuthenticationRequired
 	}
 
 	userID := user.AccountID
 
 	account, err := h.accountService.GetAccountByID(c.Request().Context(), userID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusNotFound, "Account not found").Wrap(err)
+		return err
 	}
 
 	return c.JSON(http.StatusOK, account)
@@ -40,7 +48,7 @@ func (h *AccountHandler) Profile(c *echo.Context) error {
 func (h *AccountHandler) UpdatePreferences(c *echo.Context) error {
 	user, ok := c.Get("user").(*auth.AuthUser)
 	if !ok {
-		return echo.NewHTTPError(http.StatusUnauthorized, "Invalid user")
+		return auth.ErrAuthenticationRequired
 	}
 
 	userID := user.AccountID
@@ -56,50 +64,91 @@ func (h *AccountHandler) UpdatePreferences(c *echo.Context) error {
 
 	updatedAccount, err := h.accountService.UpdatePreferences(c.Request().Context(), userID, req)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to update preferences").Wrap(err)
+		return err
 	}
 
 	return c.JSON(http.StatusOK, updatedAccount)
 }
 
+// Register creates an account and responds with it wrapped in {"account": ...} (v1).
 func (h *AccountHandler) Register(c *echo.Context) error {
-	var req account.RegisterRequest
-	if err := c.Bind(&req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body").Wrap(err)
+	userAccount, err := h.register(c)
+	if err != nil {
+		return err
 	}
 
-	if err := c.Validate(req); err != nil {
-		return err
+	response := map[string]any{
+		"account": userAccount,
 	}
 
-	userAccount, err := h.accountService.Register(c.Request().Context(), req)
+	return c.JSON(http.StatusCreated, response)
+}
+
+// RegisterV2 creates an account and responds with it unwrapped, like CreateAccount.
+func (h *AccountHandler) RegisterV2(c *echo.Context) error {
+	userAccount, err := h.register(c)
 	if err != nil {
-		switch {
-		case errors.Is(err, account.ErrPasswordTooShort):
-			return echo.NewHTTPError(http.StatusBadRequest, "Password too short").Wrap(err)
-		case errors.Is(err, account.ErrUserAlreadyExists):
-			return echo.NewHTTPError(http.StatusConflict, "User already exists").Wrap(err)
-		default:
-			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to register user").Wrap(err)
-		}
+		return err
 	}
 
-	response := map[string]any{
-		"account": userAccount,
+	return c.JSON(http.StatusCreated, userAccount)
+}
+
+func (h *AccountHandler) register(c *echo.Context) (*account.Account, error) {
+	var req account.RegisterRequest
+	if err := c.Bind(&req); err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "Invalid request body").Wrap(err)
 	}
 
-	return c.JSON(http.StatusCreated, response)
+	if err := c.Validate(req); err != nil {
+		return nil, err
+	}
+
+	return h.accountService.Register(c.Request().Context(), req)
 }
 
 func (h *AccountHandler) GetAccounts(c *echo.Context) error {
-	accounts, err := h.accountService.GetAccounts(c.Request().Context())
+	var filter account.AccountFilter
+	if err := c.Bind(&filter); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid query parameters").Wrap(err)
+	}
+	if err := c.Validate(filter); err != nil {
+		return err
+	}
+
+	accounts, err := h.accountService.GetAccounts(c.Request().Context(), filter)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to retrieve accounts").Wrap(err)
+		return err
 	}
 
 	return c.JSON(http.StatusOK, accounts)
 }
 
+// ExportAccounts streams the accounts matching the list filters as a CSV, JSON or NDJSON attachment.
+func (h *AccountHandler) ExportAccounts(c *echo.Context) error {
+	var req account.ExportAccountsRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid query parameters").Wrap(err)
+	}
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	header := c.Response().Header()
+	header.Set(echo.HeaderContentType, req.Format.ContentType())
+	header.Set(echo.HeaderContentDisposition, `attachment; filename="accounts.`+string(req.Format)+`"`)
+
+	err := h.accountService.ExportAccounts(c.Request().Context(), req, c.Response())
+	if err != nil {
+		if resp, unwrapErr := echo.UnwrapResponse(c.Response()); unwrapErr == nil && !resp.Committed {
+			// nothing was streamed yet, so the error can still be reported as a problem response
+			header.Del(echo.HeaderContentDisposition)
+		}
+		return err
+	}
+	return nil
+}
+
 func (h *AccountHandler) GetAccount(c *echo.Context) error {
 	id := c.Param("id")
 	accountID, err := uuid.Parse(id)
@@ -109,7 +158,7 @@ func (h *AccountHandler) GetAccount(c *echo.Context) error {
 
 	account, err := h.accountService.GetAccount(c.Request().Context(), accountID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusNotFound, "Account not found").Wrap(err)
+		return err
 	}
 
 	return c.JSON(http.StatusOK, account)
@@ -126,7 +175,7 @@ func (h *AccountHandler) CreateAccount(c *echo.Context) error {
 
 	createdAccount, err := h.accountService.CreateAccount(c.Request().Context(), req)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create account").Wrap(err)
+		return err
 	}
 
 	return c.JSON(http.StatusCreated, createdAccount)
@@ -150,7 +199,7 @@ func (h *AccountHandler) UpdateAccount(c *echo.Context) error {
 
 	updatedAccount, err := h.accountService.UpdateAccount(c.Request().Context(), accountID, req)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to update account").Wrap(err)
+		return err
 	}
 
 	return c.JSON(http.StatusOK, updatedAccount)
@@ -165,7 +214,7 @@ func (h *AccountHandler) DisableAccount(c *echo.Context) error {
 
 	err = h.accountService.DisableAccount(c.Request().Context(), accountID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to disable account").Wrap(err)
+		return err
 	}
 
 	return c.NoContent(http.StatusNoContent)
@@ -180,7 +229,7 @@ func (h *AccountHandler) EnableAccount(c *echo.Context) error {
 
 	err = h.accountService.EnableAccount(c.Request().Context(), accountID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to enable account").Wrap(err)
+		return err
 	}
 
 	return c.NoContent(http.StatusNoContent)
@@ -195,7 +244,7 @@ func (h *AccountHandler) DeleteAccount(c *echo.Context) error {
 
 	err = h.accountService.DeleteAccount(c.Request().Context(), accountID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to delete account").Wrap(err)
+		return err
 	}
 
 	return c.NoContent(http.StatusNoContent)
@@ -212,16 +261,140 @@ func (h *AccountHandler) InviteUsers(c *echo.Context) error {
 
 	response, err := h.accountService.InviteUsers(c.Request().Context(), req)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to invite users").Wrap(err)
+		return err
 	}
 
 	return c.JSON(http.StatusOK, response)
 }
 
+// BulkAccounts applies one action to many accounts, reporting the outcome per account.
+func (h *AccountHandler) BulkAccounts(c *echo.Context) error {
+	user, ok := c.Get("user").(*auth.AuthUser)
+	if !ok {
+		return auth.ErrAuthenticationRequired
+	}
+
+	var req account.BulkAccountsRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body").Wrap(err)
+	}
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	response, err := h.accountService.BulkUpdateAccounts(c.Request().Context(), user.AccountID, req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// ImportAccounts validates an uploaded CSV or JSON file of accounts. Without commit=true it responds with
+// a preview; with it the ready rows are created in the background and the import is tracked under
+// /accounts/imports/:id. Uploads are bounded by the server's 2 MB body limit, which MaxImportRows rows fit
+// comfortably.
+func (h *AccountHandler) ImportAccounts(c *echo.Context) error {
+	user, ok := c.Get("user").(*auth.AuthUser)
+	if !ok {
+		return auth.ErrAuthenticationRequired
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Missing import file").Wrap(err)
+	}
+
+	format, err := importFormat(fileHeader.Filename, c.FormValue("format"))
+	if err != nil {
+		return err
+	}
+
+	commit := false
+	if value := c.FormValue("commit"); value != "" {
+		if commit, err = strconv.ParseBool(value); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid commit value").Wrap(err)
+		}
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Failed to read import file").Wrap(err)
+	}
+	defer file.Close()
+
+	rows, err := account.ParseImportFile(file, format)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	if !commit {
+		preview, err := h.accountService.PreviewImport(ctx, rows)
+		if err != nil {
+			return err
+		}
+		return c.JSON(http.StatusOK, preview)
+	}
+
+	accountImport, err := h.accountService.StartImport(ctx, user.AccountID, rows)
+	if err != nil {
+		return err
+	}
+
+	importsPath := strings.TrimSuffix(c.Request().URL.Path, "/import") + "/imports/"
+	c.Response().Header().Set(echo.HeaderLocation, importsPath+accountImport.ID.String())
+	return c.JSON(http.StatusAccepted, accountImport)
+}
+
+// importFormat picks the format from the explicit format field or else the file extension.
+func importFormat(filename, format string) (account.ImportFormat, error) {
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(filename)), ".")
+	}
+	switch account.ImportFormat(format) {
+	case account.ImportFormatCSV, account.ImportFormatJSON:
+		return account.ImportFormat(format), nil
+	default:
+		return "", echo.NewHTTPError(http.StatusBadRequest, "Import file must be CSV or JSON")
+	}
+}
+
+func (h *AccountHandler) GetAccountImport(c *echo.Context) error {
+	importID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid import ID format").Wrap(err)
+	}
+
+	accountImport, err := h.accountService.GetImport(c.Request().Context(), importID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, accountImport)
+}
+
+// DownloadAccountImportReport serves the per-row outcome of a finished import as a CSV attachment.
+func (h *AccountHandler) DownloadAccountImportReport(c *echo.Context) error {
+	importID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid import ID format").Wrap(err)
+	}
+
+	var report bytes.Buffer
+	if err := h.accountService.WriteImportReport(c.Request().Context(), importID, &report); err != nil {
+		return err
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition,
+		`attachment; filename="account-import-`+importID.String()+`.csv"`)
+	return c.Blob(http.StatusOK, "text/csv; charset=utf-8", report.Bytes())
+}
+
 func (h *AccountHandler) ChangePassword(c *echo.Context) error {
 	user, ok := c.Get("user").(*auth.AuthUser)
 	if !ok {
-		return echo.NewHTTPError(http.StatusUnauthorized, "Invalid user")
+		return auth.ErrAuthenticationRequired
 	}
 
 	var req account.ChangePasswordRequest
@@ -233,16 +406,15 @@ func (h *AccountHandler) ChangePassword(c *echo.Context) error {
 		return err
 	}
 
-	err := h.accountService.ChangePassword(c.Request().Context(), user.AccountID, req)
-	if err != nil {
-		switch {
-		case errors.Is(err, account.ErrPasswordTooShort):
-			return echo.NewHTTPError(http.StatusBadRequest, "Password too short").Wrap(err)
-		case errors.Is(err, account.ErrInvalidPassword):
-			return echo.NewHTTPError(http.StatusBadRequest, "Current password is incorrect").Wrap(err)
-		default:
-			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to change password").Wrap(err)
+	// the other sessions may have been opened with the old password, so they end with it
+	err := h.tx.WithTx(c.Request().Context(), func(ctx context.Context) error {
+		if err := h.accountService.ChangePassword(ctx, user.AccountID, req); err != nil {
+			return err
 		}
+		return h.authService.RevokeOtherSessions(ctx, user.AccountID, user.SessionID)
+	})
+	if err != nil {
+		return err
 	}
 
 	return c.NoContent(http.StatusNoContent)
@@ -3,7 +3,6 @@ package api
 import (
 	"bytes"
 	"encoding/json"
-	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -12,6 +11,8 @@ import (
 	"monolith/internal/account"
 	"monolith/internal/auth"
 	"monolith/internal/database"
+	"monolith/internal/events"
+	"monolith/internal/testutil"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v5"
@@ -91,8 +92,8 @@ func TestAccountHandler_Profile(t *testing.T) {
 			tt.setupMock(mock)
 
 			db := &database.DB{Pool: mock}
-			accountService := account.NewService(db)
-			handler := NewAccountHandler(accountService)
+			accountService := account.NewService(db, account.NewPostgresRepository(db), nil)
+			handler := NewAccountHandler(accountService, nil, nil)
 
 			e := echo.New()
 			req := httptest.NewRequest(http.MethodGet, "/api/account/profile", nil)
@@ -107,10 +108,7 @@ func TestAccountHandler_Profile(t *testing.T) {
 
 			if tt.wantStatus >= 400 {
 				require.Error(t, err)
-				httpErr := &echo.HTTPError{}
-				ok := errors.As(err, &httpErr)
-				require.True(t, ok)
-				assert.Equal(t, tt.wantStatus, httpErr.Code)
+				assert.Equal(t, tt.wantStatus, problemFromError(err).Status)
 			} else {
 				require.NoError(t, err)
 				assert.Equal(t, tt.wantStatus, rec.Code)
@@ -171,8 +169,8 @@ func TestAccountHandler_Register(t *testing.T) {
 			tt.setupMock(mock)
 
 			db := &database.DB{Pool: mock}
-			accountService := account.NewService(db)
-			handler := NewAccountHandler(accountService)
+			accountService := account.NewService(db, account.NewPostgresRepository(db), nil)
+			handler := NewAccountHandler(accountService, nil, nil)
 
 			e := echo.New()
 			e.Validator = &mockValidator{}
@@ -193,10 +191,7 @@ func TestAccountHandler_Register(t *testing.T) {
 
 			if tt.wantStatus >= 400 {
 				require.Error(t, err)
-				httpErr := &echo.HTTPError{}
-				ok := errors.As(err, &httpErr)
-				require.True(t, ok)
-				assert.Equal(t, tt.wantStatus, httpErr.Code)
+				assert.Equal(t, tt.wantStatus, problemFromError(err).Status)
 			} else {
 				require.NoError(t, err)
 				assert.Equal(t, tt.wantStatus, rec.Code)
@@ -263,8 +258,8 @@ func TestAccountHandler_UpdatePreferences(t *testing.T) {
 			tt.setupMock(mock)
 
 			db := &database.DB{Pool: mock}
-			accountService := account.NewService(db)
-			handler := NewAccountHandler(accountService)
+			accountService := account.NewService(db, account.NewPostgresRepository(db), nil)
+			handler := NewAccountHandler(accountService, nil, nil)
 
 			e := echo.New()
 			e.Validator = &mockValidator{}
@@ -283,10 +278,7 @@ func TestAccountHandler_UpdatePreferences(t *testing.T) {
 
 			if tt.wantStatus >= 400 {
 				require.Error(t, err)
-				httpErr := &echo.HTTPError{}
-				ok := errors.As(err, &httpErr)
-				require.True(t, ok)
-				assert.Equal(t, tt.wantStatus, httpErr.Code)
+				assert.Equal(t, tt.wantStatus, problemFromError(err).Status)
 			} else {
 				require.NoError(t, err)
 				assert.Equal(t, tt.wantStatus, rec.Code)
@@ -298,7 +290,7 @@ func TestAccountHandler_UpdatePreferences(t *testing.T) {
 }
 
 func TestAccountHandler_ChangePassword(t *testing.T) {
-	accountID := uuid.New()
+	accountID, sessionID := uuid.New(), uuid.New()
 	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("currentpass123"), 12)
 
 	tests := []struct {
@@ -314,7 +306,7 @@ func TestAccountHandler_ChangePassword(t *testing.T) {
 				AccountID: accountID,
 				Email:     "test@example.com",
 				IsAdmin:   false,
-				SessionID: uuid.New(),
+				SessionID: sessionID,
 			},
 			body: map[string]any{
 				"currentPassword": "currentpass123",
@@ -323,6 +315,7 @@ func TestAccountHandler_ChangePassword(t *testing.T) {
 			setupMock: func(mock pgxmock.PgxPoolIface) {
 				rows := pgxmock.NewRows([]string{"id", "password"}).
 					AddRow(accountID, string(hashedPassword))
+				mock.ExpectBegin()
 				mock.ExpectQuery(`SELECT id, password FROM account WHERE id = \$1 AND status = 'active'`).
 					WithArgs(accountID).
 					WillReturnRows(rows)
@@ -330,6 +323,17 @@ func TestAccountHandler_ChangePassword(t *testing.T) {
 				mock.ExpectExec(`UPDATE account SET password = \$1, updated_at = NOW\(\) WHERE id = \$2`).
 					WithArgs(pgxmock.AnyArg(), accountID).
 					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+				// the other sessions are revoked in a savepoint of the same transaction
+				mock.ExpectBegin()
+				mock.ExpectQuery(`UPDATE auth_session SET revoked_at = NOW\(\) WHERE account_id = \$1 AND id <> \$2`).
+					WithArgs(accountID, sessionID).
+					WillReturnRows(pgxmock.NewRows([]string{"id", "account_id"}).AddRow(uuid.New(), accountID))
+				expectEvent(mock, events.TypeSessionRevoked)
+				mock.ExpectCommit()
+				mock.ExpectRollback()
+				mock.ExpectCommit()
+				mock.ExpectRollback()
 			},
 			wantStatus: http.StatusNoContent,
 		},
@@ -355,9 +359,11 @@ func TestAccountHandler_ChangePassword(t *testing.T) {
 			setupMock: func(mock pgxmock.PgxPoolIface) {
 				rows := pgxmock.NewRows([]string{"id", "password"}).
 					AddRow(accountID, string(hashedPassword))
+				mock.ExpectBegin()
 				mock.ExpectQuery(`SELECT id, password FROM account WHERE id = \$1 AND status = 'active'`).
 					WithArgs(accountID).
 					WillReturnRows(rows)
+				mock.ExpectRollback()
 			},
 			wantStatus: http.StatusBadRequest,
 		},
@@ -373,7 +379,10 @@ func TestAccountHandler_ChangePassword(t *testing.T) {
 				"currentPassword": "currentpass123",
 				"newPassword":     "short",
 			},
-			setupMock:  func(mock pgxmock.PgxPoolIface) {},
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectRollback()
+			},
 			wantStatus: http.StatusBadRequest,
 		},
 	}
@@ -386,8 +395,8 @@ func TestAccountHandler_ChangePassword(t *testing.T) {
 			tt.setupMock(mock)
 
 			db := &database.DB{Pool: mock}
-			accountService := account.NewService(db)
-			handler := NewAccountHandler(accountService)
+			accountService := account.NewService(db, account.NewPostgresRepository(db), nil)
+			handler := NewAccountHandler(accountService, newAuthService(db), db)
 
 			e := echo.New()
 			e.Validator = &mockValidator{}
@@ -406,10 +415,7 @@ func TestAccountHandler_ChangePassword(t *testing.T) {
 
 			if tt.wantStatus >= 400 {
 				require.Error(t, err)
-				httpErr := &echo.HTTPError{}
-				ok := errors.As(err, &httpErr)
-				require.True(t, ok)
-				assert.Equal(t, tt.wantStatus, httpErr.Code)
+				assert.Equal(t, tt.wantStatus, problemFromError(err).Status)
 			} else {
 				require.NoError(t, err)
 				assert.Equal(t, tt.wantStatus, rec.Code)
@@ -419,3 +425,80 @@ func TestAccountHandler_ChangePassword(t *testing.T) {
 		})
 	}
 }
+
+func TestAccountHandler_GetAccounts(t *testing.T) {
+	columns := []string{
+		"id", "username", "email", "name", "avatar", "is_admin", "language", "theme", "timezone",
+		"last_seen_at", "status", "created_at", "updated_at",
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		setupMock  func(mock pgxmock.PgxPoolIface)
+		wantStatus int
+	}{
+		{
+			name:  "without filters",
+			query: "",
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery(`SELECT .+ FROM account\s+WHERE TRUE\s+ORDER BY created_at DESC`).
+					WillReturnRows(pgxmock.NewRows(columns))
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:  "with filters",
+			query: "?status=pending&isAdmin=false&search=jo",
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery(`SELECT .+ FROM account\s+WHERE TRUE AND status = \$1 AND is_admin = \$2 AND`).
+					WithArgs("pending", false, "%jo%").
+					WillReturnRows(pgxmock.NewRows(columns))
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "invalid admin flag",
+			query:      "?isAdmin=maybe",
+			setupMock:  func(mock pgxmock.PgxPoolIface) {},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, _ := pgxmock.NewPool()
+			defer mock.Close()
+
+			tt.setupMock(mock)
+
+			handler := NewAccountHandler(newAccountService(&database.DB{Pool: mock}), nil, nil)
+
+			e := echo.New()
+			e.Validator = &mockValidator{}
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/accounts"+tt.query, nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			err := handler.GetAccounts(c)
+
+			if tt.wantStatus >= 400 {
+				require.Error(t, err)
+				assert.Equal(t, tt.wantStatus, problemFromError(err).Status)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantStatus, rec.Code)
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func newAccountService(db *database.DB) *account.Service {
+	return account.NewService(db, account.NewPostgresRepository(db), nil)
+}
+
+func newAuthService(db *database.DB) *auth.Service {
+	return auth.NewService(auth.NewPostgresSessionStore(db), testutil.NewTestSecurityConfig(), nil)
+}
@@ -2,50 +2,641 @@ package api
 
 import (
 	"net/http"
+	"slices"
+	"strings"
+	"time"
 
 	"monolith"
+	"monolith/internal/account"
+	"monolith/internal/auth"
+	"monolith/internal/database"
+	"monolith/internal/jobs"
+	"monolith/internal/login"
 	mw "monolith/internal/middleware"
+	"monolith/internal/openapi"
+	"monolith/internal/realtime"
+	"monolith/internal/scheduler"
+	"monolith/internal/scim"
+	"monolith/internal/webhook"
 
 	"github.com/labstack/echo/v5"
 )
 
+const (
+	// apiPrefix is the unversioned prefix. Routes under it without a version are a deprecated alias of v1.
+	apiPrefix = "/api"
+	apiV1     = apiPrefix + "/v1"
+	apiV2     = apiPrefix + "/v2"
+	// scimPrefix serves SCIM 2.0 provisioning, outside the API versioning scheme.
+	scimPrefix = "/scim/v2"
+)
+
+// legacyAPIDeprecatedSince is when /api/v1 became canonical and the unversioned alias was deprecated.
+var legacyAPIDeprecatedSince = time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC)
+
+// routeAccess selects the middleware chain a versioned route is mounted behind.
+type routeAccess int
+
+const (
+	accessPublic routeAccess = iota
+	accessSession
+	accessAdmin
+)
+
+// route is a versioned API route; path is relative to the version prefix. op documents the route in the
+// OpenAPI document, so a route can't be added without its documentation.
+type route struct {
+	method  string
+	path    string
+	access  routeAccess
+	handler echo.HandlerFunc
+	op      *openapi.Operation
+}
+
+// routeSet is the routes of one API version.
+type routeSet []route
+
+// with returns a copy of rs where routes with the same method and path are replaced by overrides and
+// new ones appended. Later versions are built from the previous one, so they only list what changed.
+func (rs routeSet) with(overrides ...route) routeSet {
+	result := make(routeSet, len(rs), len(rs)+len(overrides))
+	copy(result, rs)
+
+	for _, override := range overrides {
+		replaced := false
+		for i := range result {
+			if result[i].method == override.method && result[i].path == override.path {
+				result[i] = override
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			result = append(result, override)
+		}
+	}
+	return result
+}
+
 // RegisterRoutes configures all the application routes.
 func (hs *HTTPServer) RegisterRoutes() {
 	authHandler := NewAuthHandler(hs.loginService, hs.authService)
-	accountHandler := NewAccountHandler(hs.accountService)
+	accountHandler := NewAccountHandler(hs.accountService, hs.authService, hs.db)
 	authSessionHandler := NewSessionHandler(hs.authService)
+	webhookHandler := NewWebhookHandler(hs.webhooks)
+	jobHandler := NewJobHandler(hs.jobs)
+	schedulerHandler := NewSchedulerHandler(hs.scheduler)
+	diagnosticsHandler := NewDiagnosticsHandler(hs.db)
+	eventStreamHandler := NewEventStreamHandler(hs.realtime, hs.config.Events.StreamHeartbeat)
+	healthHandler := NewHealthHandler(hs.health)
+
+	doc := NewOpenAPIDocument(hs.config.Security.LoginCookieName)
+	openAPIHandler := NewOpenAPIHandler(doc)
+	hs.openAPI = doc
 
-	api := hs.echo.Group("/api")
+	hs.echo.GET("/livez", healthHandler.Livez)
+	hs.echo.GET("/readyz", healthHandler.Readyz)
 
-	// Public auth routes
-	api.POST("/login", authHandler.Login)
-	api.POST("/logout", authHandler.Logout)
+	// Unversioned system routes
+	api := hs.echo.Group(apiPrefix)
 	api.GET("/version", func(c *echo.Context) error {
 		return c.JSON(http.StatusOK, monolith.GetVersionInfo())
 	})
-	api.GET("/health", func(c *echo.Context) error {
-		return c.JSON(http.StatusOK, map[string]string{"status": "ok"})
-	})
+	api.GET("/health", healthHandler.Livez)
+	api.GET("/openapi.json", openAPIHandler.Spec)
+	api.GET("/docs", openAPIHandler.Docs)
 
-	// Protected routes
-	protected := api.Group("", mw.SessionAuth(hs.authService, hs.config.Security))
+	accountSchema := doc.SchemaFor(account.Account{})
+	messageSchema := doc.SchemaFor(messageResponse{})
+	importSchema := doc.SchemaFor(account.AccountImport{})
+	webhookSchema := doc.SchemaFor(webhook.Endpoint{})
+	deliverySchema := doc.SchemaFor(webhook.Delivery{})
+	jobSchema := doc.SchemaFor(jobs.Job{})
+	taskSchema := doc.SchemaFor(scheduler.Task{})
+	queryStatsSchema := doc.SchemaFor(database.QueryStatsReport{})
+	exportRowSchema := &openapi.Schema{Type: "object", Description: "The selected columns of an account"}
 
-	protected.GET("/account/sessions", authSessionHandler.GetSessions)
-	protected.DELETE("/account/sessions/:sessionId", authSessionHandler.RevokeSession)
-	protected.POST("/account/sessions/rotate", authSessionHandler.RotateSession)
+	v1 := routeSet{
+		// Public auth routes
+		{http.MethodPost, "/login", accessPublic, authHandler.Login, &openapi.Operation{
+			OperationID: "login",
+			Summary:     "Log in and start a session",
+			Description: "Sets the session cookie on success.",
+			Tags:        []string{tagAuth},
+			Security:    securityPublic,
+			RequestBody: openapi.JSONBody(doc.SchemaFor(login.UserLoginRequest{})),
+			Responses: responses(map[int]openapi.Response{
+				http.StatusOK:           openapi.JSONResponse("Logged in", messageSchema),
+				http.StatusBadRequest:   openapi.ResponseRef(responseBadRequest),
+				http.StatusUnauthorized: openapi.ResponseRef(responseUnauthorized),
+			}),
+		}},
+		{http.MethodPost, "/logout", accessPublic, authHandler.Logout, &openapi.Operation{
+			OperationID: "logout",
+			Summary:     "Revoke the current session and clear cookies",
+			Tags:        []string{tagAuth},
+			Security:    securityPublic,
+			Responses: responses(map[int]openapi.Response{
+				http.StatusOK: openapi.JSONResponse("Logged out", messageSchema),
+			}),
+		}},
 
-	protected.GET("/account/profile", accountHandler.Profile)
-	protected.PATCH("/account/preferences", accountHandler.UpdatePreferences)
-	protected.POST("/account/register", accountHandler.Register)
+		// Protected routes
+		{http.MethodGet, "/account/sessions", accessSession, authSessionHandler.GetSessions, &openapi.Operation{
+			OperationID: "listSessions",
+			Summary:     "List the current user's active sessions",
+			Tags:        []string{tagSessions},
+			Security:    securitySession,
+			Responses: responses(map[int]openapi.Response{
+				http.StatusOK:           openapi.JSONResponse("Active sessions", doc.SchemaFor([]auth.UserSession{})),
+				http.StatusUnauthorized: openapi.ResponseRef(responseUnauthorized),
+			}),
+		}},
+		{http.MethodDelete, "/account/sessions/:sessionId", accessSession, authSessionHandler.RevokeSession,
+			&openapi.Operation{
+				OperationID: "revokeSession",
+				Summary:     "Revoke one of the current user's sessions",
+				Tags:        []string{tagSessions},
+				Security:    securitySession,
+				Responses: responses(map[int]openapi.Response{
+					http.StatusOK:           openapi.JSONResponse("Session revoked", messageSchema),
+					http.StatusBadRequest:   openapi.ResponseRef(responseBadRequest),
+					http.StatusUnauthorized: openapi.ResponseRef(responseUnauthorized),
+					http.StatusNotFound:     openapi.ResponseRef(responseNotFound),
+				}),
+			}},
+		{http.MethodPost, "/account/sessions/rotate", accessSession, authSessionHandler.RotateSession,
+			&openapi.Operation{
+				OperationID: "rotateSession",
+				Summary:     "Rotate the current session token",
+				Tags:        []string{tagSessions},
+				Security:    securitySession,
+				Responses: responses(map[int]openapi.Response{
+					http.StatusOK:           openapi.JSONResponse("Session rotated", messageSchema),
+					http.StatusUnauthorized: openapi.ResponseRef(responseUnauthorized),
+				}),
+			}},
 
-	// Admin-only routes
+		{http.MethodGet, "/account/profile", accessSession, accountHandler.Profile, &openapi.Operation{
+			OperationID: "getProfile",
+			Summary:     "Get the current user's account",
+			Tags:        []string{tagAccount},
+			Security:    securitySession,
+			Responses: responses(map[int]openapi.Response{
+				http.StatusOK:           openapi.JSONResponse("Account", accountSchema),
+				http.StatusUnauthorized: openapi.ResponseRef(responseUnauthorized),
+				http.StatusNotFound:     openapi.ResponseRef(responseNotFound),
+			}),
+		}},
+		{http.MethodPatch, "/account/preferences", accessSession, accountHandler.UpdatePreferences, &openapi.Operation{
+			OperationID: "updatePreferences",
+			Summary:     "Update language, theme and timezone",
+			Tags:        []string{tagAccount},
+			Security:    securitySession,
+			RequestBody: openapi.JSONBody(doc.SchemaFor(account.UpdatePreferencesRequest{})),
+			Responses: responses(map[int]openapi.Response{
+				http.StatusOK:           openapi.JSONResponse("Updated account", accountSchema),
+				http.StatusBadRequest:   openapi.ResponseRef(responseValidationFail),
+				http.StatusUnauthorized: openapi.ResponseRef(responseUnauthorized),
+			}),
+		}},
+		{http.MethodPost, "/account/register", accessSession, accountHandler.Register, &openapi.Operation{
+			OperationID: "register",
+			Summary:     "Register a new account",
+			Tags:        []string{tagAccount},
+			Security:    securitySession,
+			RequestBody: openapi.JSONBody(doc.SchemaFor(account.RegisterRequest{})),
+			Responses: responses(map[int]openapi.Response{
+				http.StatusCreated:      openapi.JSONResponse("Registered account", doc.SchemaFor(registerResponse{})),
+				http.StatusBadRequest:   openapi.ResponseRef(responseValidationFail),
+				http.StatusUnauthorized: openapi.ResponseRef(responseUnauthorized),
+				http.StatusConflict:     openapi.ResponseRef(responseConflict),
+			}),
+		}},
+
+		{http.MethodGet, "/events", accessSession, eventStreamHandler.Stream, &openapi.Operation{
+			OperationID: "streamEvents",
+			Summary:     "Stream events for the current session",
+			Description: "Server-sent events telling the web app to react immediately: " +
+				realtime.EventSessionRevoked + " when the session was revoked, after which the stream ends, " +
+				realtime.EventAccountUpdated + " when the account changed and " + realtime.EventNotification +
+				" for messages to the user. A comment line is sent as a heartbeat while idle. Clients " +
+				"reconnecting with Last-Event-ID first get the events they missed.",
+			Tags:     []string{tagAccount},
+			Security: securitySession,
+			Parameters: []openapi.Parameter{
+				openapi.HeaderParameter(headerLastEventID, "ID of the last event received, to resume after a reconnect",
+					&openapi.Schema{Type: "integer"}),
+			},
+			Responses: responses(map[int]openapi.Response{
+				http.StatusOK:           openapi.ContentResponse("Event stream", "text/event-stream", openapi.String()),
+				http.StatusUnauthorized: openapi.ResponseRef(responseUnauthorized),
+			}),
+		}},
+
+		// Admin-only routes
+		{http.MethodGet, "/accounts", accessAdmin, accountHandler.GetAccounts, &openapi.Operation{
+			OperationID: "listAccounts",
+			Summary:     "List accounts",
+			Tags:        []string{tagAdmin},
+			Security:    securitySession,
+			Parameters:  accountFilterParameters,
+			Responses: adminResponses(map[int]openapi.Response{
+				http.StatusOK:         openapi.JSONResponse("Accounts", openapi.ArrayOf(accountSchema)),
+				http.StatusBadRequest: openapi.ResponseRef(responseValidationFail),
+			}),
+		}},
+		{http.MethodGet, "/accounts/export", accessAdmin, accountHandler.ExportAccounts, &openapi.Operation{
+			OperationID: "exportAccounts",
+			Summary:     "Export accounts",
+			Description: "Streams the accounts matching the list filters. Timestamps are RFC 3339 in the " +
+				"requested time zone. A failure after streaming started truncates the output.",
+			Tags:     []string{tagAdmin},
+			Security: securitySession,
+			Parameters: append(slices.Clone(accountFilterParameters),
+				openapi.QueryParameter("format", "Output format", &openapi.Schema{
+					Type: "string",
+					Enum: []any{account.ExportFormatCSV, account.ExportFormatJSON, account.ExportFormatNDJSON},
+				}),
+				openapi.QueryParameter("columns", "Comma separated columns to export, all by default: "+
+					strings.Join(account.ExportColumnNames(), ", "), openapi.String()),
+				openapi.QueryParameter("timezone", "IANA time zone of timestamps, UTC by default",
+					openapi.String()),
+			),
+			Responses: adminResponses(map[int]openapi.Response{
+				http.StatusOK: {
+					Description: "Accounts as an attachment",
+					Content: map[string]openapi.MediaType{
+						account.ExportFormatCSV.ContentType():    {Schema: openapi.String()},
+						account.ExportFormatJSON.ContentType():   {Schema: openapi.ArrayOf(exportRowSchema)},
+						account.ExportFormatNDJSON.ContentType(): {Schema: openapi.String()},
+					},
+				},
+				http.StatusBadRequest: openapi.ResponseRef(responseValidationFail),
+			}),
+		}},
+		{http.MethodPost, "/accounts", accessAdmin, accountHandler.CreateAccount, &openapi.Operation{
+			OperationID: "createAccount",
+			Summary:     "Create an account",
+			Description: "Accounts created without a password stay pending until they set one.",
+			Tags:        []string{tagAdmin},
+			Security:    securitySession,
+			RequestBody: openapi.JSONBody(doc.SchemaFor(account.CreateAccountRequest{})),
+			Responses: adminResponses(map[int]openapi.Response{
+				http.StatusCreated:    openapi.JSONResponse("Created account", accountSchema),
+				http.StatusBadRequest: openapi.ResponseRef(responseValidationFail),
+				http.StatusConflict:   openapi.ResponseRef(responseConflict),
+			}),
+		}},
+		{http.MethodPost, "/accounts/invite", accessAdmin, accountHandler.InviteUsers, &openapi.Operation{
+			OperationID: "inviteUsers",
+			Summary:     "Invite users by email",
+			Tags:        []string{tagAdmin},
+			Security:    securitySession,
+			RequestBody: openapi.JSONBody(doc.SchemaFor(account.InviteUsersRequest{})),
+			Responses: adminResponses(map[int]openapi.Response{
+				http.StatusOK: openapi.JSONResponse("Per-email results",
+					doc.SchemaFor(account.InviteUsersResponse{})),
+				http.StatusBadRequest: openapi.ResponseRef(responseValidationFail),
+			}),
+		}},
+		{http.MethodPost, "/accounts/bulk", accessAdmin, accountHandler.BulkAccounts, &openapi.Operation{
+			OperationID: "bulkAccounts",
+			Summary:     "Apply an action to many accounts",
+			Description: "Disables, enables, deletes, promotes or demotes the accounts listed in `ids` or " +
+				"matched by `filter`, which needs at least one criterion. In `transaction` mode nothing changes " +
+				"unless every account succeeds. Disabled and deleted accounts have their sessions revoked.",
+			Tags:        []string{tagAdmin},
+			Security:    securitySession,
+			RequestBody: openapi.JSONBody(doc.SchemaFor(account.BulkAccountsRequest{})),
+			Responses: adminResponses(map[int]openapi.Response{
+				http.StatusOK: openapi.JSONResponse("Per-account results",
+					doc.SchemaFor(account.BulkAccountsResponse{})),
+				http.StatusBadRequest: openapi.ResponseRef(responseValidationFail),
+			}),
+		}},
+		{http.MethodPost, "/accounts/import", accessAdmin, accountHandler.ImportAccounts, &openapi.Operation{
+			OperationID: "importAccounts",
+			Summary:     "Import accounts from a CSV or JSON file",
+			Description: "Validates every row and responds with a preview of invalid rows, conflicts and derived " +
+				"usernames. With `commit=true` the ready rows are created in the background instead; the " +
+				"response's Location header points at the import. The request body may be at most 2 MB.",
+			Tags:     []string{tagAdmin},
+			Security: securitySession,
+			RequestBody: openapi.MultipartBody(&openapi.Schema{
+				Type: "object",
+				Properties: map[string]*openapi.Schema{
+					"file": openapi.Binary(),
+					"format": {Type: "string", Enum: []any{account.ImportFormatCSV, account.ImportFormatJSON},
+						Description: "Defaults to the file extension"},
+					"commit": {Type: "boolean", Description: "Create the accounts instead of previewing"},
+				},
+				Required: []string{"file"},
+			}),
+			Responses: adminResponses(map[int]openapi.Response{
+				http.StatusOK:         openapi.JSONResponse("Import preview", doc.SchemaFor(account.ImportPreview{})),
+				http.StatusAccepted:   openapi.JSONResponse("Import started", importSchema),
+				http.StatusBadRequest: openapi.ResponseRef(responseBadRequest),
+				http.StatusRequestEntityTooLarge: openapi.ContentResponse("The request body is over 2 MB",
+					MIMEApplicationProblemJSON, doc.SchemaFor(Problem{})),
+			}),
+		}},
+		{http.MethodGet, "/accounts/imports/:id", accessAdmin, accountHandler.GetAccountImport, &openapi.Operation{
+			OperationID: "getAccountImport",
+			Summary:     "Get the status of an account import",
+			Tags:        []string{tagAdmin},
+			Security:    securitySession,
+			Responses: adminResponses(map[int]openapi.Response{
+				http.StatusOK:         openapi.JSONResponse("Account import", importSchema),
+				http.StatusBadRequest: openapi.ResponseRef(responseBadRequest),
+			}),
+		}},
+		{http.MethodGet, "/accounts/imports/:id/report", accessAdmin, accountHandler.DownloadAccountImportReport,
+			&openapi.Operation{
+				OperationID: "downloadAccountImportReport",
+				Summary:     "Download the per-row report of a finished account import",
+				Tags:        []string{tagAdmin},
+				Security:    securitySession,
+				Responses: adminResponses(map[int]openapi.Response{
+					http.StatusOK:         openapi.ContentResponse("CSV report", "text/csv", openapi.String()),
+					http.StatusBadRequest: openapi.ResponseRef(responseBadRequest),
+					http.StatusConflict:   openapi.ResponseRef(responseConflict),
+				}),
+			}},
+		{http.MethodGet, "/accounts/:id", accessAdmin, accountHandler.GetAccount, &openapi.Operation{
+			OperationID: "getAccount",
+			Summary:     "Get an account",
+			Tags:        []string{tagAdmin},
+			Security:    securitySession,
+			Responses: adminResponses(map[int]openapi.Response{
+				http.StatusOK:         openapi.JSONResponse("Account", accountSchema),
+				http.StatusBadRequest: openapi.ResponseRef(responseBadRequest),
+				http.StatusNotFound:   openapi.ResponseRef(responseNotFound),
+			}),
+		}},
+		{http.MethodPut, "/accounts/:id", accessAdmin, accountHandler.UpdateAccount, &openapi.Operation{
+			OperationID: "updateAccount",
+			Summary:     "Update an active account",
+			Tags:        []string{tagAdmin},
+			Security:    securitySession,
+			RequestBody: openapi.JSONBody(doc.SchemaFor(account.UpdateAccountRequest{})),
+			Responses: adminResponses(map[int]openapi.Response{
+				http.StatusOK:         openapi.JSONResponse("Updated account", accountSchema),
+				http.StatusBadRequest: openapi.ResponseRef(responseValidationFail),
+				http.StatusNotFound:   openapi.ResponseRef(responseNotFound),
+				http.StatusConflict:   openapi.ResponseRef(responseConflict),
+			}),
+		}},
+		{http.MethodPatch, "/accounts/:id/disable", accessAdmin, accountHandler.DisableAccount, &openapi.Operation{
+			OperationID: "disableAccount",
+			Summary:     "Disable an account",
+			Tags:        []string{tagAdmin},
+			Security:    securitySession,
+			Responses: adminResponses(map[int]openapi.Response{
+				http.StatusNoContent:  openapi.NoContent("Done"),
+				http.StatusBadRequest: openapi.ResponseRef(responseBadRequest),
+				http.StatusNotFound:   openapi.ResponseRef(responseNotFound),
+			}),
+		}},
+		{http.MethodPatch, "/accounts/:id/enable", accessAdmin, accountHandler.EnableAccount, &openapi.Operation{
+			OperationID: "enableAccount",
+			Summary:     "Enable an account",
+			Tags:        []string{tagAdmin},
+			Security:    securitySession,
+			Responses: adminResponses(map[int]openapi.Response{
+				http.StatusNoContent:  openapi.NoContent("Done"),
+				http.StatusBadRequest: openapi.ResponseRef(responseBadRequest),
+				http.StatusNotFound:   openapi.ResponseRef(responseNotFound),
+			}),
+		}},
+		{http.MethodDelete, "/accounts/:id", accessAdmin, accountHandler.DeleteAccount, &openapi.Operation{
+			OperationID: "deleteAccount",
+			Summary:     "Delete an account",
+			Tags:        []string{tagAdmin},
+			Security:    securitySession,
+			Responses: adminResponses(map[int]openapi.Response{
+				http.StatusNoContent:  openapi.NoContent("Deleted"),
+				http.StatusBadRequest: openapi.ResponseRef(responseBadRequest),
+				http.StatusNotFound:   openapi.ResponseRef(responseNotFound),
+			}),
+		}},
+
+		{http.MethodGet, "/webhooks", accessAdmin, webhookHandler.ListEndpoints, &openapi.Operation{
+			OperationID: "listWebhooks",
+			Summary:     "List webhook endpoints",
+			Tags:        []string{tagAdmin},
+			Security:    securitySession,
+			Responses: adminResponses(map[int]openapi.Response{
+				http.StatusOK: openapi.JSONResponse("Webhook endpoints", openapi.ArrayOf(webhookSchema)),
+			}),
+		}},
+		{http.MethodPost, "/webhooks", accessAdmin, webhookHandler.CreateEndpoint, &openapi.Operation{
+			OperationID: "createWebhook",
+			Summary:     "Register a webhook endpoint",
+			Description: "Events are POSTed as JSON with `" + webhook.HeaderID + "`, `" + webhook.HeaderEvent + "`, `" +
+				webhook.HeaderTimestamp + "` and `" + webhook.HeaderSignature + "` headers. The signature is `v1=` " +
+				"followed by the hex HMAC-SHA256 of `<timestamp>.<body>` keyed with the endpoint secret, which is " +
+				"only returned in this response. Event types: " + webhookEventTypes() + ".",
+			Tags:        []string{tagAdmin},
+			Security:    securitySession,
+			RequestBody: openapi.JSONBody(doc.SchemaFor(webhook.CreateEndpointRequest{})),
+			Responses: adminResponses(map[int]openapi.Response{
+				http.StatusCreated:    openapi.JSONResponse("Created endpoint with its secret", webhookSchema),
+				http.StatusBadRequest: openapi.ResponseRef(responseValidationFail),
+			}),
+		}},
+		{http.MethodGet, "/webhooks/:id", accessAdmin, webhookHandler.GetEndpoint, &openapi.Operation{
+			OperationID: "getWebhook",
+			Summary:     "Get a webhook endpoint",
+			Tags:        []string{tagAdmin},
+			Security:    securitySession,
+			Responses: adminResponses(map[int]openapi.Response{
+				http.StatusOK:         openapi.JSONResponse("Webhook endpoint", webhookSchema),
+				http.StatusBadRequest: openapi.ResponseRef(responseBadRequest),
+			}),
+		}},
+		{http.MethodPut, "/webhooks/:id", accessAdmin, webhookHandler.UpdateEndpoint, &openapi.Operation{
+			OperationID: "updateWebhook",
+			Summary:     "Update a webhook endpoint",
+			Description: "Replaces the URL, description and subscribed event types. The secret is kept.",
+			Tags:        []string{tagAdmin},
+			Security:    securitySession,
+			RequestBody: openapi.JSONBody(doc.SchemaFor(webhook.UpdateEndpointRequest{})),
+			Responses: adminResponses(map[int]openapi.Response{
+				http.StatusOK:         openapi.JSONResponse("Updated endpoint", webhookSchema),
+				http.StatusBadRequest: openapi.ResponseRef(responseValidationFail),
+			}),
+		}},
+		{http.MethodDelete, "/webhooks/:id", accessAdmin, webhookHandler.DeleteEndpoint, &openapi.Operation{
+			OperationID: "deleteWebhook",
+			Summary:     "Delete a webhook endpoint and its delivery log",
+			Tags:        []string{tagAdmin},
+			Security:    securitySession,
+			Responses: adminResponses(map[int]openapi.Response{
+				http.StatusNoContent:  openapi.NoContent("Deleted"),
+				http.StatusBadRequest: openapi.ResponseRef(responseBadRequest),
+			}),
+		}},
+		{http.MethodGet, "/webhooks/:id/deliveries", accessAdmin, webhookHandler.ListDeliveries, &openapi.Operation{
+			OperationID: "listWebhookDeliveries",
+			Summary:     "List the most recent deliveries to a webhook endpoint",
+			Tags:        []string{tagAdmin},
+			Security:    securitySession,
+			Parameters: []openapi.Parameter{
+				openapi.QueryParameter("status", "Only deliveries with this status",
+					&openapi.Schema{Type: "string", Enum: []any{"pending", "succeeded", "failed"}}),
+				openapi.QueryParameter("limit", "Maximum number of deliveries, 100 by default",
+					&openapi.Schema{Type: "integer", Minimum: new(1.0), Maximum: new(500.0)}),
+			},
+			Responses: adminResponses(map[int]openapi.Response{
+				http.StatusOK: openapi.JSONResponse("Deliveries, newest first",
+					openapi.ArrayOf(deliverySchema)),
+				http.StatusBadRequest: openapi.ResponseRef(responseValidationFail),
+			}),
+		}},
+		{http.MethodPost, "/webhooks/deliveries/:id/redeliver", accessAdmin, webhookHandler.Redeliver,
+			&openapi.Operation{
+				OperationID: "redeliverWebhook",
+				Summary:     "Send the event of a finished delivery again",
+				Description: "Queues the event as a new delivery; the original stays in the log and the event " +
+					"keeps its ID.",
+				Tags:     []string{tagAdmin},
+				Security: securitySession,
+				Responses: adminResponses(map[int]openapi.Response{
+					http.StatusAccepted:   openapi.JSONResponse("Queued delivery", deliverySchema),
+					http.StatusBadRequest: openapi.ResponseRef(responseBadRequest),
+					http.StatusConflict:   openapi.ResponseRef(responseConflict),
+				}),
+			}},
+
+		{http.MethodGet, "/jobs", accessAdmin, jobHandler.ListJobs, &openapi.Operation{
+			OperationID: "listJobs",
+			Summary:     "List background jobs",
+			Tags:        []string{tagAdmin},
+			Security:    securitySession,
+			Parameters: []openapi.Parameter{
+				openapi.QueryParameter("status", "Only jobs with this status",
+					&openapi.Schema{Type: "string", Enum: []any{"pending", "running", "succeeded", "dead"}}),
+				openapi.QueryParameter("kind", "Only jobs of this kind", &openapi.Schema{Type: "string"}),
+				openapi.QueryParameter("limit", "Maximum number of jobs, 100 by default",
+					&openapi.Schema{Type: "integer", Minimum: new(1.0), Maximum: new(500.0)}),
+			},
+			Responses: adminResponses(map[int]openapi.Response{
+				http.StatusOK:         openapi.JSONResponse("Jobs, newest first", openapi.ArrayOf(jobSchema)),
+				http.StatusBadRequest: openapi.ResponseRef(responseValidationFail),
+			}),
+		}},
+		{http.MethodGet, "/jobs/:id", accessAdmin, jobHandler.GetJob, &openapi.Operation{
+			OperationID: "getJob",
+			Summary:     "Get a background job",
+			Tags:        []string{tagAdmin},
+			Security:    securitySession,
+			Responses: adminResponses(map[int]openapi.Response{
+				http.StatusOK:         openapi.JSONResponse("Job", jobSchema),
+				http.StatusBadRequest: openapi.ResponseRef(responseBadRequest),
+			}),
+		}},
+		{http.MethodPost, "/jobs/:id/retry", accessAdmin, jobHandler.RetryJob, &openapi.Operation{
+			OperationID: "retryJob",
+			Summary:     "Run a pending or dead job now",
+			Description: "Resets the attempts of the job and makes it due immediately. Dead jobs are jobs that " +
+				"failed on every attempt.",
+			Tags:     []string{tagAdmin},
+			Security: securitySession,
+			Responses: adminResponses(map[int]openapi.Response{
+				http.StatusAccepted:   openapi.JSONResponse("Queued job", jobSchema),
+				http.StatusBadRequest: openapi.ResponseRef(responseBadRequest),
+				http.StatusConflict:   openapi.ResponseRef(responseConflict),
+			}),
+		}},
+		{http.MethodGet, "/scheduled-tasks", accessAdmin, schedulerHandler.ListTasks, &openapi.Operation{
+			OperationID: "listScheduledTasks",
+			Summary:     "List scheduled tasks",
+			Description: "Returns every periodic task with its cron schedule, next run and the outcome of its last " +
+				"run. Each run happens on one instance of the cluster.",
+			Tags:     []string{tagAdmin},
+			Security: securitySession,
+			Responses: adminResponses(map[int]openapi.Response{
+				http.StatusOK: openapi.JSONResponse("Scheduled tasks, by name", openapi.ArrayOf(taskSchema)),
+			}),
+		}},
+
+		{http.MethodGet, "/diagnostics/queries", accessAdmin, diagnosticsHandler.QueryStats, &openapi.Operation{
+			OperationID: "getQueryStats",
+			Summary:     "Get query statistics",
+			Description: "Returns the calls, errors and timings of every SQL statement, normalized so literals " +
+				"don't split them, ordered by total time. Statistics are kept in memory by each instance of the " +
+				"cluster, since it started or was last reset.",
+			Tags:     []string{tagAdmin},
+			Security: securitySession,
+			Responses: adminResponses(map[int]openapi.Response{
+				http.StatusOK: openapi.JSONResponse("Query statistics", queryStatsSchema),
+			}),
+		}},
+		{http.MethodDelete, "/diagnostics/queries", accessAdmin, diagnosticsHandler.ResetQueryStats, &openapi.Operation{
+			OperationID: "resetQueryStats",
+			Summary:     "Reset query statistics",
+			Description: "Drops the query statistics collected so far by the instance serving the request.",
+			Tags:        []string{tagAdmin},
+			Security:    securitySession,
+			Responses: adminResponses(map[int]openapi.Response{
+				http.StatusNoContent: openapi.NoContent("Reset"),
+			}),
+		}},
+	}
+
+	v2 := v1.with(
+		route{http.MethodPost, "/account/register", accessSession, accountHandler.RegisterV2, &openapi.Operation{
+			OperationID: "register",
+			Summary:     "Register a new account",
+			Description: "Unlike v1, the account is returned unwrapped.",
+			Tags:        []string{tagAccount},
+			Security:    securitySession,
+			RequestBody: openapi.JSONBody(doc.SchemaFor(account.RegisterRequest{})),
+			Responses: responses(map[int]openapi.Response{
+				http.StatusCreated:      openapi.JSONResponse("Registered account", accountSchema),
+				http.StatusBadRequest:   openapi.ResponseRef(responseValidationFail),
+				http.StatusUnauthorized: openapi.ResponseRef(responseUnauthorized),
+				http.StatusConflict:     openapi.ResponseRef(responseConflict),
+			}),
+		}},
+	)
+
+	documentRoutes(doc, apiV1, v1, "", "")
+	documentRoutes(doc, apiV2, v2, "V2", "")
+	documentRoutes(doc, apiPrefix, v1, "Legacy", "Deprecated alias of the same route under "+apiV1+".")
+
+	if token := hs.config.SCIM.Token; token != "" {
+		scim.Mount(hs.echo.Group(scimPrefix), scim.NewPostgresStore(hs.db, hs.authService), token)
+	}
+
+	hs.mountRoutes(hs.echo.Group(apiV1), v1)
+	hs.mountRoutes(hs.echo.Group(apiV2), v2)
+	hs.mountRoutes(hs.echo.Group(apiPrefix, mw.Deprecation(mw.DeprecationConfig{
+		Since:     legacyAPIDeprecatedSince,
+		Sunset:    hs.config.Server.LegacyAPISunset,
+		Successor: legacySuccessor,
+	})), v1)
+}
+
+// mountRoutes registers routes on g behind the middleware their access level requires.
+func (hs *HTTPServer) mountRoutes(g *echo.Group, routes routeSet) {
+	protected := g.Group("", mw.SessionAuth(hs.authService, hs.config.Security), mw.Idempotency(hs.idempotency))
 	admin := protected.Group("", mw.AdminOnly())
-	admin.GET("/accounts", accountHandler.GetAccounts)
-	admin.POST("/accounts", accountHandler.CreateAccount)
-	admin.POST("/accounts/invite", accountHandler.InviteUsers)
-	admin.GET("/accounts/:id", accountHandler.GetAccount)
-	admin.PUT("/accounts/:id", accountHandler.UpdateAccount)
-	admin.PATCH("/accounts/:id/disable", accountHandler.DisableAccount)
-	admin.PATCH("/accounts/:id/enable", accountHandler.EnableAccount)
-	admin.DELETE("/accounts/:id", accountHandler.DeleteAccount)
+
+	for _, r := range routes {
+		switch r.access {
+		case accessPublic:
+			g.Add(r.method, r.path, r.handler)
+		case accessSession:
+			protected.Add(r.method, r.path, r.handler)
+		case accessAdmin:
+			admin.Add(r.method, r.path, r.handler)
+		}
+	}
+}
+
+// legacySuccessor maps an unversioned /api request to its /api/v1 equivalent.
+func legacySuccessor(r *http.Request) string {
+	return apiV1 + strings.TrimPrefix(r.URL.Path, apiPrefix)
 }
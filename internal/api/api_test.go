@@ -0,0 +1,147 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"monolith/internal/database"
+	"monolith/internal/events"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterRoutes_Versions(t *testing.T) {
+	tests := []struct {
+		name           string
+		path           string
+		wantDeprecated bool
+	}{
+		{name: "canonical v1", path: "/api/v1/login"},
+		{name: "v2 inherits v1", path: "/api/v2/login"},
+		{name: "unversioned alias", path: "/api/login", wantDeprecated: true},
+	}
+
+	hs := newRoutesTestServer()
+	hs.echo.HTTPErrorHandler = HTTPErrorHandler
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.path, bytes.NewReader([]byte("invalid")))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			hs.echo.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			if tt.wantDeprecated {
+				assert.NotEmpty(t, rec.Header().Get("Deprecation"))
+				assert.Equal(t, `</api/v1/login>; rel="successor-version"`, rec.Header().Get("Link"))
+			} else {
+				assert.Empty(t, rec.Header().Get("Deprecation"))
+			}
+		})
+	}
+}
+
+func TestRouteSet_With(t *testing.T) {
+	v1 := routeSet{
+		{method: http.MethodGet, path: "/a", access: accessPublic},
+		{method: http.MethodPost, path: "/a", access: accessPublic},
+	}
+
+	v2 := v1.with(
+		route{method: http.MethodPost, path: "/a", access: accessAdmin},
+		route{method: http.MethodGet, path: "/b", access: accessSession},
+	)
+
+	require.Len(t, v2, 3)
+	assert.Equal(t, accessPublic, v2[0].access)
+	assert.Equal(t, accessAdmin, v2[1].access)
+	assert.Equal(t, "/b", v2[2].path)
+	assert.Equal(t, accessPublic, v1[1].access, "the base set must not be modified")
+}
+
+func TestAccountHandler_RegisterVersions(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler func(h *AccountHandler) echo.HandlerFunc
+		wrapped bool
+	}{
+		{
+			name:    "v1 wraps the account",
+			handler: func(h *AccountHandler) echo.HandlerFunc { return h.Register },
+			wrapped: true,
+		},
+		{
+			name:    "v2 returns the account unwrapped",
+			handler: func(h *AccountHandler) echo.HandlerFunc { return h.RegisterV2 },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, _ := pgxmock.NewPool()
+			defer mock.Close()
+
+			accountID := uuid.New()
+			now := time.Now()
+			mock.ExpectQuery(`SELECT id FROM account WHERE email = \$1 OR username = \$2`).
+				WithArgs("new@example.com", "newuser").
+				WillReturnError(database.ErrNoRows)
+			mock.ExpectBegin()
+			mock.ExpectQuery(`INSERT INTO account`).
+				WithArgs("newuser", "new@example.com", pgxmock.AnyArg(), pgxmock.AnyArg(), false, "disabled").
+				WillReturnRows(pgxmock.NewRows([]string{
+					"id", "username", "email", "name", "is_admin", "language", "theme", "timezone",
+					"last_seen_at", "status", "created_at", "updated_at",
+				}).AddRow(
+					accountID, "newuser", "new@example.com", new("New User"), false, (*string)(nil),
+					(*string)(nil), (*string)(nil), (*time.Time)(nil), "active", now, now,
+				))
+			expectEvent(mock, events.TypeAccountCreated)
+			mock.ExpectCommit()
+			mock.ExpectRollback()
+
+			handler := NewAccountHandler(newAccountService(&database.DB{Pool: mock}), nil, nil)
+
+			e := echo.New()
+			e.Validator = &mockValidator{}
+			body, _ := json.Marshal(map[string]any{
+				"username": "newuser",
+				"email":    "new@example.com",
+				"password": "password123",
+				"name":     "New User",
+			})
+			req := httptest.NewRequest(http.MethodPost, "/api/v2/account/register", bytes.NewReader(body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			require.NoError(t, tt.handler(handler)(c))
+			assert.Equal(t, http.StatusCreated, rec.Code)
+
+			var got map[string]any
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+			if tt.wrapped {
+				require.Contains(t, got, "account")
+				got, _ = got["account"].(map[string]any)
+			}
+			assert.Equal(t, accountID.String(), got["id"])
+			assert.Equal(t, "newuser", got["username"])
+		})
+	}
+}
+
+// expectEvent expects an event of the given type to be written to the outbox.
+func expectEvent(mock pgxmock.PgxPoolIface, eventType events.Type) {
+	mock.ExpectExec(`INSERT INTO outbox`).
+		WithArgs(pgxmock.AnyArg(), string(eventType), pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+}
@@ -1,21 +1,20 @@
 package api
 
 import (
-	"errors"
+	"fmt"
 	"net/http"
 
 	authService "monolith/internal/auth"
-	loginService "monolith/internal/login"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v5"
 )
 
 type SessionHandler struct {
-	authService *authService.Service
+	authService AuthService
 }
 
-func NewSessionHandler(authService *authService.Service) *SessionHandler {
+func NewSessionHandler(authService AuthService) *SessionHandler {
 	return &SessionHandler{
 		authService: authService,
 	}
@@ -24,15 +23,12 @@ func NewSessionHandler(authService *authService.Service) *SessionHandler {
 func (h *SessionHandler) GetSessions(c *echo.Context) error {
 	user, ok := c.Get("user").(*authService.AuthUser)
 	if !ok {
-		return echo.NewHTTPError(http.StatusUnauthorized, "Authentication required")
+		return authService.ErrAuthenticationRequired
 	}
 
 	sessions, err := h.authService.GetUserSessions(c.Request().Context(), user.AccountID)
 	if err != nil {
-		if errors.Is(err, loginService.ErrInvalidUserID) {
-			return echo.NewHTTPError(http.StatusBadRequest, "Invalid user ID").Wrap(err)
-		}
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to retrieve sessions").Wrap(err)
+		return err
 	}
 
 	for i := range sessions {
@@ -45,7 +41,7 @@ func (h *SessionHandler) GetSessions(c *echo.Context) error {
 func (h *SessionHandler) RevokeSession(c *echo.Context) error {
 	user, ok := c.Get("user").(*authService.AuthUser)
 	if !ok {
-		return echo.NewHTTPError(http.StatusUnauthorized, "Authentication required")
+		return authService.ErrAuthenticationRequired
 	}
 	sessionIDParam := c.Param("sessionId")
 
@@ -60,14 +56,7 @@ func (h *SessionHandler) RevokeSession(c *echo.Context) error {
 
 	err = h.authService.RevokeSession(c.Request().Context(), user.AccountID, sessionID)
 	if err != nil {
-		switch {
-		case errors.Is(err, loginService.ErrInvalidUserID):
-			return echo.NewHTTPError(http.StatusBadRequest, "Invalid user ID").Wrap(err)
-		case errors.Is(err, authService.ErrSessionNotFound):
-			return echo.NewHTTPError(http.StatusNotFound, "Session not found").Wrap(err)
-		default:
-			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to revoke session").Wrap(err)
-		}
+		return err
 	}
 
 	return c.JSON(http.StatusOK, map[string]string{"message": "Session revoked successfully"})
@@ -76,7 +65,7 @@ func (h *SessionHandler) RevokeSession(c *echo.Context) error {
 func (h *SessionHandler) RotateSession(c *echo.Context) error {
 	sessionTokenCookie, err := c.Cookie("session_token")
 	if err != nil {
-		return echo.NewHTTPError(http.StatusUnauthorized, "Session token cookie not found").Wrap(err)
+		return fmt.Errorf("%w: %w", authService.ErrAuthenticationRequired, err)
 	}
 
 	session, err := h.authService.RotateSession(c.Request().Context(), &authService.RotateSessionRequest{
@@ -86,15 +75,7 @@ func (h *SessionHandler) RotateSession(c *echo.Context) error {
 	})
 	if err != nil {
 		h.authService.ClearAuthCookies(c)
-
-		switch {
-		case errors.Is(err, authService.ErrSessionExpired):
-			return echo.NewHTTPError(http.StatusUnauthorized, "Session expired").Wrap(err)
-		case errors.Is(err, loginService.ErrUserNotFound):
-			return echo.NewHTTPError(http.StatusUnauthorized, "User not found").Wrap(err)
-		default:
-			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to rotate session").Wrap(err)
-		}
+		return err
 	}
 
 	h.authService.SetSessionCookies(c, session)
@@ -0,0 +1,38 @@
+package api
+
+import (
+	"net/http"
+
+	"monolith/internal/database"
+
+	"github.com/labstack/echo/v5"
+)
+
+type DiagnosticsHandler struct {
+	db *database.DB
+}
+
+func NewDiagnosticsHandler(db *database.DB) *DiagnosticsHandler {
+	return &DiagnosticsHandler{
+		db: db,
+	}
+}
+
+// QueryStats returns the per-statement query statistics of the instance serving the request.
+func (h *DiagnosticsHandler) QueryStats(c *echo.Context) error {
+	stats := h.db.QueryStats()
+	if stats == nil {
+		return c.JSON(http.StatusOK, database.QueryStatsReport{Queries: []database.QueryStat{}})
+	}
+
+	return c.JSON(http.StatusOK, stats.Report())
+}
+
+// ResetQueryStats starts collecting query statistics afresh, e.g. before measuring a change.
+func (h *DiagnosticsHandler) ResetQueryStats(c *echo.Context) error {
+	if stats := h.db.QueryStats(); stats != nil {
+		stats.Reset()
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
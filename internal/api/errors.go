@@ -0,0 +1,211 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+
+	"monolith/internal/account"
+	"monolith/internal/auth"
+	"monolith/internal/database"
+	"monolith/internal/idempotency"
+	"monolith/internal/jobs"
+	"monolith/internal/logger"
+	"monolith/internal/login"
+	mw "monolith/internal/middleware"
+	"monolith/internal/webhook"
+
+	"github.com/labstack/echo/v5"
+)
+
+// MIMEApplicationProblemJSON is the media type of RFC 7807 problem details responses.
+const MIMEApplicationProblemJSON = "application/problem+json"
+
+// problemTypeBase prefixes the machine-readable code to form the problem "type" URI.
+const problemTypeBase = "/problems/"
+
+// Problem is an RFC 7807 problem details body. Code is a stable, machine-readable identifier
+// clients can switch on; Title and Detail are for humans and may change.
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      string       `json:"code"`
+	RequestID string       `json:"requestId,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// ErrorMapping describes how a domain error is presented to clients.
+type ErrorMapping struct {
+	Status int
+	Code   string
+	Detail string
+}
+
+type registeredError struct {
+	target  error
+	mapping ErrorMapping
+}
+
+var (
+	errorRegistryMu sync.RWMutex
+	// errorRegistry is matched in registration order with errors.Is, so more specific errors must come first.
+	errorRegistry []registeredError
+)
+
+func init() {
+	RegisterError(auth.ErrAuthenticationRequired, http.StatusUnauthorized, "authentication_required",
+		"Authentication required")
+	RegisterError(auth.ErrSessionExpired, http.StatusUnauthorized, "session_expired", "Session expired")
+	RegisterError(auth.ErrSessionRevoked, http.StatusUnauthorized, "session_revoked", "Session revoked")
+	RegisterError(auth.ErrSessionNotFound, http.StatusNotFound, "session_not_found", "Session not found")
+	RegisterError(auth.ErrInvalidSessionID, http.StatusBadRequest, "invalid_session_id", "Invalid session ID")
+
+	RegisterError(login.ErrInvalidCredentials, http.StatusUnauthorized, "invalid_credentials", "Invalid credentials")
+	RegisterError(login.ErrUserNotFound, http.StatusUnauthorized, "user_not_found", "User not found")
+	RegisterError(login.ErrInvalidUserID, http.StatusBadRequest, "invalid_user_id", "Invalid user ID")
+
+	RegisterError(account.ErrUserAlreadyExists, http.StatusConflict, "user_already_exists", "User already exists")
+	RegisterError(account.ErrPasswordTooShort, http.StatusBadRequest, "password_too_short", "Password too short")
+	RegisterError(account.ErrInvalidPassword, http.StatusBadRequest, "invalid_current_password",
+		"Current password is incorrect")
+	RegisterError(account.ErrInvalidBulkTarget, http.StatusBadRequest, "invalid_bulk_target",
+		"Either ids or a filter is required, but not both")
+	RegisterError(account.ErrEmptyBulkFilter, http.StatusBadRequest, "empty_bulk_filter",
+		"The filter needs at least one of status, isAdmin or search")
+	RegisterError(account.ErrTooManyBulkAccounts, http.StatusBadRequest, "too_many_accounts",
+		"The filter matches more accounts than a single bulk request may change")
+	RegisterError(account.ErrInvalidImportFile, http.StatusBadRequest, "invalid_import_file",
+		"The import file couldn't be read; CSV files need a header row with an email column")
+	RegisterError(account.ErrTooManyImportRows, http.StatusBadRequest, "too_many_import_rows",
+		"The import file has more rows than a single import may contain")
+	RegisterError(account.ErrUnknownExportColumn, http.StatusBadRequest, "unknown_export_column",
+		"Unknown export column")
+	RegisterError(account.ErrInvalidTimezone, http.StatusBadRequest, "invalid_timezone",
+		"Time zone must be an IANA time zone name such as Europe/Istanbul")
+	RegisterError(account.ErrImportInProgress, http.StatusConflict, "import_in_progress",
+		"The import is still running")
+
+	RegisterError(idempotency.ErrInvalidKey, http.StatusBadRequest, "invalid_idempotency_key",
+		"Invalid idempotency key")
+	RegisterError(idempotency.ErrKeyReused, http.StatusConflict, "idempotency_key_reused",
+		"Idempotency key was already used for a different request")
+	RegisterError(idempotency.ErrRequestInProgress, http.StatusConflict, "idempotency_request_in_progress",
+		"A request with this idempotency key is still being processed")
+
+	RegisterError(webhook.ErrUnknownEventType, http.StatusBadRequest, "unknown_event_type",
+		"Unknown webhook event type")
+	RegisterError(webhook.ErrPrivateURL, http.StatusBadRequest, "private_webhook_url",
+		"Webhook URLs must point at a public address")
+	RegisterError(webhook.ErrDeliveryPending, http.StatusConflict, "delivery_pending",
+		"The delivery hasn't finished yet")
+
+	RegisterError(jobs.ErrJobNotRetryable, http.StatusConflict, "job_not_retryable",
+		"Only pending and dead jobs can be retried")
+
+	RegisterError(database.ErrNoRows, http.StatusNotFound, "not_found", "Resource not found")
+}
+
+// RegisterError maps a sentinel error to an HTTP status, a stable error code and a client-safe detail message.
+// Subsystems call it from init so their errors can be returned from handlers unwrapped.
+func RegisterError(target error, status int, code, detail string) {
+	errorRegistryMu.Lock()
+	defer errorRegistryMu.Unlock()
+
+	errorRegistry = append(errorRegistry, registeredError{
+		target:  target,
+		mapping: ErrorMapping{Status: status, Code: code, Detail: detail},
+	})
+}
+
+func lookupError(err error) (ErrorMapping, bool) {
+	errorRegistryMu.RLock()
+	defer errorRegistryMu.RUnlock()
+
+	for _, registered := range errorRegistry {
+		if errors.Is(err, registered.target) {
+			return registered.mapping, true
+		}
+	}
+	return ErrorMapping{}, false
+}
+
+// problemFromError resolves err into the problem sent to clients. Registered domain errors use their mapping,
+// echo.HTTPError keeps its status and public message, and anything else becomes a generic internal error.
+func problemFromError(err error) Problem {
+	if mapping, ok := lookupError(err); ok {
+		return newProblem(mapping.Status, mapping.Code, mapping.Detail)
+	}
+
+	status := echo.StatusCode(err)
+	if status == 0 {
+		status = http.StatusInternalServerError
+	}
+
+	detail := ""
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) && httpErr.Message != "" && httpErr.Message != http.StatusText(status) {
+		detail = httpErr.Message
+	}
+
+	return newProblem(status, statusCode(status), detail)
+}
+
+func newProblem(status int, code, detail string) Problem {
+	return Problem{
+		Type:   problemTypeBase + code,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// statusCode derives a machine-readable code from an HTTP status, e.g. 404 -> "not_found".
+func statusCode(status int) string {
+	text := http.StatusText(status)
+	if text == "" {
+		return "error"
+	}
+	return strings.ReplaceAll(strings.ToLower(text), " ", "_")
+}
+
+// HTTPErrorHandler is the central error handler. It renders every error as application/problem+json.
+// Only registered details or the public message of an echo.HTTPError reach the client; wrapped causes and
+// any other error are logged through the request-scoped logger, and the request ID is returned so users can
+// reference it when reporting a problem.
+func HTTPErrorHandler(c *echo.Context, err error) {
+	if resp, _ := echo.UnwrapResponse(c.Response()); resp != nil && resp.Committed {
+		return
+	}
+
+	problem := problemFromError(err)
+	problem.Instance = c.Request().URL.Path
+	problem.RequestID = mw.GetRequestID(c)
+
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		problem.Errors = validationErr.Fields(requestLocales(c)...)
+	}
+
+	if problem.Status >= http.StatusInternalServerError {
+		ctx := c.Request().Context()
+		logger.FromContext(ctx).ErrorContext(ctx, "Request failed", "status", problem.Status, "error", err)
+	}
+
+	if sendErr := writeProblem(c, problem); sendErr != nil {
+		logger.FromContext(c.Request().Context()).Warn("Failed to send error response", "error", sendErr)
+	}
+}
+
+func writeProblem(c *echo.Context, problem Problem) error {
+	if c.Request().Method == http.MethodHead {
+		return c.NoContent(problem.Status)
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, MIMEApplicationProblemJSON)
+	return c.JSON(problem.Status, problem)
+}
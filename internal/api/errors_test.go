@@ -0,0 +1,100 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"monolith/internal/account"
+	"monolith/internal/auth"
+	"monolith/internal/database"
+
+	"github.com/labstack/echo/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPErrorHandler(t *testing.T) {
+	cause := errors.New("pq: duplicate key")
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+		wantDetail string
+	}{
+		{
+			name:       "registered domain error",
+			err:        account.ErrUserAlreadyExists,
+			wantStatus: http.StatusConflict,
+			wantCode:   "user_already_exists",
+			wantDetail: "User already exists",
+		},
+		{
+			name:       "wrapped domain error",
+			err:        fmt.Errorf("create account: %w", database.ErrNoRows),
+			wantStatus: http.StatusNotFound,
+			wantCode:   "not_found",
+			wantDetail: "Resource not found",
+		},
+		{
+			name:       "authentication required wins over wrapped cause",
+			err:        fmt.Errorf("%w: %w", auth.ErrAuthenticationRequired, auth.ErrSessionNotFound),
+			wantStatus: http.StatusUnauthorized,
+			wantCode:   "authentication_required",
+			wantDetail: "Authentication required",
+		},
+		{
+			name:       "http error keeps public message and hides cause",
+			err:        echo.NewHTTPError(http.StatusBadRequest, "Invalid request body").Wrap(cause),
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "bad_request",
+			wantDetail: "Invalid request body",
+		},
+		{
+			name:       "raw error becomes generic internal error",
+			err:        errors.New(`ERROR: relation "account" does not exist (SQLSTATE 42P01)`),
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   "internal_server_error",
+		},
+		{
+			name:       "sentinel status error",
+			err:        echo.ErrNotFound,
+			wantStatus: http.StatusNotFound,
+			wantCode:   "not_found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/api/accounts", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.Response().Header().Set(echo.HeaderXRequestID, "req-42")
+
+			HTTPErrorHandler(c, tt.err)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, MIMEApplicationProblemJSON, rec.Header().Get(echo.HeaderContentType))
+			assert.NotContains(t, rec.Body.String(), "duplicate key")
+			assert.NotContains(t, rec.Body.String(), "SQLSTATE")
+
+			var problem Problem
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+			assert.Equal(t, Problem{
+				Type:      problemTypeBase + tt.wantCode,
+				Title:     http.StatusText(tt.wantStatus),
+				Status:    tt.wantStatus,
+				Detail:    tt.wantDetail,
+				Instance:  "/api/accounts",
+				Code:      tt.wantCode,
+				RequestID: "req-42",
+			}, problem)
+		})
+	}
+}
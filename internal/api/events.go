@@ -0,0 +1,108 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"monolith/internal/auth"
+	"monolith/internal/realtime"
+
+	"github.com/labstack/echo/v5"
+)
+
+// headerLastEventID is sent by a reconnecting EventSource with the ID of the last event it received.
+const headerLastEventID = "Last-Event-ID"
+
+type EventStreamHandler struct {
+	broker    *realtime.Broker
+	heartbeat time.Duration
+}
+
+func NewEventStreamHandler(broker *realtime.Broker, heartbeat time.Duration) *EventStreamHandler {
+	return &EventStreamHandler{
+		broker:    broker,
+		heartbeat: heartbeat,
+	}
+}
+
+// Stream sends the events for the current session as server-sent events until the client disconnects.
+// A client reconnecting with Last-Event-ID first gets the events it missed. The stream ends after telling
+// the session it was revoked.
+func (h *EventStreamHandler) Stream(c *echo.Context) error {
+	user, ok := c.Get("user").(*auth.AuthUser)
+	if !ok {
+		return auth.ErrAuthenticationRequired
+	}
+	ctx := c.Request().Context()
+
+	// subscribe before replaying so nothing published in between is lost
+	sub := h.broker.Subscribe(user.AccountID, user.SessionID)
+	defer h.broker.Unsubscribe(sub)
+
+	var missed []realtime.Message
+	if lastEventID := c.Request().Header.Get(headerLastEventID); lastEventID != "" {
+		var err error
+		missed, err = h.broker.Replay(ctx, user.AccountID, user.SessionID, realtime.ParseLastEventID(lastEventID))
+		if err != nil {
+			return err
+		}
+	}
+
+	header := c.Response().Header()
+	header.Set(echo.HeaderContentType, "text/event-stream")
+	header.Set(echo.HeaderCacheControl, "no-cache")
+	// keeps nginx from buffering the stream
+	header.Set("X-Accel-Buffering", "no")
+	c.Response().WriteHeader(http.StatusOK)
+
+	w := c.Response()
+	flush := func() error {
+		return http.NewResponseController(w).Flush()
+	}
+
+	replayed := make(map[int64]bool, len(missed))
+	for _, msg := range missed {
+		if err := realtime.WriteMessage(w, msg); err != nil {
+			return err
+		}
+		if msg.Event == realtime.EventSessionRevoked {
+			return flush()
+		}
+		replayed[msg.ID] = true
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-sub.Closed():
+			return nil
+		case msg := <-sub.Messages():
+			if replayed[msg.ID] {
+				continue
+			}
+			if err := realtime.WriteMessage(w, msg); err != nil {
+				return err
+			}
+			if err := flush(); err != nil {
+				return err
+			}
+			if msg.Event == realtime.EventSessionRevoked {
+				return nil
+			}
+		case <-ticker.C:
+			if err := realtime.WriteHeartbeat(w); err != nil {
+				return err
+			}
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"monolith/internal/auth"
+	"monolith/internal/database"
+	"monolith/internal/events"
+	"monolith/internal/realtime"
+	"monolith/internal/testutil"
+
+	"github.com/google/uuid"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventStreamHandler_Stream(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	user := &auth.AuthUser{AccountID: uuid.New(), SessionID: uuid.New()}
+	payload := func(event any) json.RawMessage {
+		data, _ := json.Marshal(event)
+		return data
+	}
+	mock.ExpectQuery(`FROM outbox`).
+		WithArgs(int64(41), pgxmock.AnyArg(), user.AccountID.String(), pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows([]string{"position", "id", "type", "payload", "created_at"}).
+			AddRow(int64(42), uuid.New(), events.TypeAccountUpdated,
+				payload(events.AccountUpdated{AccountID: user.AccountID}), time.Now()).
+			AddRow(int64(43), uuid.New(), events.TypeSessionRevoked,
+				payload(events.SessionRevoked{AccountID: user.AccountID, SessionIDs: []uuid.UUID{user.SessionID}}),
+				time.Now()))
+
+	handler := NewEventStreamHandler(realtime.NewBroker(&database.DB{Pool: mock}), time.Minute)
+	tc := testutil.NewTestContext(http.MethodGet, "/api/v1/events", nil)
+	tc.SetUser(user)
+	tc.Request.Header.Set(headerLastEventID, "41")
+
+	// the stream ends once the session learns it was revoked
+	require.NoError(t, handler.Stream(tc.Context))
+
+	assert.Equal(t, http.StatusOK, tc.Recorder.Code)
+	assert.Equal(t, "text/event-stream", tc.Recorder.Header().Get("Content-Type"))
+	accountID, sessionID := user.AccountID.String(), user.SessionID.String()
+	assert.Equal(t,
+		"id: 42\nevent: account.updated\ndata: {\"accountId\":\""+accountID+"\",\"change\":\"updated\"}\n\n"+
+			"id: 43\nevent: session.revoked\ndata: {\"accountId\":\""+accountID+
+			"\",\"sessionIds\":[\""+sessionID+"\"]}\n\n",
+		tc.Recorder.Body.String())
+	require.NoError(t, mock.ExpectationsWereMet())
+}
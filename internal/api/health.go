@@ -0,0 +1,37 @@
+package api
+
+import (
+	"net/http"
+
+	"monolith/internal/health"
+
+	"github.com/labstack/echo/v5"
+)
+
+type HealthHandler struct {
+	registry *health.Registry
+}
+
+func NewHealthHandler(registry *health.Registry) *HealthHandler {
+	return &HealthHandler{
+		registry: registry,
+	}
+}
+
+// Livez reports that the process is up and able to serve HTTP. It never touches dependencies
+// so a database outage doesn't get healthy pods restarted.
+func (h *HealthHandler) Livez(c *echo.Context) error {
+	return c.JSON(http.StatusOK, map[string]string{"status": health.StatusOK})
+}
+
+// Readyz runs the registered readiness checks and reports per-check results.
+func (h *HealthHandler) Readyz(c *echo.Context) error {
+	report := h.registry.Check(c.Request().Context())
+
+	status := http.StatusOK
+	if !report.Ready() {
+		status = http.StatusServiceUnavailable
+	}
+
+	return c.JSON(status, report)
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"monolith/internal/health"
+
+	"github.com/labstack/echo/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthHandler_Readyz(t *testing.T) {
+	tests := []struct {
+		name         string
+		dbErr        error
+		shuttingDown bool
+		wantStatus   int
+		wantBody     string
+	}{
+		{
+			name:       "ready",
+			wantStatus: http.StatusOK,
+			wantBody:   health.StatusOK,
+		},
+		{
+			name:       "database down",
+			dbErr:      errors.New("connection refused"),
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   health.StatusFailing,
+		},
+		{
+			name:         "shutting down",
+			shuttingDown: true,
+			wantStatus:   http.StatusServiceUnavailable,
+			wantBody:     health.StatusNotReady,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := health.NewRegistry()
+			registry.Register("database", health.CheckerFunc(func(context.Context) error {
+				return tt.dbErr
+			}))
+			if tt.shuttingDown {
+				registry.SetShuttingDown()
+			}
+			handler := NewHealthHandler(registry)
+
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			require.NoError(t, handler.Readyz(c))
+			assert.Equal(t, tt.wantStatus, rec.Code)
+
+			var report health.Report
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+			assert.Equal(t, tt.wantBody, report.Status)
+			assert.Contains(t, report.Checks, "database")
+			assert.NotContains(t, rec.Body.String(), "connection refused")
+		})
+	}
+}
+
+func TestHealthHandler_Livez(t *testing.T) {
+	registry := health.NewRegistry()
+	registry.Register("database", health.CheckerFunc(func(context.Context) error {
+		t.Fatal("liveness must not run readiness checks")
+		return nil
+	}))
+	handler := NewHealthHandler(registry)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/livez", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	require.NoError(t, handler.Livez(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"status":"ok"}`, rec.Body.String())
+}
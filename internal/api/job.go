@@ -0,0 +1,66 @@
+package api
+
+import (
+	"net/http"
+
+	"monolith/internal/jobs"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v5"
+)
+
+type JobHandler struct {
+	jobQueue *jobs.Queue
+}
+
+func NewJobHandler(jobQueue *jobs.Queue) *JobHandler {
+	return &JobHandler{
+		jobQueue: jobQueue,
+	}
+}
+
+func (h *JobHandler) ListJobs(c *echo.Context) error {
+	var req jobs.ListJobsRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid query parameters").Wrap(err)
+	}
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	list, err := h.jobQueue.ListJobs(c.Request().Context(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, list)
+}
+
+func (h *JobHandler) GetJob(c *echo.Context) error {
+	jobID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid job ID format").Wrap(err)
+	}
+
+	job, err := h.jobQueue.GetJob(c.Request().Context(), jobID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, job)
+}
+
+// RetryJob makes a pending or dead job due now with its attempts reset.
+func (h *JobHandler) RetryJob(c *echo.Context) error {
+	jobID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid job ID format").Wrap(err)
+	}
+
+	job, err := h.jobQueue.Retry(c.Request().Context(), jobID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusAccepted, job)
+}
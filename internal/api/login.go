@@ -1,7 +1,6 @@
 package api
 
 import (
-	"errors"
 	"net/http"
 
 	authService "monolith/internal/auth"
@@ -11,11 +10,11 @@ import (
 )
 
 type AuthHandler struct {
-	loginService *loginService.Service
-	authService  *authService.Service
+	loginService LoginService
+	authService  AuthService
 }
 
-func NewAuthHandler(loginService *loginService.Service, authService *authService.Service) *AuthHandler {
+func NewAuthHandler(loginService LoginService, authService AuthService) *AuthHandler {
 	return &AuthHandler{
 		loginService: loginService,
 		authService:  authService,
@@ -30,10 +29,7 @@ func (h *AuthHandler) Login(c *echo.Context) error {
 
 	user, err := h.loginService.Login(c.Request().Context(), req)
 	if err != nil {
-		if errors.Is(err, loginService.ErrInvalidCredentials) {
-			return echo.NewHTTPError(http.StatusUnauthorized, "Invalid credentials").Wrap(err)
-		}
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to login").Wrap(err)
+		return err
 	}
 
 	session, tokenErr := h.authService.CreateSession(c.Request().Context(), &authService.CreateSessionRequest{
@@ -43,7 +39,7 @@ func (h *AuthHandler) Login(c *echo.Context) error {
 	})
 
 	if tokenErr != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create session").Wrap(tokenErr)
+		return tokenErr
 	}
 
 	h.authService.SetSessionCookies(c, session)
@@ -60,7 +56,7 @@ func (h *AuthHandler) Logout(c *echo.Context) error {
 	revokeErr := h.authService.RevokeSessionFromCookie(c)
 	h.authService.ClearAuthCookies(c)
 	if revokeErr != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to logout").Wrap(revokeErr)
+		return revokeErr
 	}
 
 	return c.JSON(http.StatusOK, map[string]string{"message": "Logged out successfully"})
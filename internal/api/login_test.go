@@ -3,7 +3,6 @@ package api
 import (
 	"bytes"
 	"encoding/json"
-	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -13,6 +12,7 @@ import (
 	"monolith/internal/auth"
 	"monolith/internal/config"
 	"monolith/internal/database"
+	"monolith/internal/events"
 	"monolith/internal/login"
 
 	"github.com/google/uuid"
@@ -77,9 +77,13 @@ func TestAuthHandler_Login(t *testing.T) {
 					sessionID, "hashed_token", new("hashed_token"), accountID,
 					"", "", false, (*time.Time)(nil), now, now, (*time.Time)(nil),
 				)
+				mock.ExpectBegin()
 				mock.ExpectQuery(`INSERT INTO auth_session`).
 					WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), accountID, pgxmock.AnyArg(), pgxmock.AnyArg()).
 					WillReturnRows(sessionRows)
+				expectEvent(mock, events.TypeSessionCreated)
+				mock.ExpectCommit()
+				mock.ExpectRollback()
 			},
 			wantStatus: http.StatusOK,
 			wantCookie: true,
@@ -137,9 +141,9 @@ func TestAuthHandler_Login(t *testing.T) {
 			tt.setupMock(mock)
 
 			db := &database.DB{Pool: mock}
-			accountService := account.NewService(db)
-			loginService := login.NewService(db, accountService)
-			authService := auth.NewService(db, cfg)
+			accountService := account.NewService(db, account.NewPostgresRepository(db), nil)
+			loginService := login.NewService(accountService)
+			authService := auth.NewService(auth.NewPostgresSessionStore(db), cfg, nil)
 			handler := NewAuthHandler(loginService, authService)
 
 			e := echo.New()
@@ -159,10 +163,7 @@ func TestAuthHandler_Login(t *testing.T) {
 
 			if tt.wantStatus >= 400 {
 				require.Error(t, err)
-				httpErr := &echo.HTTPError{}
-				ok := errors.As(err, &httpErr)
-				require.True(t, ok)
-				assert.Equal(t, tt.wantStatus, httpErr.Code)
+				assert.Equal(t, tt.wantStatus, problemFromError(err).Status)
 			} else {
 				require.NoError(t, err)
 				assert.Equal(t, tt.wantStatus, rec.Code)
@@ -200,9 +201,13 @@ func TestAuthHandler_Logout(t *testing.T) {
 			name:        "revokes session and clears cookies",
 			cookieValue: "valid_token",
 			setupMock: func(mock pgxmock.PgxPoolIface) {
-				mock.ExpectExec(`UPDATE auth_session SET revoked_at = NOW\(\) WHERE \(token = \$1 OR prev_token = \$2\) AND revoked_at IS NULL`).
+				mock.ExpectBegin()
+				mock.ExpectQuery(`UPDATE auth_session SET revoked_at = NOW\(\) WHERE \(token = \$1 OR prev_token = \$2\) AND revoked_at IS NULL`).
 					WithArgs(hashedToken, hashedToken).
-					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+					WillReturnRows(pgxmock.NewRows([]string{"id", "account_id"}).AddRow(uuid.New(), uuid.New()))
+				expectEvent(mock, events.TypeSessionRevoked)
+				mock.ExpectCommit()
+				mock.ExpectRollback()
 			},
 			wantStatus: http.StatusOK,
 		},
@@ -215,9 +220,11 @@ func TestAuthHandler_Logout(t *testing.T) {
 			name:        "returns error when revocation fails",
 			cookieValue: "valid_token",
 			setupMock: func(mock pgxmock.PgxPoolIface) {
-				mock.ExpectExec(`UPDATE auth_session SET revoked_at = NOW\(\) WHERE \(token = \$1 OR prev_token = \$2\) AND revoked_at IS NULL`).
+				mock.ExpectBegin()
+				mock.ExpectQuery(`UPDATE auth_session SET revoked_at = NOW\(\) WHERE \(token = \$1 OR prev_token = \$2\) AND revoked_at IS NULL`).
 					WithArgs(hashedToken, hashedToken).
 					WillReturnError(assert.AnError)
+				mock.ExpectRollback()
 			},
 			wantStatus: http.StatusInternalServerError,
 			wantErr:    true,
@@ -232,9 +239,9 @@ func TestAuthHandler_Logout(t *testing.T) {
 			tt.setupMock(mock)
 
 			db := &database.DB{Pool: mock}
-			accountService := account.NewService(db)
-			loginService := login.NewService(db, accountService)
-			authService := auth.NewService(db, cfg)
+			accountService := account.NewService(db, account.NewPostgresRepository(db), nil)
+			loginService := login.NewService(accountService)
+			authService := auth.NewService(auth.NewPostgresSessionStore(db), cfg, nil)
 			handler := NewAuthHandler(loginService, authService)
 
 			e := echo.New()
@@ -248,10 +255,7 @@ func TestAuthHandler_Logout(t *testing.T) {
 			err := handler.Logout(c)
 			if tt.wantErr {
 				require.Error(t, err)
-				httpErr := &echo.HTTPError{}
-				ok := errors.As(err, &httpErr)
-				require.True(t, ok)
-				assert.Equal(t, tt.wantStatus, httpErr.Code)
+				assert.Equal(t, tt.wantStatus, problemFromError(err).Status)
 			} else {
 				require.NoError(t, err)
 				assert.Equal(t, tt.wantStatus, rec.Code)
@@ -0,0 +1,254 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/base64"
+	"net/http"
+	"slices"
+	"strings"
+
+	"monolith"
+	"monolith/internal/account"
+	"monolith/internal/health"
+	"monolith/internal/idempotency"
+	"monolith/internal/openapi"
+	"monolith/internal/webhook"
+
+	"github.com/labstack/echo/v5"
+)
+
+//go:embed docs.html
+var docsHTML []byte
+
+// docsCSP only lets the docs page run its own inline script and fetch from the API origin.
+var docsCSP = newDocsCSP(docsHTML)
+
+func newDocsCSP(page []byte) string {
+	_, rest, _ := bytes.Cut(page, []byte("<script>"))
+	script, _, _ := bytes.Cut(rest, []byte("</script>"))
+	hash := sha256.Sum256(script)
+	return "default-src 'none'; connect-src 'self'; style-src 'unsafe-inline'; " +
+		"script-src 'sha256-" + base64.StdEncoding.EncodeToString(hash[:]) + "'"
+}
+
+const (
+	securitySessionCookie = "sessionCookie"
+
+	tagAuth     = "auth"
+	tagAccount  = "account"
+	tagSessions = "sessions"
+	tagAdmin    = "admin"
+	tagSystem   = "system"
+)
+
+// Security requirements of public routes and of routes requiring a session.
+var (
+	securityPublic  = []openapi.SecurityRequirement{}
+	securitySession = []openapi.SecurityRequirement{{securitySessionCookie: {}}}
+)
+
+// Shared error responses declared under components/responses.
+const (
+	responseBadRequest     = "BadRequest"
+	responseUnauthorized   = "Unauthorized"
+	responseNotFound       = "NotFound"
+	responseConflict       = "Conflict"
+	responseInternalError  = "InternalError"
+	responseValidationFail = "ValidationFailed"
+)
+
+// OpenAPIHandler serves the OpenAPI document and the interactive docs UI.
+type OpenAPIHandler struct {
+	document *openapi.Document
+}
+
+func NewOpenAPIHandler(document *openapi.Document) *OpenAPIHandler {
+	return &OpenAPIHandler{
+		document: document,
+	}
+}
+
+func (h *OpenAPIHandler) Spec(c *echo.Context) error {
+	return c.JSON(http.StatusOK, h.document)
+}
+
+// Docs serves the docs UI, which is embedded and renders the document without third-party assets.
+func (h *OpenAPIHandler) Docs(c *echo.Context) error {
+	c.Response().Header().Set(echo.HeaderContentSecurityPolicy, docsCSP)
+	return c.HTMLBlob(http.StatusOK, docsHTML)
+}
+
+// messageResponse is the {"message": "..."} body returned by several auth endpoints.
+type messageResponse struct {
+	Message string `json:"message"`
+}
+
+type registerResponse struct {
+	Account account.Account `json:"account"`
+}
+
+type statusResponse struct {
+	Status string `json:"status"`
+}
+
+// NewOpenAPIDocument describes the unversioned system routes. The versioned routes are documented from
+// their route entries by RegisterRoutes.
+func NewOpenAPIDocument(cookieName string) *openapi.Document {
+	doc := openapi.NewDocument("Monolith API", monolith.GetVersionInfo().Version)
+	doc.Info.Description = "HTTP API of the monolith service. Errors are returned as RFC 7807 " +
+		"application/problem+json documents with a stable `code`."
+	doc.Tags = []openapi.Tag{
+		{Name: tagAuth, Description: "Login and logout"},
+		{Name: tagAccount, Description: "The authenticated user's account"},
+		{Name: tagSessions, Description: "The authenticated user's sessions"},
+		{Name: tagAdmin, Description: "Account administration, admin only"},
+		{Name: tagSystem, Description: "Health, version and API metadata"},
+	}
+	doc.Components.SecuritySchemes[securitySessionCookie] = openapi.SecurityScheme{
+		Type:        "apiKey",
+		In:          "cookie",
+		Name:        cookieName,
+		Description: "Session token set by POST /api/v1/login.",
+	}
+
+	addErrorResponses(doc)
+
+	// System
+	doc.AddOperation(http.MethodGet, "/livez", &openapi.Operation{
+		OperationID: "livez",
+		Summary:     "Liveness probe",
+		Tags:        []string{tagSystem},
+		Security:    securityPublic,
+		Responses: responses(map[int]openapi.Response{
+			http.StatusOK: openapi.JSONResponse("The process is alive", doc.SchemaFor(statusResponse{})),
+		}),
+	})
+	doc.AddOperation(http.MethodGet, "/readyz", &openapi.Operation{
+		OperationID: "readyz",
+		Summary:     "Readiness probe",
+		Tags:        []string{tagSystem},
+		Security:    securityPublic,
+		Responses: responses(map[int]openapi.Response{
+			http.StatusOK: openapi.JSONResponse("All checks passed", doc.SchemaFor(health.Report{})),
+			http.StatusServiceUnavailable: openapi.JSONResponse("A check failed or the server is shutting down",
+				doc.SchemaFor(health.Report{})),
+		}),
+	})
+	doc.AddOperation(http.MethodGet, "/api/health", &openapi.Operation{
+		OperationID: "health",
+		Summary:     "Liveness probe (legacy path)",
+		Tags:        []string{tagSystem},
+		Security:    securityPublic,
+		Responses: responses(map[int]openapi.Response{
+			http.StatusOK: openapi.JSONResponse("The process is alive", doc.SchemaFor(statusResponse{})),
+		}),
+	})
+	doc.AddOperation(http.MethodGet, "/api/version", &openapi.Operation{
+		OperationID: "getVersion",
+		Summary:     "Build information",
+		Tags:        []string{tagSystem},
+		Security:    securityPublic,
+		Responses: responses(map[int]openapi.Response{
+			http.StatusOK: openapi.JSONResponse("Build information", doc.SchemaFor(monolith.VersionInfo{})),
+		}),
+	})
+	doc.AddOperation(http.MethodGet, "/api/openapi.json", &openapi.Operation{
+		OperationID: "getOpenAPI",
+		Summary:     "This OpenAPI document",
+		Tags:        []string{tagSystem},
+		Security:    securityPublic,
+		Responses: responses(map[int]openapi.Response{
+			http.StatusOK: openapi.JSONResponse("OpenAPI 3.1 document", &openapi.Schema{}),
+		}),
+	})
+	doc.AddOperation(http.MethodGet, "/api/docs", &openapi.Operation{
+		OperationID: "getDocs",
+		Summary:     "Interactive API documentation",
+		Tags:        []string{tagSystem},
+		Security:    securityPublic,
+		Responses: responses(map[int]openapi.Response{
+			http.StatusOK: openapi.ContentResponse("HTML page", "text/html", openapi.String()),
+		}),
+	})
+
+	return doc
+}
+
+// documentRoutes adds the operations of routes under prefix. operationIDSuffix keeps operation IDs unique
+// across versions; a non-empty deprecation note marks the operations deprecated.
+func documentRoutes(doc *openapi.Document, prefix string, routes routeSet, operationIDSuffix, deprecation string) {
+	for _, r := range routes {
+		op := *r.op
+		op.OperationID += operationIDSuffix
+		op.Parameters = slices.Clone(r.op.Parameters)
+		if len(op.Security) > 0 && idempotency.AppliesTo(r.method) {
+			op.Parameters = append(op.Parameters, idempotencyKeyParameter)
+		}
+		if deprecation != "" {
+			op.Deprecated = true
+			op.Description = strings.TrimSpace(op.Description + " " + deprecation)
+		}
+		doc.AddOperation(r.method, prefix+r.path, &op)
+	}
+}
+
+// accountFilterParameters documents the query parameters of account.AccountFilter.
+var accountFilterParameters = []openapi.Parameter{
+	openapi.QueryParameter("status", "Only accounts with this status",
+		&openapi.Schema{Type: "string", Enum: []any{"active", "pending", "disabled"}}),
+	openapi.QueryParameter("isAdmin", "Only admins or only non-admins", &openapi.Schema{Type: "boolean"}),
+	openapi.QueryParameter("search", "Case-insensitive substring of the username, email or name", openapi.String()),
+}
+
+// webhookEventTypes lists the webhook event types for descriptions.
+func webhookEventTypes() string {
+	names := make([]string, 0, len(webhook.EventTypes))
+	for _, eventType := range webhook.EventTypes {
+		names = append(names, "`"+string(eventType)+"`")
+	}
+	return strings.Join(names, ", ")
+}
+
+// idempotencyKeyParameter documents the Idempotency-Key header accepted by authenticated mutations.
+var idempotencyKeyParameter = openapi.HeaderParameter(idempotency.HeaderKey,
+	"Client generated key that makes the request safe to retry. A retry with the same key and payload "+
+		"replays the original response with an `"+idempotency.HeaderReplayed+": true` header; reusing the key "+
+		"for a different payload returns 409.",
+	&openapi.Schema{Type: "string", MaxLength: new(idempotency.MaxKeyLength)})
+
+// addErrorResponses declares the problem+json schemas and the shared error responses operations reference.
+func addErrorResponses(doc *openapi.Document) {
+	problemSchema := doc.SchemaFor(Problem{})
+	problem := func(description string) openapi.Response {
+		return openapi.ContentResponse(description, MIMEApplicationProblemJSON, problemSchema)
+	}
+
+	doc.Components.Responses[responseBadRequest] = problem("The request is malformed")
+	doc.Components.Responses[responseValidationFail] = problem(
+		"The request is malformed or failed validation; `errors` lists the offending fields")
+	doc.Components.Responses[responseUnauthorized] = problem("Authentication is required or the session is invalid")
+	doc.Components.Responses[responseNotFound] = problem("The resource doesn't exist")
+	doc.Components.Responses[responseConflict] = problem("The resource conflicts with an existing one")
+	doc.Components.Responses[responseInternalError] = problem("Unexpected server error")
+}
+
+// responses adds the generic 500 problem response every operation can return.
+func responses(byStatus map[int]openapi.Response) map[string]openapi.Response {
+	result := map[string]openapi.Response{
+		openapi.StatusKey(http.StatusInternalServerError): openapi.ResponseRef(responseInternalError),
+	}
+	for status, response := range byStatus {
+		result[openapi.StatusKey(status)] = response
+	}
+	return result
+}
+
+// adminResponses adds the responses of admin-only routes to responses. Non-admins get 404 rather than 403
+// so the routes' existence isn't revealed.
+func adminResponses(byStatus map[int]openapi.Response) map[string]openapi.Response {
+	byStatus[http.StatusUnauthorized] = openapi.ResponseRef(responseUnauthorized)
+	byStatus[http.StatusNotFound] = openapi.ResponseRef(responseNotFound)
+	return responses(byStatus)
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"monolith/internal/config"
+	"monolith/internal/health"
+
+	"github.com/labstack/echo/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRoutesTestServer() *HTTPServer {
+	cfg := &config.Config{Security: config.SecurityConfig{LoginCookieName: "session_token"}}
+	hs := NewHTTPServer(nil, cfg, nil, nil, nil, health.NewRegistry(), nil, nil, nil, nil, nil)
+	hs.RegisterRoutes()
+	return hs
+}
+
+func TestOpenAPIDocumentCoversRoutes(t *testing.T) {
+	hs := newRoutesTestServer()
+	doc := hs.openAPI
+
+	registered := map[string]bool{}
+	for _, route := range hs.echo.Router().Routes() {
+		key := route.Method + " " + route.Path
+		registered[key] = true
+
+		_, ok := doc.Operation(route.Method, route.Path)
+		assert.True(t, ok, "route %s is registered but missing from the OpenAPI document", key)
+	}
+
+	for path, item := range doc.Paths {
+		echoPath := regexp.MustCompile(`\{(\w+)\}`).ReplaceAllString(path, ":$1")
+		for method := range item {
+			key := strings.ToUpper(method) + " " + echoPath
+			assert.True(t, registered[key], "OpenAPI operation %s has no registered route", key)
+		}
+	}
+}
+
+func TestOpenAPIDocumentReferencesResolve(t *testing.T) {
+	body, err := json.Marshal(newRoutesTestServer().openAPI)
+	require.NoError(t, err)
+
+	var raw map[string]any
+	require.NoError(t, json.Unmarshal(body, &raw))
+	assert.Equal(t, "3.1.0", raw["openapi"])
+
+	components, ok := raw["components"].(map[string]any)
+	require.True(t, ok)
+
+	for _, match := range regexp.MustCompile(`"#/components/(\w+)/(\w+)"`).FindAllStringSubmatch(string(body), -1) {
+		section, ok := components[match[1]].(map[string]any)
+		require.True(t, ok, "missing components section %s", match[1])
+		assert.Contains(t, section, match[2], "unresolved reference %s", match[0])
+	}
+
+	operationIDs := map[string]bool{}
+	paths, ok := raw["paths"].(map[string]any)
+	require.True(t, ok)
+	for _, item := range paths {
+		for _, op := range item.(map[string]any) {
+			id, _ := op.(map[string]any)["operationId"].(string)
+			assert.False(t, operationIDs[id], "duplicate operationId %s", id)
+			operationIDs[id] = true
+		}
+	}
+}
+
+func TestOpenAPIHandler(t *testing.T) {
+	hs := newRoutesTestServer()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil)
+	rec := httptest.NewRecorder()
+	hs.echo.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"openapi":"3.1.0"`)
+	assert.Contains(t, rec.Body.String(), `"/api/accounts/{id}"`)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/docs", nil)
+	rec = httptest.NewRecorder()
+	hs.echo.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, strings.HasPrefix(rec.Header().Get(echo.HeaderContentType), echo.MIMETextHTML))
+	assert.Contains(t, rec.Body.String(), "/api/openapi.json")
+	assert.NotContains(t, rec.Body.String(), "https://")
+	assert.Contains(t, rec.Header().Get(echo.HeaderContentSecurityPolicy), "script-src 'sha256-")
+}
@@ -0,0 +1,29 @@
+package api
+
+import (
+	"net/http"
+
+	"monolith/internal/scheduler"
+
+	"github.com/labstack/echo/v5"
+)
+
+type SchedulerHandler struct {
+	scheduler *scheduler.Scheduler
+}
+
+func NewSchedulerHandler(taskScheduler *scheduler.Scheduler) *SchedulerHandler {
+	return &SchedulerHandler{
+		scheduler: taskScheduler,
+	}
+}
+
+// ListTasks returns the last run of every scheduled task and when it runs next.
+func (h *SchedulerHandler) ListTasks(c *echo.Context) error {
+	tasks, err := h.scheduler.ListTasks(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, tasks)
+}
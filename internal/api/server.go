@@ -2,19 +2,25 @@ package api
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"net/http"
 	"strings"
+	"time"
 
-	"monolith/internal/account"
-	"monolith/internal/auth"
 	"monolith/internal/config"
 	"monolith/internal/database"
+	"monolith/internal/health"
+	"monolith/internal/idempotency"
+	"monolith/internal/jobs"
+	"monolith/internal/metrics"
 	mw "monolith/internal/middleware"
-	"monolith/internal/login"
+	"monolith/internal/openapi"
+	"monolith/internal/realtime"
+	"monolith/internal/scheduler"
+	"monolith/internal/webhook"
 	"monolith/web"
 
-	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v5"
 	"github.com/labstack/echo/v5/middleware"
 )
@@ -24,18 +30,33 @@ type HTTPServer struct {
 	echo           *echo.Echo
 	db             *database.DB
 	config         *config.Config
-	accountService *account.Service
-	loginService   *login.Service
-	authService    *auth.Service
+	accountService AccountService
+	loginService   LoginService
+	authService    AuthService
+	health         *health.Registry
+	idempotency    *idempotency.Store
+	webhooks       *webhook.Service
+	jobs           *jobs.Queue
+	scheduler      *scheduler.Scheduler
+	realtime       *realtime.Broker
+
+	// openAPI documents the routes registered by RegisterRoutes.
+	openAPI *openapi.Document
 }
 
 // NewHTTPServer creates a new server instance with the given database, logger, and services.
 func NewHTTPServer(
 	db *database.DB,
 	cfg *config.Config,
-	accountService *account.Service,
-	loginService *login.Service,
-	authService *auth.Service,
+	accountService AccountService,
+	loginService LoginService,
+	authService AuthService,
+	healthRegistry *health.Registry,
+	idempotencyStore *idempotency.Store,
+	webhookService *webhook.Service,
+	jobQueue *jobs.Queue,
+	taskScheduler *scheduler.Scheduler,
+	broker *realtime.Broker,
 ) *HTTPServer {
 	e := echo.New()
 	e.Logger = slog.Default()
@@ -47,25 +68,25 @@ func NewHTTPServer(
 		accountService: accountService,
 		loginService:   loginService,
 		authService:    authService,
+		health:         healthRegistry,
+		idempotency:    idempotencyStore,
+		webhooks:       webhookService,
+		jobs:           jobQueue,
+		scheduler:      taskScheduler,
+		realtime:       broker,
 	}
 }
 
-type CustomValidator struct {
-	validator *validator.Validate
-}
-
-func (cv *CustomValidator) Validate(i any) error {
-	if err := cv.validator.Struct(i); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
-	}
-	return nil
-}
-
 // Setup configures the server with middleware and routes.
-func (hs *HTTPServer) Setup() {
+func (hs *HTTPServer) Setup() error {
 	e := hs.echo
 
-	e.Validator = &CustomValidator{validator: validator.New()}
+	validator, err := NewValidator()
+	if err != nil {
+		return err
+	}
+	e.Validator = validator
+	e.HTTPErrorHandler = HTTPErrorHandler
 
 	e.Pre(middleware.RemoveTrailingSlash())
 
@@ -74,31 +95,85 @@ func (hs *HTTPServer) Setup() {
 
 	e.Use(middleware.Recover())
 
+	e.Use(middleware.RequestID())
+	e.Use(mw.Tracing())
+	e.Use(mw.ContextLogger())
 	e.Use(mw.Logger())
+	if hs.config.Metrics.Enabled {
+		e.Use(mw.Metrics())
+	}
 	e.Use(middleware.Gzip())
-	e.Use(middleware.RequestID())
 	e.Use(middleware.Secure())
 	e.Use(middleware.CORS("*"))
 	e.Use(middleware.BodyLimit(2 * middleware.MB))
 
 	e.Use(middleware.StaticWithConfig(middleware.StaticConfig{
 		Skipper: func(c *echo.Context) bool {
-			return strings.HasPrefix(c.Request().URL.Path, "/api")
+			path := c.Request().URL.Path
+			return strings.HasPrefix(path, apiPrefix) || strings.HasPrefix(path, scimPrefix)
 		},
 		Filesystem: web.Assets(),
 		HTML5:      true,
 	}))
 
+	if hs.config.Metrics.Enabled && hs.config.Metrics.Port == "" {
+		e.GET("/metrics", echo.WrapHandler(metrics.Handler()))
+	}
+
 	hs.RegisterRoutes()
+
+	return nil
 }
 
-// Start starts the server on the specified port.
+// Start starts the server on the specified port. When ctx is canceled readiness is switched off first
+// and the listener is only closed after the configured drain delay.
 func (hs *HTTPServer) Start(ctx context.Context) error {
 	port := hs.config.Server.Port
 
+	serverCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancel()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-serverCtx.Done():
+			return
+		}
+
+		hs.health.SetShuttingDown()
+		if delay := hs.config.Server.ShutdownDrainDelay; delay > 0 {
+			slog.Info("Draining before shutdown", "delay", delay)
+			time.Sleep(delay)
+		}
+		cancel()
+	}()
+
+	if hs.config.Metrics.Enabled && hs.config.Metrics.Port != "" {
+		go hs.startMetricsServer(ctx)
+	}
+
 	slog.Info("Server starting", "port", port)
 	return echo.StartConfig{
 		Address:    ":" + port,
 		HideBanner: true,
-	}.Start(ctx, hs.echo)
+	}.Start(serverCtx, hs.echo)
+}
+
+// startMetricsServer serves the metrics endpoint on its own port so it can be kept off the public listener.
+func (hs *HTTPServer) startMetricsServer(ctx context.Context) {
+	port := hs.config.Metrics.Port
+
+	e := echo.New()
+	e.Logger = slog.Default()
+	e.GET("/metrics", echo.WrapHandler(metrics.Handler()))
+
+	slog.Info("Metrics server starting", "port", port)
+	err := echo.StartConfig{
+		Address:    ":" + port,
+		HideBanner: true,
+		HidePort:   true,
+	}.Start(ctx, e)
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.Error("Metrics server failed", "error", err)
+	}
 }
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"context"
+	"io"
+
+	"monolith/internal/account"
+	"monolith/internal/auth"
+	"monolith/internal/database"
+	"monolith/internal/login"
+	mw "monolith/internal/middleware"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v5"
+)
+
+// AccountService is what the handlers need from account.Service.
+type AccountService interface {
+	Register(ctx context.Context, req account.RegisterRequest) (*account.Account, error)
+	GetAccountByID(ctx context.Context, accountID uuid.UUID) (*account.Account, error)
+	UpdatePreferences(
+		ctx context.Context,
+		accountID uuid.UUID,
+		req account.UpdatePreferencesRequest,
+	) (*account.Account, error)
+	ChangePassword(ctx context.Context, accountID uuid.UUID, req account.ChangePasswordRequest) error
+	GetAccounts(ctx context.Context, filter account.AccountFilter) ([]account.Account, error)
+	GetAccount(ctx context.Context, id uuid.UUID) (*account.Account, error)
+	CreateAccount(ctx context.Context, req account.CreateAccountRequest) (*account.Account, error)
+	UpdateAccount(ctx context.Context, id uuid.UUID, req account.UpdateAccountRequest) (*account.Account, error)
+	DisableAccount(ctx context.Context, id uuid.UUID) error
+	EnableAccount(ctx context.Context, id uuid.UUID) error
+	DeleteAccount(ctx context.Context, id uuid.UUID) error
+	InviteUsers(ctx context.Context, req account.InviteUsersRequest) (*account.InviteUsersResponse, error)
+	BulkUpdateAccounts(
+		ctx context.Context,
+		actorID uuid.UUID,
+		req account.BulkAccountsRequest,
+	) (*account.BulkAccountsResponse, error)
+	ExportAccounts(ctx context.Context, req account.ExportAccountsRequest, w io.Writer) error
+	PreviewImport(ctx context.Context, rows []account.ImportRow) (*account.ImportPreview, error)
+	StartImport(ctx context.Context, actorID uuid.UUID, rows []account.ImportRow) (*account.AccountImport, error)
+	GetImport(ctx context.Context, id uuid.UUID) (*account.AccountImport, error)
+	WriteImportReport(ctx context.Context, id uuid.UUID, w io.Writer) error
+}
+
+// LoginService is what the handlers need from login.Service.
+type LoginService interface {
+	Login(ctx context.Context, req login.UserLoginRequest) (*account.Account, error)
+}
+
+// AuthService is what the handlers and the session middleware need from auth.Service.
+type AuthService interface {
+	mw.AuthContextProvider
+	CreateSession(ctx context.Context, req *auth.CreateSessionRequest) (*auth.Session, error)
+	RotateSession(ctx context.Context, req *auth.RotateSessionRequest) (*auth.Session, error)
+	SetSessionCookies(c *echo.Context, session *auth.Session)
+	ClearAuthCookies(c *echo.Context)
+	RevokeSessionFromCookie(c *echo.Context) error
+	GetUserSessions(ctx context.Context, userID uuid.UUID) ([]auth.UserSession, error)
+	RevokeSession(ctx context.Context, userID uuid.UUID, sessionID uuid.UUID) error
+	RevokeOtherSessions(ctx context.Context, accountID, currentSessionID uuid.UUID) error
+	RevokeAllUserSessions(ctx context.Context, accountID uuid.UUID) error
+}
+
+// Transactor runs fn in a transaction that the services called with its context take part in, as
+// database.DB does.
+type Transactor interface {
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+var (
+	_ AccountService = (*account.Service)(nil)
+	_ LoginService   = (*login.Service)(nil)
+	_ AuthService    = (*auth.Service)(nil)
+	_ Transactor     = (*database.DB)(nil)
+)
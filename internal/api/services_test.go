@@ -0,0 +1,101 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"monolith/internal/account"
+	"monolith/internal/auth"
+	"monolith/internal/login"
+	"monolith/internal/testutil"
+
+	"github.com/labstack/echo/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	_ AccountService = (*testutil.MockAccountService)(nil)
+	_ LoginService   = (*testutil.MockLoginService)(nil)
+	_ AuthService    = (*testutil.MockAuthService)(nil)
+)
+
+func TestAccountHandler_GetAccountsWithMockService(t *testing.T) {
+	var got account.AccountFilter
+	handler := NewAccountHandler(&testutil.MockAccountService{
+		GetAccountsFn: func(_ context.Context, filter account.AccountFilter) ([]account.Account, error) {
+			got = filter
+			return []account.Account{*testutil.NewTestAccount()}, nil
+		},
+	}, nil, nil)
+
+	e := echo.New()
+	e.Validator = &mockValidator{}
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/accounts?status=pending&search=jane", nil)
+	rec := httptest.NewRecorder()
+
+	require.NoError(t, handler.GetAccounts(e.NewContext(req, rec)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, account.AccountFilter{Status: "pending", Search: "jane"}, got)
+
+	var accounts []account.Account
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &accounts))
+	assert.Len(t, accounts, 1)
+}
+
+func TestAuthHandler_LoginWithMockServices(t *testing.T) {
+	user := testutil.NewTestAccount()
+
+	t.Run("sets the session cookies", func(t *testing.T) {
+		var cookieSession *auth.Session
+		handler := NewAuthHandler(
+			&testutil.MockLoginService{
+				LoginFn: func(_ context.Context, req login.UserLoginRequest) (*account.Account, error) {
+					assert.Equal(t, "jane", req.Login)
+					return user, nil
+				},
+			},
+			&testutil.MockAuthService{
+				SetSessionCookiesFn: func(_ *echo.Context, session *auth.Session) {
+					cookieSession = session
+				},
+			},
+		)
+
+		rec := httptest.NewRecorder()
+		require.NoError(t, handler.Login(newLoginContext("password123", rec)))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		require.NotNil(t, cookieSession)
+		assert.Equal(t, user.ID, cookieSession.AccountID)
+	})
+
+	t.Run("rejects invalid credentials", func(t *testing.T) {
+		handler := NewAuthHandler(
+			&testutil.MockLoginService{
+				LoginFn: func(context.Context, login.UserLoginRequest) (*account.Account, error) {
+					return nil, login.ErrInvalidCredentials
+				},
+			},
+			&testutil.MockAuthService{
+				CreateSessionFn: func(context.Context, *auth.CreateSessionRequest) (*auth.Session, error) {
+					t.Fatal("no session must be created")
+					return nil, nil
+				},
+			},
+		)
+
+		err := handler.Login(newLoginContext("wrong", httptest.NewRecorder()))
+		require.ErrorIs(t, err, login.ErrInvalidCredentials)
+	})
+}
+
+func newLoginContext(password string, rec *httptest.ResponseRecorder) *echo.Context {
+	body, _ := json.Marshal(map[string]string{"login": "jane", "password": password})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", bytes.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	return echo.New().NewContext(req, rec)
+}
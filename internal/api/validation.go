@@ -0,0 +1,177 @@
+package api
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"monolith/internal/auth"
+
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/tr"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	entranslations "github.com/go-playground/validator/v10/translations/en"
+	trtranslations "github.com/go-playground/validator/v10/translations/tr"
+	"github.com/labstack/echo/v5"
+)
+
+// ErrValidation is matched by every ValidationError so it can be mapped in the error registry.
+var ErrValidation = errors.New("validation failed")
+
+const headerAcceptLanguage = "Accept-Language"
+
+// defaultLocale is used when neither the account nor Accept-Language names a supported language.
+const defaultLocale = "en"
+
+func init() {
+	RegisterError(ErrValidation, http.StatusBadRequest, "validation_failed", "Request validation failed")
+}
+
+// FieldError describes a single failed validation rule. Field is the JSON name of the offending field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// ValidationError carries the raw validator errors so they can be translated once the request locale is known.
+type ValidationError struct {
+	errs validator.ValidationErrors
+	uni  *ut.UniversalTranslator
+}
+
+func (e *ValidationError) Error() string {
+	return e.errs.Error()
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// Fields translates the errors using the first supported locale, falling back to English.
+func (e *ValidationError) Fields(locales ...string) []FieldError {
+	trans, _ := e.uni.FindTranslator(append(locales, defaultLocale)...)
+
+	fields := make([]FieldError, 0, len(e.errs))
+	for _, fe := range e.errs {
+		fields = append(fields, FieldError{
+			Field:   fe.Field(),
+			Rule:    fe.Tag(),
+			Param:   fe.Param(),
+			Message: fe.Translate(trans),
+		})
+	}
+	return fields
+}
+
+type CustomValidator struct {
+	validator *validator.Validate
+	uni       *ut.UniversalTranslator
+}
+
+// NewValidator returns a validator that reports fields by their JSON names and translates messages
+// into the supported locales (en, tr).
+func NewValidator() (*CustomValidator, error) {
+	validate := validator.New()
+	validate.RegisterTagNameFunc(jsonFieldName)
+
+	english := en.New()
+	uni := ut.New(english, english, tr.New())
+
+	enTrans, _ := uni.GetTranslator("en")
+	if err := entranslations.RegisterDefaultTranslations(validate, enTrans); err != nil {
+		return nil, fmt.Errorf("failed to register en translations: %w", err)
+	}
+
+	trTrans, _ := uni.GetTranslator("tr")
+	if err := trtranslations.RegisterDefaultTranslations(validate, trTrans); err != nil {
+		return nil, fmt.Errorf("failed to register tr translations: %w", err)
+	}
+
+	return &CustomValidator{validator: validate, uni: uni}, nil
+}
+
+func (cv *CustomValidator) Validate(i any) error {
+	err := cv.validator.Struct(i)
+	if err == nil {
+		return nil
+	}
+
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		return &ValidationError{errs: validationErrs, uni: cv.uni}
+	}
+	return echo.NewHTTPError(http.StatusBadRequest, "Invalid request").Wrap(err)
+}
+
+func jsonFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return field.Name
+	default:
+		return name
+	}
+}
+
+// requestLocales returns the preferred locales for c: the authenticated account's language first,
+// then the Accept-Language entries ordered by quality. Region subtags are dropped, e.g. tr-TR -> tr.
+func requestLocales(c *echo.Context) []string {
+	var locales []string
+
+	if user, ok := c.Get("user").(*auth.AuthUser); ok && user.Language != "" {
+		locales = append(locales, baseLanguage(user.Language))
+	}
+
+	return append(locales, parseAcceptLanguage(c.Request().Header.Get(headerAcceptLanguage))...)
+}
+
+func parseAcceptLanguage(header string) []string {
+	type weighted struct {
+		locale  string
+		quality float64
+	}
+
+	var entries []weighted
+	for part := range strings.SplitSeq(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil || parsed <= 0 {
+				continue
+			}
+			quality = parsed
+		}
+		entries = append(entries, weighted{locale: baseLanguage(tag), quality: quality})
+	}
+
+	slices.SortStableFunc(entries, func(a, b weighted) int {
+		return cmp.Compare(b.quality, a.quality)
+	})
+
+	locales := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		locales = append(locales, entry.locale)
+	}
+	return locales
+}
+
+func baseLanguage(tag string) string {
+	base, _, _ := strings.Cut(tag, "-")
+	base, _, _ = strings.Cut(base, "_")
+	return strings.ToLower(base)
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"monolith/internal/account"
+	"monolith/internal/auth"
+
+	"github.com/labstack/echo/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidationErrorResponse(t *testing.T) {
+	englishMessages := []string{
+		"email must be a valid email address",
+		"password must be at least 8 characters in length",
+	}
+	turkishMessages := []string{
+		"email geçerli bir e-posta adresi olmalıdır",
+		"password en az 8 karakter uzunluğunda olmalıdır",
+	}
+
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		user           *auth.AuthUser
+		wantMessages   []string
+	}{
+		{
+			name:         "defaults to english",
+			wantMessages: englishMessages,
+		},
+		{
+			name:           "accept-language picks turkish",
+			acceptLanguage: "tr-TR,tr;q=0.9,en;q=0.8",
+			wantMessages:   turkishMessages,
+		},
+		{
+			name:           "quality order is respected",
+			acceptLanguage: "tr;q=0.2, en-US",
+			wantMessages:   englishMessages,
+		},
+		{
+			name:           "account language wins over accept-language",
+			acceptLanguage: "en-US",
+			user:           &auth.AuthUser{Language: "tr-TR"},
+			wantMessages:   turkishMessages,
+		},
+		{
+			name:           "unsupported language falls back to english",
+			acceptLanguage: "de-DE",
+			wantMessages:   englishMessages,
+		},
+	}
+
+	v, err := NewValidator()
+	require.NoError(t, err)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/api/auth/register", nil)
+			if tt.acceptLanguage != "" {
+				req.Header.Set(headerAcceptLanguage, tt.acceptLanguage)
+			}
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			if tt.user != nil {
+				c.Set("user", tt.user)
+			}
+
+			validateErr := v.Validate(account.RegisterRequest{
+				Username: "john",
+				Email:    "not-an-email",
+				Password: "short",
+			})
+			require.ErrorIs(t, validateErr, ErrValidation)
+
+			HTTPErrorHandler(c, validateErr)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+			var problem Problem
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+			assert.Equal(t, "validation_failed", problem.Code)
+			require.Len(t, problem.Errors, 2)
+
+			assert.Equal(t, "email", problem.Errors[0].Field)
+			assert.Equal(t, "email", problem.Errors[0].Rule)
+			assert.Empty(t, problem.Errors[0].Param)
+			assert.Equal(t, "password", problem.Errors[1].Field)
+			assert.Equal(t, "min", problem.Errors[1].Rule)
+			assert.Equal(t, "8", problem.Errors[1].Param)
+
+			assert.Equal(t, tt.wantMessages, []string{problem.Errors[0].Message, problem.Errors[1].Message})
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package api
+
+import (
+	"net/http"
+
+	"monolith/internal/webhook"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v5"
+)
+
+type WebhookHandler struct {
+	webhookService *webhook.Service
+}
+
+func NewWebhookHandler(webhookService *webhook.Service) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+	}
+}
+
+func (h *WebhookHandler) ListEndpoints(c *echo.Context) error {
+	endpoints, err := h.webhookService.ListEndpoints(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, endpoints)
+}
+
+func (h *WebhookHandler) GetEndpoint(c *echo.Context) error {
+	endpointID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid webhook ID format").Wrap(err)
+	}
+
+	endpoint, err := h.webhookService.GetEndpoint(c.Request().Context(), endpointID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, endpoint)
+}
+
+// CreateEndpoint registers a webhook endpoint. The response is the only one that includes the signing secret.
+func (h *WebhookHandler) CreateEndpoint(c *echo.Context) error {
+	var req webhook.CreateEndpointRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body").Wrap(err)
+	}
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	endpoint, err := h.webhookService.CreateEndpoint(c.Request().Context(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, endpoint)
+}
+
+func (h *WebhookHandler) UpdateEndpoint(c *echo.Context) error {
+	endpointID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid webhook ID format").Wrap(err)
+	}
+
+	var req webhook.UpdateEndpointRequest
+	if err = c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body").Wrap(err)
+	}
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	endpoint, err := h.webhookService.UpdateEndpoint(c.Request().Context(), endpointID, req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, endpoint)
+}
+
+func (h *WebhookHandler) DeleteEndpoint(c *echo.Context) error {
+	endpointID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid webhook ID format").Wrap(err)
+	}
+
+	if err := h.webhookService.DeleteEndpoint(c.Request().Context(), endpointID); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *WebhookHandler) ListDeliveries(c *echo.Context) error {
+	endpointID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid webhook ID format").Wrap(err)
+	}
+
+	var req webhook.ListDeliveriesRequest
+	if err = c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid query parameters").Wrap(err)
+	}
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	deliveries, err := h.webhookService.ListDeliveries(c.Request().Context(), endpointID, req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, deliveries)
+}
+
+// Redeliver queues a finished delivery's event again and responds with the new delivery.
+func (h *WebhookHandler) Redeliver(c *echo.Context) error {
+	deliveryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid delivery ID format").Wrap(err)
+	}
+
+	delivery, err := h.webhookService.Redeliver(c.Request().Context(), deliveryID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusAccepted, delivery)
+}
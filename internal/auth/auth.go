@@ -2,62 +2,66 @@ package auth
 
 import (
 	"context"
-	"errors"
+	"maps"
 	"net/http"
+	"slices"
 	"strconv"
 	"time"
 
 	"monolith/internal/config"
-	"monolith/internal/database"
+	"monolith/internal/logger"
+	"monolith/internal/metrics"
+	"monolith/internal/tracing"
 
-	"github.com/georgysavva/scany/v2/pgxscan"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v5"
 )
 
 type Service struct {
-	db             *database.DB
+	store          SessionStore
 	securityConfig config.SecurityConfig
+	// cache is nil when auth contexts aren't cached.
+	cache *AuthContextCache
 }
 
-func NewService(db *database.DB, cfg config.SecurityConfig) *Service {
+func NewService(store SessionStore, cfg config.SecurityConfig, cache *AuthContextCache) *Service {
 	return &Service{
-		db:             db,
+		store:          store,
 		securityConfig: cfg,
+		cache:          cache,
 	}
 }
 
-func (s *Service) CreateSession(ctx context.Context, req *CreateSessionRequest) (*Session, error) {
+// invalidateCachedAuth drops the cached auth contexts of the given accounts right away, without waiting for
+// the change notification.
+func (s *Service) invalidateCachedAuth(accountIDs ...uuid.UUID) {
+	if s.cache == nil {
+		return
+	}
+	for _, accountID := range accountIDs {
+		s.cache.InvalidateAccount(accountID)
+	}
+}
+
+func (s *Service) CreateSession(ctx context.Context, req *CreateSessionRequest) (_ *Session, err error) {
+	ctx, span := tracing.Start(ctx, "auth.Service.CreateSession")
+	defer func() { tracing.End(span, err) }()
+
 	token, hashedToken, err := createAndHashToken(s.securityConfig.SecretKey)
 	if err != nil {
 		return nil, err
 	}
 
-	query := `
-		INSERT INTO auth_session (token, prev_token, account_id, user_agent, client_ip)
-		VALUES ($1, $2, $3, $4, $5)
-		RETURNING *
-	`
-
-	var session Session
-	err = pgxscan.Get(
-		ctx,
-		s.db.Pool,
-		&session,
-		query,
-		hashedToken,
-		hashedToken,
-		req.AccountID,
-		req.UserAgent,
-		req.ClientIP,
-	)
+	session, err := s.store.Create(ctx, req, hashedToken)
 	if err != nil {
 		return nil, err
 	}
 
 	session.UnhashedToken = token
+	metrics.ObserveSessionEvent(metrics.SessionEventCreated, 1)
+	logger.FromContext(ctx).Info("Session created", "account_id", session.AccountID, "session_id", session.ID)
 
-	return &session, nil
+	return session, nil
 }
 
 func (s *Service) SetSessionCookies(c *echo.Context, session *Session) {
@@ -84,7 +88,10 @@ func (s *Service) SetSessionCookies(c *echo.Context, session *Session) {
 	})
 }
 
-func (s *Service) RotateSession(ctx context.Context, req *RotateSessionRequest) (*Session, error) {
+func (s *Service) RotateSession(ctx context.Context, req *RotateSessionRequest) (_ *Session, err error) {
+	ctx, span := tracing.Start(ctx, "auth.Service.RotateSession")
+	defer func() { tracing.End(span, err) }()
+
 	currentSession, err := s.GetSessionByToken(ctx, req.UnhashedToken)
 	if err != nil {
 		return nil, err
@@ -95,22 +102,17 @@ func (s *Service) RotateSession(ctx context.Context, req *RotateSessionRequest)
 		return nil, err
 	}
 
-	query := `
-		UPDATE auth_session
-		SET token = $1, prev_token = $2, rotated_at = NOW(), token_seen = FALSE, seen_at = NULL
-		WHERE id = $3
-		RETURNING *
-	`
-
-	var session Session
-	err = pgxscan.Get(ctx, s.db.Pool, &session, query, hashedToken, currentSession.Token, currentSession.ID)
+	session, err := s.store.Rotate(ctx, currentSession, hashedToken)
 	if err != nil {
 		return nil, err
 	}
 
+	s.invalidateCachedAuth(session.AccountID)
 	session.UnhashedToken = newToken
+	metrics.ObserveSessionEvent(metrics.SessionEventRotated, 1)
+	logger.FromContext(ctx).Debug("Session rotated", "session_id", session.ID)
 
-	return &session, nil
+	return session, nil
 }
 
 func (s *Service) ClearAuthCookies(c *echo.Context) {
@@ -164,42 +166,33 @@ func (s *Service) GetUserSessions(ctx context.Context, userID uuid.UUID) ([]User
 	return response, nil
 }
 
-func (s *Service) RevokeSession(ctx context.Context, userID uuid.UUID, sessionID uuid.UUID) error {
-	query := `
-		UPDATE auth_session
-		SET revoked_at = NOW()
-		WHERE id = $1 and account_id = $2
-	`
-	_, err := s.db.Pool.Exec(ctx, query, sessionID, userID)
-	return err
+func (s *Service) RevokeSession(ctx context.Context, userID uuid.UUID, sessionID uuid.UUID) (err error) {
+	ctx, span := tracing.Start(ctx, "auth.Service.RevokeSession")
+	defer func() { tracing.End(span, err) }()
+
+	revoked, err := s.revoked(s.store.RevokeSession(ctx, userID, sessionID))
+	if err != nil {
+		return err
+	}
+	logger.FromContext(ctx).Info("Session revoked", "revoked_session_id", sessionID, "revoked", revoked)
+	return nil
 }
 
-func (s *Service) RevokeSessionByToken(ctx context.Context, unhashedToken string) error {
+func (s *Service) RevokeSessionByToken(ctx context.Context, unhashedToken string) (err error) {
+	ctx, span := tracing.Start(ctx, "auth.Service.RevokeSessionByToken")
+	defer func() { tracing.End(span, err) }()
+
 	hashedToken := hashToken(unhashedToken, s.securityConfig.SecretKey)
 
-	query := `
-		UPDATE auth_session
-		SET revoked_at = NOW()
-		WHERE (token = $1 OR prev_token = $2) AND revoked_at IS NULL
-	`
-	_, err := s.db.Pool.Exec(ctx, query, hashedToken, hashedToken)
+	_, err = s.revoked(s.store.RevokeByToken(ctx, hashedToken))
 	return err
 }
 
 func (s *Service) GetSessionByToken(ctx context.Context, unhashedToken string) (*Session, error) {
 	hashedtoken := hashToken(unhashedToken, s.securityConfig.SecretKey)
 
-	query := `
-		SELECT id, token, account_id, user_agent, client_ip, created_at, rotated_at, revoked_at
-		FROM auth_session
-		WHERE token = $1 OR prev_token = $2
-	`
-	var session Session
-	err := pgxscan.Get(ctx, s.db.Pool, &session, query, hashedtoken, hashedtoken)
+	session, err := s.store.GetByToken(ctx, hashedtoken)
 	if err != nil {
-		if errors.Is(err, database.ErrNoRows) {
-			return nil, ErrSessionNotFound
-		}
 		return nil, err
 	}
 
@@ -215,50 +208,46 @@ func (s *Service) GetSessionByToken(ctx context.Context, unhashedToken string) (
 	// 	return nil, ErrSessionNeedsRotation
 	// }
 
-	return &session, nil
+	return session, nil
 }
 
-// GetAuthContextByToken retrieves all authentication context (session, account, workspace) in a single query.
+// GetAuthContextByToken retrieves all authentication context (session, account) from the session store.
 // It performs the following security validations:
 // - Session token validation (matches current or previous token)
 // - Account status verification (must be active)
 // - Session revocation check
 // - Session expiration check (both created and rotated timestamps)
 //
-// Note: The account status check is performed in the JOIN condition rather than a separate WHERE clause
-// for security purposes - this prevents information leakage about account states by returning a generic
-// "session not found" error for both non-existent sessions and inactive accounts.
+// Note: The store reports inactive accounts the same way as non-existent sessions for security purposes -
+// this prevents information leakage about account states by returning a generic "session not found" error.
 //
 // Returns:
 // - ErrSessionNotFound if session doesn't exist or account is not active
 // - ErrSessionRevoked if session has been revoked
 // - ErrSessionExpired if session has expired
-func (s *Service) GetAuthContextByToken(ctx context.Context, unhashedToken string) (*AuthContext, error) {
+//
+// Valid auth contexts are cached when the service has a cache. Only valid ones are, so a revoked session or
+// inactive account is always looked up again.
+func (s *Service) GetAuthContextByToken(ctx context.Context, unhashedToken string) (_ *AuthContext, err error) {
+	ctx, span := tracing.Start(ctx, "auth.Service.GetAuthContextByToken")
+	defer func() { tracing.End(span, err) }()
+
 	hashedtoken := hashToken(unhashedToken, s.securityConfig.SecretKey)
 
-	query := `
-		SELECT 
-			s.id as session_id,
-			s.token as session_token,
-			s.account_id,
-			a.email as account_email,
-			a.is_admin as account_is_admin,
-			a.status as account_status,
-			s.created_at as session_created,
-			s.rotated_at as session_rotated,
-			s.revoked_at as session_revoked
-		FROM auth_session s
-		INNER JOIN account a ON s.account_id = a.id AND a.status = $3
-		WHERE (s.token = $1 OR s.prev_token = $2)
-	`
-
-	var authCtx AuthContext
-	err := pgxscan.Get(ctx, s.db.Pool, &authCtx, query, hashedtoken, hashedtoken, AccountStatusActive)
-	if err != nil {
-		if errors.Is(err, database.ErrNoRows) {
-			// Return generic error to avoid leaking account state information
-			return nil, ErrSessionNotFound
+	var epoch uint64
+	if s.cache != nil {
+		if authCtx, ok := s.cache.get(hashedtoken); ok {
+			// the session may have expired while cached
+			if err := s.checkExpiry(authCtx); err != nil {
+				return nil, err
+			}
+			return authCtx, nil
 		}
+		epoch = s.cache.currentEpoch()
+	}
+
+	authCtx, err := s.store.GetAuthContext(ctx, hashedtoken)
+	if err != nil {
 		return nil, err
 	}
 
@@ -267,13 +256,22 @@ func (s *Service) GetAuthContextByToken(ctx context.Context, unhashedToken strin
 		return nil, ErrSessionRevoked
 	}
 
-	// Check if session is expired
+	if err := s.checkExpiry(authCtx); err != nil {
+		return nil, err
+	}
+
+	if s.cache != nil {
+		s.cache.put(hashedtoken, authCtx, epoch)
+	}
+	return authCtx, nil
+}
+
+func (s *Service) checkExpiry(authCtx *AuthContext) error {
 	if authCtx.SessionCreated.Before(s.createdAfterThreshold()) ||
 		authCtx.SessionRotated.Before(s.rotatedAfterThreshold()) {
-		return nil, ErrSessionExpired
+		return ErrSessionExpired
 	}
-
-	return &authCtx, nil
+	return nil
 }
 
 func (s *Service) createdAfterThreshold() time.Time {
@@ -285,37 +283,56 @@ func (s *Service) rotatedAfterThreshold() time.Time {
 }
 
 func (s *Service) RevokeAllUserSessions(ctx context.Context, accountID uuid.UUID) error {
-	query := `
-		UPDATE auth_session
-		SET revoked_at = NOW()
-		WHERE account_id = $1 AND revoked_at IS NULL
-	`
-	_, err := s.db.Pool.Exec(ctx, query, accountID)
-	return err
+	revoked, err := s.revoked(s.store.RevokeAccount(ctx, accountID))
+	if err != nil {
+		return err
+	}
+	logger.FromContext(ctx).Info("All sessions revoked", "target_account_id", accountID, "revoked", revoked)
+	return nil
 }
 
-func (s *Service) GetSessionsByAccountID(ctx context.Context, accountID uuid.UUID) ([]Session, error) {
-	query := `
-		SELECT id, token, account_id, user_agent, client_ip, created_at, rotated_at, revoked_at
-		FROM auth_session
-		WHERE account_id = $1 AND revoked_at IS NULL
-		ORDER BY rotated_at DESC
-	`
-	var sessions []Session
-	err := pgxscan.Select(ctx, s.db.Pool, &sessions, query, accountID)
+// RevokeOtherSessions revokes every session of an account except the current one, e.g. after its password
+// changed.
+func (s *Service) RevokeOtherSessions(ctx context.Context, accountID, currentSessionID uuid.UUID) error {
+	revoked, err := s.revoked(s.store.RevokeOthers(ctx, accountID, currentSessionID))
 	if err != nil {
-		return nil, err
+		return err
+	}
+	logger.FromContext(ctx).Info("Other sessions revoked", "target_account_id", accountID, "revoked", revoked)
+	return nil
+}
+
+// revoked takes the result of a SessionStore revocation, drops the revoked sessions from the cache and
+// returns how many were revoked.
+func (s *Service) revoked(sessions []RevokedSession, err error) (int, error) {
+	if err != nil {
+		return 0, err
 	}
-	return sessions, nil
+
+	accountIDs := make(map[uuid.UUID]struct{}, len(sessions))
+	for _, session := range sessions {
+		accountIDs[session.AccountID] = struct{}{}
+	}
+	s.invalidateCachedAuth(slices.Collect(maps.Keys(accountIDs))...)
+	metrics.ObserveSessionEvent(metrics.SessionEventRevoked, int64(len(sessions)))
+	return len(sessions), nil
+}
+
+func (s *Service) GetSessionsByAccountID(ctx context.Context, accountID uuid.UUID) ([]Session, error) {
+	return s.store.ListByAccount(ctx, accountID)
+}
+
+// CountActiveSessions returns the number of sessions that are neither revoked nor expired.
+func (s *Service) CountActiveSessions(ctx context.Context) (int64, error) {
+	return s.store.CountActive(ctx, s.createdAfterThreshold(), s.rotatedAfterThreshold())
 }
 
 func (s *Service) CleanupSessions(ctx context.Context) error {
-	query := `
-		DELETE FROM auth_session
-		WHERE revoked_at IS NOT NULL
-		   OR created_at < $1
-		   OR rotated_at < $2
-	`
-	_, err := s.db.Pool.Exec(ctx, query, s.createdAfterThreshold(), s.rotatedAfterThreshold())
-	return err
+	deleted, err := s.store.DeleteInactive(ctx, s.createdAfterThreshold(), s.rotatedAfterThreshold())
+	if err != nil {
+		return err
+	}
+
+	logger.FromContext(ctx).Info("Sessions cleaned up", "deleted", deleted)
+	return nil
 }
@@ -7,6 +7,7 @@ import (
 
 	"monolith/internal/config"
 	"monolith/internal/database"
+	"monolith/internal/events"
 
 	"github.com/google/uuid"
 	"github.com/pashagolub/pgxmock/v4"
@@ -27,7 +28,14 @@ func newTestSecurityConfig() config.SecurityConfig {
 func newTestService(mock pgxmock.PgxPoolIface) *Service {
 	db := &database.DB{Pool: mock}
 	cfg := newTestSecurityConfig()
-	return NewService(db, cfg)
+	return NewService(NewPostgresSessionStore(db), cfg, nil)
+}
+
+// expectEvent expects an event of the given type to be written to the outbox.
+func expectEvent(mock pgxmock.PgxPoolIface, eventType events.Type) {
+	mock.ExpectExec(`INSERT INTO outbox`).
+		WithArgs(pgxmock.AnyArg(), string(eventType), pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
 }
 
 func TestService_CreateSession(t *testing.T) {
@@ -57,9 +65,13 @@ func TestService_CreateSession(t *testing.T) {
 					"Mozilla/5.0 Test Browser", "127.0.0.1",
 					false, (*time.Time)(nil), now, now, (*time.Time)(nil),
 				)
+				mock.ExpectBegin()
 				mock.ExpectQuery(`INSERT INTO auth_session`).
 					WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), accountID, "Mozilla/5.0 Test Browser", "127.0.0.1").
 					WillReturnRows(rows)
+				expectEvent(mock, events.TypeSessionCreated)
+				mock.ExpectCommit()
+				mock.ExpectRollback()
 			},
 			wantErr: false,
 		},
@@ -71,9 +83,11 @@ func TestService_CreateSession(t *testing.T) {
 				UserAgent: "Mozilla/5.0 Test Browser",
 			},
 			setupMock: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
 				mock.ExpectQuery(`INSERT INTO auth_session`).
 					WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), accountID, "Mozilla/5.0 Test Browser", "127.0.0.1").
 					WillReturnError(assert.AnError)
+				mock.ExpectRollback()
 			},
 			wantErr: true,
 		},
@@ -415,9 +429,13 @@ func TestService_RevokeSession(t *testing.T) {
 			userID:    userID,
 			sessionID: sessionID,
 			setupMock: func(mock pgxmock.PgxPoolIface) {
-				mock.ExpectExec(`UPDATE auth_session SET revoked_at = NOW\(\) WHERE id = \$1 and account_id = \$2`).
+				mock.ExpectBegin()
+				mock.ExpectQuery(`UPDATE auth_session SET revoked_at = NOW\(\) WHERE id = \$1 and account_id = \$2`).
 					WithArgs(sessionID, userID).
-					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+					WillReturnRows(pgxmock.NewRows([]string{"id", "account_id"}).AddRow(sessionID, userID))
+				expectEvent(mock, events.TypeSessionRevoked)
+				mock.ExpectCommit()
+				mock.ExpectRollback()
 			},
 			wantErr: false,
 		},
@@ -426,9 +444,11 @@ func TestService_RevokeSession(t *testing.T) {
 			userID:    userID,
 			sessionID: sessionID,
 			setupMock: func(mock pgxmock.PgxPoolIface) {
-				mock.ExpectExec(`UPDATE auth_session SET revoked_at = NOW\(\) WHERE id = \$1 and account_id = \$2`).
+				mock.ExpectBegin()
+				mock.ExpectQuery(`UPDATE auth_session SET revoked_at = NOW\(\) WHERE id = \$1 and account_id = \$2`).
 					WithArgs(sessionID, userID).
 					WillReturnError(assert.AnError)
+				mock.ExpectRollback()
 			},
 			wantErr: true,
 		},
@@ -467,18 +487,24 @@ func TestService_RevokeSessionByToken(t *testing.T) {
 		{
 			name: "successful token revocation",
 			setupMock: func(mock pgxmock.PgxPoolIface) {
-				mock.ExpectExec(`UPDATE auth_session SET revoked_at = NOW\(\) WHERE \(token = \$1 OR prev_token = \$2\) AND revoked_at IS NULL`).
+				mock.ExpectBegin()
+				mock.ExpectQuery(`UPDATE auth_session SET revoked_at = NOW\(\) WHERE \(token = \$1 OR prev_token = \$2\) AND revoked_at IS NULL`).
 					WithArgs(hashedToken, hashedToken).
-					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+					WillReturnRows(pgxmock.NewRows([]string{"id", "account_id"}).AddRow(uuid.New(), uuid.New()))
+				expectEvent(mock, events.TypeSessionRevoked)
+				mock.ExpectCommit()
+				mock.ExpectRollback()
 			},
 			wantErr: false,
 		},
 		{
 			name: "database error",
 			setupMock: func(mock pgxmock.PgxPoolIface) {
-				mock.ExpectExec(`UPDATE auth_session SET revoked_at = NOW\(\) WHERE \(token = \$1 OR prev_token = \$2\) AND revoked_at IS NULL`).
+				mock.ExpectBegin()
+				mock.ExpectQuery(`UPDATE auth_session SET revoked_at = NOW\(\) WHERE \(token = \$1 OR prev_token = \$2\) AND revoked_at IS NULL`).
 					WithArgs(hashedToken, hashedToken).
 					WillReturnError(assert.AnError)
+				mock.ExpectRollback()
 			},
 			wantErr: true,
 		},
@@ -505,6 +531,24 @@ func TestService_RevokeSessionByToken(t *testing.T) {
 	}
 }
 
+func TestService_RevokeOtherSessions(t *testing.T) {
+	mock, _ := pgxmock.NewPool()
+	defer mock.Close()
+
+	accountID, currentID := uuid.New(), uuid.New()
+	mock.ExpectBegin()
+	mock.ExpectQuery(`UPDATE auth_session SET revoked_at = NOW\(\) WHERE account_id = \$1 AND id <> \$2`).
+		WithArgs(accountID, currentID).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "account_id"}).AddRow(uuid.New(), accountID))
+	expectEvent(mock, events.TypeSessionRevoked)
+	mock.ExpectCommit()
+	mock.ExpectRollback()
+
+	s := newTestService(mock)
+	require.NoError(t, s.RevokeOtherSessions(context.Background(), accountID, currentID))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestService_CleanupSessions(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -0,0 +1,247 @@
+// Package authtest holds the conformance suite every auth.SessionStore has to pass.
+package authtest
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"monolith/internal/auth"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// AddAccountFunc makes an account with the given status and admin flag visible to the store under test.
+type AddAccountFunc func(t *testing.T, status string, isAdmin bool) uuid.UUID
+
+// NewStoreFunc returns an empty store to run a test against, and a way to add accounts to it.
+type NewStoreFunc func(t *testing.T) (auth.SessionStore, AddAccountFunc)
+
+// TestSessionStore runs the conformance suite against the stores returned by newStore.
+func TestSessionStore(t *testing.T, newStore NewStoreFunc) {
+	ctx := context.Background()
+
+	create := func(t *testing.T, store auth.SessionStore, accountID uuid.UUID) *auth.Session {
+		t.Helper()
+		session, err := store.Create(ctx, &auth.CreateSessionRequest{
+			AccountID: accountID, ClientIP: "10.0.0.1", UserAgent: "test-agent",
+		}, uuid.NewString())
+		require.NoError(t, err)
+		return session
+	}
+
+	t.Run("Create and GetByToken", func(t *testing.T) {
+		store, addAccount := newStore(t)
+		accountID := addAccount(t, auth.AccountStatusActive, false)
+
+		token := uuid.NewString()
+		created, err := store.Create(ctx, &auth.CreateSessionRequest{
+			AccountID: accountID, ClientIP: "10.0.0.1", UserAgent: "test-agent",
+		}, token)
+		require.NoError(t, err)
+		assert.NotEqual(t, uuid.Nil, created.ID)
+		assert.Equal(t, token, created.Token)
+		assert.Equal(t, accountID, created.AccountID)
+		assert.Nil(t, created.RevokedAt)
+
+		found, err := store.GetByToken(ctx, token)
+		require.NoError(t, err)
+		assert.Equal(t, created.ID, found.ID)
+		assert.Equal(t, "10.0.0.1", found.ClientIP)
+		assert.Equal(t, "test-agent", found.UserAgent)
+
+		_, err = store.GetByToken(ctx, uuid.NewString())
+		require.ErrorIs(t, err, auth.ErrSessionNotFound)
+	})
+
+	t.Run("Rotate keeps the previous token", func(t *testing.T) {
+		store, addAccount := newStore(t)
+		session := create(t, store, addAccount(t, auth.AccountStatusActive, false))
+		firstToken := session.Token
+
+		rotated, err := store.Rotate(ctx, session, uuid.NewString())
+		require.NoError(t, err)
+		assert.Equal(t, session.ID, rotated.ID)
+		assert.False(t, rotated.RotatedAt.Before(session.RotatedAt))
+
+		for _, token := range []string{firstToken, rotated.Token} {
+			found, err := store.GetByToken(ctx, token)
+			require.NoError(t, err)
+			assert.Equal(t, session.ID, found.ID)
+		}
+
+		_, err = store.Rotate(ctx, rotated, uuid.NewString())
+		require.NoError(t, err)
+		_, err = store.GetByToken(ctx, firstToken)
+		require.ErrorIs(t, err, auth.ErrSessionNotFound)
+	})
+
+	t.Run("GetAuthContext", func(t *testing.T) {
+		store, addAccount := newStore(t)
+		admin := create(t, store, addAccount(t, auth.AccountStatusActive, true))
+		disabled := create(t, store, addAccount(t, auth.AccountStatusDisabled, false))
+
+		authCtx, err := store.GetAuthContext(ctx, admin.Token)
+		require.NoError(t, err)
+		assert.Equal(t, admin.ID, authCtx.SessionID)
+		assert.Equal(t, admin.AccountID, authCtx.AccountID)
+		assert.True(t, authCtx.AccountIsAdmin)
+		assert.Equal(t, auth.AccountStatusActive, authCtx.AccountStatus)
+		assert.NotEmpty(t, authCtx.AccountEmail)
+		assert.Nil(t, authCtx.SessionRevoked)
+
+		_, err = store.GetAuthContext(ctx, disabled.Token)
+		require.ErrorIs(t, err, auth.ErrSessionNotFound)
+		_, err = store.GetAuthContext(ctx, uuid.NewString())
+		require.ErrorIs(t, err, auth.ErrSessionNotFound)
+
+		// revoked sessions are returned so callers can tell them apart
+		_, err = store.RevokeAccount(ctx, admin.AccountID)
+		require.NoError(t, err)
+		authCtx, err = store.GetAuthContext(ctx, admin.Token)
+		require.NoError(t, err)
+		assert.NotNil(t, authCtx.SessionRevoked)
+	})
+
+	t.Run("ListByAccount", func(t *testing.T) {
+		store, addAccount := newStore(t)
+		accountID := addAccount(t, auth.AccountStatusActive, false)
+		older := create(t, store, accountID)
+		revoked := create(t, store, accountID)
+		create(t, store, addAccount(t, auth.AccountStatusActive, false))
+		newer, err := store.Rotate(ctx, create(t, store, accountID), uuid.NewString())
+		require.NoError(t, err)
+		_, err = store.RevokeSession(ctx, accountID, revoked.ID)
+		require.NoError(t, err)
+
+		sessions, err := store.ListByAccount(ctx, accountID)
+		require.NoError(t, err)
+		require.Len(t, sessions, 2)
+		assert.Equal(t, newer.ID, sessions[0].ID)
+		assert.Equal(t, older.ID, sessions[1].ID)
+	})
+
+	t.Run("RevokeSession", func(t *testing.T) {
+		store, addAccount := newStore(t)
+		session := create(t, store, addAccount(t, auth.AccountStatusActive, false))
+
+		// another account can't revoke the session
+		revoked, err := store.RevokeSession(ctx, uuid.New(), session.ID)
+		require.NoError(t, err)
+		assert.Empty(t, revoked)
+
+		revoked, err = store.RevokeSession(ctx, session.AccountID, session.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []auth.RevokedSession{{ID: session.ID, AccountID: session.AccountID}}, revoked)
+
+		found, err := store.GetByToken(ctx, session.Token)
+		require.NoError(t, err)
+		assert.NotNil(t, found.RevokedAt)
+
+		revoked, err = store.RevokeSession(ctx, session.AccountID, session.ID)
+		require.NoError(t, err)
+		assert.Empty(t, revoked)
+	})
+
+	t.Run("RevokeByToken matches the previous token", func(t *testing.T) {
+		store, addAccount := newStore(t)
+		session := create(t, store, addAccount(t, auth.AccountStatusActive, false))
+		_, err := store.Rotate(ctx, session, uuid.NewString())
+		require.NoError(t, err)
+
+		revoked, err := store.RevokeByToken(ctx, session.Token)
+		require.NoError(t, err)
+		assert.Equal(t, []auth.RevokedSession{{ID: session.ID, AccountID: session.AccountID}}, revoked)
+	})
+
+	t.Run("RevokeAccount", func(t *testing.T) {
+		store, addAccount := newStore(t)
+		accountID := addAccount(t, auth.AccountStatusActive, false)
+		create(t, store, accountID)
+		create(t, store, accountID)
+		other := create(t, store, addAccount(t, auth.AccountStatusActive, false))
+
+		revoked, err := store.RevokeAccount(ctx, accountID)
+		require.NoError(t, err)
+		assert.Len(t, revoked, 2)
+
+		sessions, err := store.ListByAccount(ctx, other.AccountID)
+		require.NoError(t, err)
+		assert.Len(t, sessions, 1)
+	})
+
+	t.Run("RevokeOthers keeps the given session", func(t *testing.T) {
+		store, addAccount := newStore(t)
+		accountID := addAccount(t, auth.AccountStatusActive, false)
+		current := create(t, store, accountID)
+		other := create(t, store, accountID)
+		create(t, store, addAccount(t, auth.AccountStatusActive, false))
+
+		revoked, err := store.RevokeOthers(ctx, accountID, current.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []auth.RevokedSession{{ID: other.ID, AccountID: accountID}}, revoked)
+
+		sessions, err := store.ListByAccount(ctx, accountID)
+		require.NoError(t, err)
+		require.Len(t, sessions, 1)
+		assert.Equal(t, current.ID, sessions[0].ID)
+	})
+
+	t.Run("CountActive and DeleteInactive", func(t *testing.T) {
+		store, addAccount := newStore(t)
+		accountID := addAccount(t, auth.AccountStatusActive, false)
+		active := create(t, store, accountID)
+		revoked := create(t, store, accountID)
+		_, err := store.RevokeSession(ctx, accountID, revoked.ID)
+		require.NoError(t, err)
+
+		past, future := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+		count, err := store.CountActive(ctx, past, past)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+		count, err = store.CountActive(ctx, past, future)
+		require.NoError(t, err)
+		assert.Zero(t, count)
+
+		deleted, err := store.DeleteInactive(ctx, past, past)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), deleted)
+		_, err = store.GetByToken(ctx, revoked.Token)
+		require.ErrorIs(t, err, auth.ErrSessionNotFound)
+
+		deleted, err = store.DeleteInactive(ctx, future, past)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), deleted)
+		_, err = store.GetByToken(ctx, active.Token)
+		require.ErrorIs(t, err, auth.ErrSessionNotFound)
+	})
+
+	t.Run("concurrent use", func(t *testing.T) {
+		store, addAccount := newStore(t)
+		accountID := addAccount(t, auth.AccountStatusActive, false)
+
+		var wg sync.WaitGroup
+		for range 8 {
+			wg.Go(func() {
+				session, err := store.Create(ctx, &auth.CreateSessionRequest{AccountID: accountID}, uuid.NewString())
+				if !assert.NoError(t, err) {
+					return
+				}
+				rotated, err := store.Rotate(ctx, session, uuid.NewString())
+				if !assert.NoError(t, err) {
+					return
+				}
+				_, err = store.GetAuthContext(ctx, rotated.Token)
+				assert.NoError(t, err)
+			})
+		}
+		wg.Wait()
+
+		revoked, err := store.RevokeAccount(ctx, accountID)
+		require.NoError(t, err)
+		assert.Len(t, revoked, 8)
+	})
+}
@@ -0,0 +1,163 @@
+package auth
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"monolith/internal/config"
+	"monolith/internal/database"
+	"monolith/internal/logger"
+	"monolith/internal/metrics"
+
+	"github.com/google/uuid"
+)
+
+// AuthChangeChannel is the NOTIFY channel on which the database announces changes to the sessions or
+// accounts of auth contexts, with the account ID as payload. See migration 00010.
+const AuthChangeChannel = "auth_change"
+
+type cacheEntry struct {
+	key       string
+	authCtx   AuthContext
+	expiresAt time.Time
+}
+
+// AuthContextCache is a bounded in-memory cache of auth contexts keyed by hashed session token. Entries
+// are dropped when the session or account changes, and after the TTL at the latest, which bounds how long
+// a missed invalidation can go unnoticed. The least recently used entry is evicted when the cache is full.
+type AuthContextCache struct {
+	ttl        time.Duration
+	maxEntries int
+
+	mu        sync.Mutex
+	entries   map[string]*list.Element
+	byAccount map[uuid.UUID]map[string]struct{}
+	// lru holds *cacheEntry values, most recently used first.
+	lru *list.List
+	// epoch is advanced by every invalidation, so a lookup that raced one doesn't store what it loaded.
+	epoch uint64
+}
+
+func NewAuthContextCache(cfg config.AuthCacheConfig) *AuthContextCache {
+	return &AuthContextCache{
+		ttl:        cfg.TTL,
+		maxEntries: cfg.MaxEntries,
+		entries:    map[string]*list.Element{},
+		byAccount:  map[uuid.UUID]map[string]struct{}{},
+		lru:        list.New(),
+	}
+}
+
+// get returns a copy of the cached auth context for a hashed token.
+func (c *AuthContextCache) get(key string) (*AuthContext, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		metrics.ObserveAuthCacheLookup(metrics.AuthCacheMiss)
+		return nil, false
+	}
+	entry := element.Value.(*cacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.remove(element)
+		metrics.ObserveAuthCacheLookup(metrics.AuthCacheMiss)
+		return nil, false
+	}
+
+	c.lru.MoveToFront(element)
+	metrics.ObserveAuthCacheLookup(metrics.AuthCacheHit)
+	authCtx := entry.authCtx
+	return &authCtx, true
+}
+
+// currentEpoch must be read before loading an auth context that is then passed to put.
+func (c *AuthContextCache) currentEpoch() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.epoch
+}
+
+// put caches authCtx for a hashed token, unless an invalidation happened since epoch was read.
+func (c *AuthContextCache) put(key string, authCtx *AuthContext, epoch uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if epoch != c.epoch {
+		return
+	}
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+	for c.lru.Len() >= c.maxEntries && c.lru.Len() > 0 {
+		c.remove(c.lru.Back())
+	}
+
+	c.entries[key] = c.lru.PushFront(&cacheEntry{
+		key:       key,
+		authCtx:   *authCtx,
+		expiresAt: time.Now().Add(c.ttl),
+	})
+	if c.byAccount[authCtx.AccountID] == nil {
+		c.byAccount[authCtx.AccountID] = map[string]struct{}{}
+	}
+	c.byAccount[authCtx.AccountID][key] = struct{}{}
+}
+
+// InvalidateAccount drops the cached auth contexts of every session of an account.
+func (c *AuthContextCache) InvalidateAccount(accountID uuid.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.epoch++
+	for key := range c.byAccount[accountID] {
+		c.remove(c.entries[key])
+	}
+	metrics.ObserveAuthCacheInvalidation()
+}
+
+// Clear drops every cached auth context.
+func (c *AuthContextCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.epoch++
+	c.entries = map[string]*list.Element{}
+	c.byAccount = map[uuid.UUID]map[string]struct{}{}
+	c.lru.Init()
+	metrics.ObserveAuthCacheInvalidation()
+}
+
+// Len returns the number of cached auth contexts, including expired ones not evicted yet.
+func (c *AuthContextCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// remove must be called with mu held.
+func (c *AuthContextCache) remove(element *list.Element) {
+	entry := c.lru.Remove(element).(*cacheEntry)
+	delete(c.entries, entry.key)
+
+	keys := c.byAccount[entry.authCtx.AccountID]
+	delete(keys, entry.key)
+	if len(keys) == 0 {
+		delete(c.byAccount, entry.authCtx.AccountID)
+	}
+}
+
+// Listen keeps the cache in sync with the session and account changes made by any instance until ctx is
+// canceled. Everything is dropped whenever listening (re)starts, since changes may have been missed meanwhile.
+func (c *AuthContextCache) Listen(ctx context.Context, db *database.DB) {
+	db.Listen(ctx, AuthChangeChannel, c.Clear, func(payload string) {
+		accountID, err := uuid.Parse(payload)
+		if err != nil {
+			logger.FromContext(ctx).Warn("Ignoring malformed auth change notification", "payload", payload)
+			return
+		}
+		c.InvalidateAccount(accountID)
+	})
+}
@@ -0,0 +1,134 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"monolith/internal/config"
+	"monolith/internal/database"
+	"monolith/internal/events"
+
+	"github.com/google/uuid"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCache(maxEntries int) *AuthContextCache {
+	return NewAuthContextCache(config.AuthCacheConfig{Enabled: true, TTL: time.Minute, MaxEntries: maxEntries})
+}
+
+func TestAuthContextCache(t *testing.T) {
+	alice, bob := uuid.New(), uuid.New()
+
+	t.Run("evicts the least recently used entry when full", func(t *testing.T) {
+		c := newTestCache(2)
+		c.put("a", &AuthContext{AccountID: alice}, c.currentEpoch())
+		c.put("b", &AuthContext{AccountID: bob}, c.currentEpoch())
+		_, ok := c.get("a")
+		require.True(t, ok)
+
+		c.put("c", &AuthContext{AccountID: bob}, c.currentEpoch())
+
+		_, ok = c.get("b")
+		assert.False(t, ok)
+		_, ok = c.get("a")
+		assert.True(t, ok)
+		assert.Equal(t, 2, c.Len())
+	})
+
+	t.Run("expires entries after the TTL", func(t *testing.T) {
+		c := newTestCache(10)
+		c.ttl = -time.Second
+		c.put("a", &AuthContext{AccountID: alice}, c.currentEpoch())
+
+		_, ok := c.get("a")
+		assert.False(t, ok)
+		assert.Equal(t, 0, c.Len())
+	})
+
+	t.Run("invalidates every session of an account", func(t *testing.T) {
+		c := newTestCache(10)
+		c.put("a1", &AuthContext{AccountID: alice}, c.currentEpoch())
+		c.put("a2", &AuthContext{AccountID: alice}, c.currentEpoch())
+		c.put("b", &AuthContext{AccountID: bob}, c.currentEpoch())
+
+		c.InvalidateAccount(alice)
+
+		_, ok := c.get("a1")
+		assert.False(t, ok)
+		_, ok = c.get("a2")
+		assert.False(t, ok)
+		_, ok = c.get("b")
+		assert.True(t, ok)
+		assert.NotContains(t, c.byAccount, alice)
+	})
+
+	t.Run("skips a load that raced an invalidation", func(t *testing.T) {
+		c := newTestCache(10)
+		epoch := c.currentEpoch()
+		c.InvalidateAccount(alice)
+
+		c.put("a", &AuthContext{AccountID: alice}, epoch)
+
+		assert.Equal(t, 0, c.Len())
+	})
+
+	t.Run("returns copies", func(t *testing.T) {
+		c := newTestCache(10)
+		c.put("a", &AuthContext{AccountID: alice, AccountIsAdmin: true}, c.currentEpoch())
+
+		cached, _ := c.get("a")
+		cached.AccountIsAdmin = false
+
+		cached, _ = c.get("a")
+		assert.True(t, cached.AccountIsAdmin)
+	})
+}
+
+func TestService_GetAuthContextByTokenCached(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	cfg := newTestSecurityConfig()
+	s := NewService(NewPostgresSessionStore(&database.DB{Pool: mock}), cfg, newTestCache(10))
+	hashedToken := hashToken("valid_token", cfg.SecretKey)
+	accountID := uuid.New()
+	now := time.Now()
+
+	// the second call is served from the cache
+	mock.ExpectQuery(`SELECT .+ FROM auth_session s INNER JOIN account a ON s.account_id = a.id`).
+		WithArgs(hashedToken, hashedToken, AccountStatusActive).
+		WillReturnRows(pgxmock.NewRows([]string{
+			"session_id", "session_token", "account_id", "account_email",
+			"account_is_admin", "account_status", "session_created", "session_rotated", "session_revoked",
+		}).AddRow(
+			uuid.New(), hashedToken, accountID, "test@example.com",
+			false, "active", now, now, (*time.Time)(nil),
+		))
+	for range 2 {
+		authCtx, err := s.GetAuthContextByToken(context.Background(), "valid_token")
+		require.NoError(t, err)
+		assert.Equal(t, accountID, authCtx.AccountID)
+	}
+
+	// revoking the account's sessions drops them from the cache
+	mock.ExpectBegin()
+	mock.ExpectQuery(`UPDATE auth_session SET revoked_at = NOW\(\)`).
+		WithArgs(accountID).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "account_id"}).AddRow(uuid.New(), accountID))
+	expectEvent(mock, events.TypeSessionRevoked)
+	mock.ExpectCommit()
+	mock.ExpectRollback()
+	require.NoError(t, s.RevokeAllUserSessions(context.Background(), accountID))
+
+	mock.ExpectQuery(`SELECT .+ FROM auth_session s INNER JOIN account a ON s.account_id = a.id`).
+		WithArgs(hashedToken, hashedToken, AccountStatusActive).
+		WillReturnError(database.ErrNoRows)
+	_, err = s.GetAuthContextByToken(context.Background(), "valid_token")
+	require.ErrorIs(t, err, ErrSessionNotFound)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SessionStore persists sessions. Tokens are always passed hashed, and lookups by token match the current as
+// well as the previous token of a session, so a request racing a rotation still authenticates. Validating
+// revocation and expiry is left to the Service.
+//
+// PostgresSessionStore records SessionCreated and SessionRevoked events in the transaction of the change;
+// other stores don't publish events.
+type SessionStore interface {
+	// Create stores a new session whose current and previous token are both token.
+	Create(ctx context.Context, req *CreateSessionRequest, token string) (*Session, error)
+	// Rotate replaces the token of session and keeps its current token as the previous one.
+	Rotate(ctx context.Context, session *Session, token string) (*Session, error)
+	// GetByToken returns the session with the given token, or ErrSessionNotFound.
+	GetByToken(ctx context.Context, token string) (*Session, error)
+	// GetAuthContext returns the session with the given token along with its account, or ErrSessionNotFound
+	// when there is none or the account isn't active.
+	GetAuthContext(ctx context.Context, token string) (*AuthContext, error)
+	// ListByAccount returns the sessions of an account that aren't revoked, most recently rotated first.
+	ListByAccount(ctx context.Context, accountID uuid.UUID) ([]Session, error)
+	// RevokeSession revokes a session of an account.
+	RevokeSession(ctx context.Context, accountID, sessionID uuid.UUID) ([]RevokedSession, error)
+	// RevokeByToken revokes the session with the given token.
+	RevokeByToken(ctx context.Context, token string) ([]RevokedSession, error)
+	// RevokeAccount revokes every session of an account.
+	RevokeAccount(ctx context.Context, accountID uuid.UUID) ([]RevokedSession, error)
+	// RevokeOthers revokes every session of an account except sessionID.
+	RevokeOthers(ctx context.Context, accountID, sessionID uuid.UUID) ([]RevokedSession, error)
+	// CountActive counts the sessions that aren't revoked and were created and last rotated after the given
+	// times.
+	CountActive(ctx context.Context, createdAfter, rotatedAfter time.Time) (int64, error)
+	// DeleteInactive deletes the sessions that are revoked or were created or last rotated before the given
+	// times, and returns how many it deleted.
+	DeleteInactive(ctx context.Context, createdAfter, rotatedAfter time.Time) (int64, error)
+}
+
+// RevokedSession identifies a session revoked by a SessionStore. Sessions that were already revoked are
+// never reported again.
+type RevokedSession struct {
+	ID        uuid.UUID
+	AccountID uuid.UUID
+}
+
+// SessionAccount is the account side of an auth context.
+type SessionAccount struct {
+	ID       uuid.UUID
+	Email    string
+	IsAdmin  bool
+	Language *string
+	Status   string
+}
+
+// AccountLookup returns the account of a session for stores that don't keep accounts next to their sessions.
+// It returns nil when the account doesn't exist.
+type AccountLookup func(ctx context.Context, accountID uuid.UUID) (*SessionAccount, error)
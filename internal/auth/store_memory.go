@@ -0,0 +1,223 @@
+package auth
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MemorySessionStore keeps sessions in memory, for tests and single-instance development. Sessions are lost on
+// restart and aren't shared between instances.
+type MemorySessionStore struct {
+	accounts AccountLookup
+
+	mu       sync.RWMutex
+	sessions map[uuid.UUID]*Session
+	// byToken indexes sessions by their current and previous token.
+	byToken map[string]uuid.UUID
+}
+
+func NewMemorySessionStore(accounts AccountLookup) *MemorySessionStore {
+	return &MemorySessionStore{
+		accounts: accounts,
+		sessions: map[uuid.UUID]*Session{},
+		byToken:  map[string]uuid.UUID{},
+	}
+}
+
+func (s *MemorySessionStore) Create(_ context.Context, req *CreateSessionRequest, token string) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	session := &Session{
+		ID:        uuid.New(),
+		Token:     token,
+		PrevToken: &token,
+		AccountID: req.AccountID,
+		UserAgent: req.UserAgent,
+		ClientIP:  req.ClientIP,
+		TokenSeen: true,
+		SeenAt:    &now,
+		CreatedAt: now,
+		RotatedAt: now,
+	}
+	s.sessions[session.ID] = session
+	s.byToken[token] = session.ID
+
+	stored := *session
+	return &stored, nil
+}
+
+func (s *MemorySessionStore) Rotate(_ context.Context, session *Session, token string) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.sessions[session.ID]
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+	s.unindex(stored)
+	prevToken := session.Token
+	stored.PrevToken = &prevToken
+	stored.Token = token
+	stored.RotatedAt = time.Now()
+	stored.TokenSeen = false
+	stored.SeenAt = nil
+	s.byToken[prevToken] = stored.ID
+	s.byToken[token] = stored.ID
+
+	rotated := *stored
+	return &rotated, nil
+}
+
+func (s *MemorySessionStore) GetByToken(_ context.Context, token string) (*Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	session, ok := s.lookup(token)
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+	found := *session
+	return &found, nil
+}
+
+func (s *MemorySessionStore) GetAuthContext(ctx context.Context, token string) (*AuthContext, error) {
+	session, err := s.GetByToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	account, err := s.accounts(ctx, session.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil || account.Status != AccountStatusActive {
+		return nil, ErrSessionNotFound
+	}
+
+	return &AuthContext{
+		SessionID:       session.ID,
+		SessionToken:    session.Token,
+		AccountID:       session.AccountID,
+		AccountEmail:    account.Email,
+		AccountIsAdmin:  account.IsAdmin,
+		AccountLanguage: account.Language,
+		AccountStatus:   account.Status,
+		SessionCreated:  session.CreatedAt,
+		SessionRotated:  session.RotatedAt,
+		SessionRevoked:  session.RevokedAt,
+	}, nil
+}
+
+func (s *MemorySessionStore) ListByAccount(_ context.Context, accountID uuid.UUID) ([]Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var sessions []Session
+	for _, session := range s.sessions {
+		if session.AccountID == accountID && session.RevokedAt == nil {
+			sessions = append(sessions, *session)
+		}
+	}
+	slices.SortFunc(sessions, func(a, b Session) int {
+		return b.RotatedAt.Compare(a.RotatedAt)
+	})
+	return sessions, nil
+}
+
+func (s *MemorySessionStore) RevokeSession(
+	_ context.Context,
+	accountID, sessionID uuid.UUID,
+) ([]RevokedSession, error) {
+	return s.revoke(func(session *Session) bool {
+		return session.ID == sessionID && session.AccountID == accountID
+	}), nil
+}
+
+func (s *MemorySessionStore) RevokeByToken(_ context.Context, token string) ([]RevokedSession, error) {
+	return s.revoke(func(session *Session) bool {
+		return session.Token == token || (session.PrevToken != nil && *session.PrevToken == token)
+	}), nil
+}
+
+func (s *MemorySessionStore) RevokeAccount(_ context.Context, accountID uuid.UUID) ([]RevokedSession, error) {
+	return s.revoke(func(session *Session) bool {
+		return session.AccountID == accountID
+	}), nil
+}
+
+func (s *MemorySessionStore) RevokeOthers(
+	_ context.Context,
+	accountID, sessionID uuid.UUID,
+) ([]RevokedSession, error) {
+	return s.revoke(func(session *Session) bool {
+		return session.AccountID == accountID && session.ID != sessionID
+	}), nil
+}
+
+func (s *MemorySessionStore) revoke(match func(session *Session) bool) []RevokedSession {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var revoked []RevokedSession
+	for _, session := range s.sessions {
+		if session.RevokedAt == nil && match(session) {
+			session.RevokedAt = &now
+			revoked = append(revoked, RevokedSession{ID: session.ID, AccountID: session.AccountID})
+		}
+	}
+	return revoked
+}
+
+func (s *MemorySessionStore) CountActive(_ context.Context, createdAfter, rotatedAfter time.Time) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var count int64
+	for _, session := range s.sessions {
+		if session.RevokedAt == nil && !session.CreatedAt.Before(createdAfter) &&
+			!session.RotatedAt.Before(rotatedAfter) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *MemorySessionStore) DeleteInactive(_ context.Context, createdAfter, rotatedAfter time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deleted int64
+	for id, session := range s.sessions {
+		if session.RevokedAt != nil || session.CreatedAt.Before(createdAfter) ||
+			session.RotatedAt.Before(rotatedAfter) {
+			delete(s.sessions, id)
+			s.unindex(session)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// lookup must be called with mu held.
+func (s *MemorySessionStore) lookup(token string) (*Session, bool) {
+	sessionID, ok := s.byToken[token]
+	if !ok {
+		return nil, false
+	}
+	session, ok := s.sessions[sessionID]
+	return session, ok
+}
+
+// unindex removes the tokens of session from byToken. It must be called with mu held.
+func (s *MemorySessionStore) unindex(session *Session) {
+	delete(s.byToken, session.Token)
+	if session.PrevToken != nil {
+		delete(s.byToken, *session.PrevToken)
+	}
+}
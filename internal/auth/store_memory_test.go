@@ -0,0 +1,67 @@
+package auth_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"monolith/internal/auth"
+	"monolith/internal/auth/authtest"
+	"monolith/internal/testutil"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryAccounts is an auth.AccountLookup backed by a map.
+type memoryAccounts struct {
+	mu       sync.Mutex
+	accounts map[uuid.UUID]*auth.SessionAccount
+}
+
+func (m *memoryAccounts) add(_ *testing.T, status string, isAdmin bool) uuid.UUID {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := uuid.New()
+	m.accounts[id] = &auth.SessionAccount{ID: id, Email: id.String() + "@example.com", IsAdmin: isAdmin, Status: status}
+	return id
+}
+
+func (m *memoryAccounts) lookup(_ context.Context, accountID uuid.UUID) (*auth.SessionAccount, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.accounts[accountID], nil
+}
+
+func newMemoryStore(*testing.T) (*auth.MemorySessionStore, *memoryAccounts) {
+	accounts := &memoryAccounts{accounts: map[uuid.UUID]*auth.SessionAccount{}}
+	return auth.NewMemorySessionStore(accounts.lookup), accounts
+}
+
+func TestMemorySessionStore(t *testing.T) {
+	authtest.TestSessionStore(t, func(t *testing.T) (auth.SessionStore, authtest.AddAccountFunc) {
+		store, accounts := newMemoryStore(t)
+		return store, accounts.add
+	})
+}
+
+func TestService_WithMemorySessionStore(t *testing.T) {
+	ctx := context.Background()
+	store, accounts := newMemoryStore(t)
+	s := auth.NewService(store, testutil.NewTestSecurityConfig(), nil)
+	accountID := accounts.add(t, auth.AccountStatusActive, false)
+
+	session, err := s.CreateSession(ctx, &auth.CreateSessionRequest{AccountID: accountID})
+	require.NoError(t, err)
+
+	rotated, err := s.RotateSession(ctx, &auth.RotateSessionRequest{UnhashedToken: session.UnhashedToken})
+	require.NoError(t, err)
+	authCtx, err := s.GetAuthContextByToken(ctx, rotated.UnhashedToken)
+	require.NoError(t, err)
+	require.Equal(t, accountID, authCtx.AccountID)
+
+	require.NoError(t, s.RevokeAllUserSessions(ctx, accountID))
+	_, err = s.GetAuthContextByToken(ctx, rotated.UnhashedToken)
+	require.ErrorIs(t, err, auth.ErrSessionRevoked)
+}
@@ -0,0 +1,255 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"monolith/internal/database"
+	"monolith/internal/events"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/google/uuid"
+)
+
+// PostgresSessionStore keeps sessions in the auth_session table.
+type PostgresSessionStore struct {
+	db *database.DB
+}
+
+func NewPostgresSessionStore(db *database.DB) *PostgresSessionStore {
+	return &PostgresSessionStore{db: db}
+}
+
+func (s *PostgresSessionStore) Create(ctx context.Context, req *CreateSessionRequest, token string) (*Session, error) {
+	query := `
+		INSERT INTO auth_session (token, prev_token, account_id, user_agent, client_ip)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING *
+	`
+
+	var session Session
+	err := s.db.WithTx(ctx, func(ctx context.Context) error {
+		tx := s.db.Conn(ctx)
+		err := pgxscan.Get(ctx, tx, &session, query, token, token, req.AccountID, req.UserAgent, req.ClientIP)
+		if err != nil {
+			return err
+		}
+		return events.Record(ctx, tx, events.SessionCreated{
+			SessionID: session.ID,
+			AccountID: session.AccountID,
+			UserAgent: session.UserAgent,
+			ClientIP:  session.ClientIP,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (s *PostgresSessionStore) Rotate(ctx context.Context, session *Session, token string) (*Session, error) {
+	query := `
+		UPDATE auth_session
+		SET token = $1, prev_token = $2, rotated_at = NOW(), token_seen = FALSE, seen_at = NULL
+		WHERE id = $3
+		RETURNING *
+	`
+
+	var rotated Session
+	err := pgxscan.Get(ctx, s.db.Conn(ctx), &rotated, query, token, session.Token, session.ID)
+	if err != nil {
+		if errors.Is(err, database.ErrNoRows) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, err
+	}
+	return &rotated, nil
+}
+
+func (s *PostgresSessionStore) GetByToken(ctx context.Context, token string) (*Session, error) {
+	query := `
+		SELECT id, token, account_id, user_agent, client_ip, created_at, rotated_at, revoked_at
+		FROM auth_session
+		WHERE token = $1 OR prev_token = $2
+	`
+	var session Session
+	err := pgxscan.Get(ctx, s.db.Conn(ctx), &session, query, token, token)
+	if err != nil {
+		if errors.Is(err, database.ErrNoRows) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+// GetAuthContext checks the account status in the JOIN condition rather than a separate WHERE clause, so a
+// missing session and an inactive account can't be told apart.
+func (s *PostgresSessionStore) GetAuthContext(ctx context.Context, token string) (*AuthContext, error) {
+	query := `
+		SELECT
+			s.id as session_id,
+			s.token as session_token,
+			s.account_id,
+			a.email as account_email,
+			a.is_admin as account_is_admin,
+			a.language as account_language,
+			a.status as account_status,
+			s.created_at as session_created,
+			s.rotated_at as session_rotated,
+			s.revoked_at as session_revoked
+		FROM auth_session s
+		INNER JOIN account a ON s.account_id = a.id AND a.status = $3
+		WHERE (s.token = $1 OR s.prev_token = $2)
+	`
+
+	var authCtx AuthContext
+	err := pgxscan.Get(ctx, s.db.Conn(ctx), &authCtx, query, token, token, AccountStatusActive)
+	if err != nil {
+		if errors.Is(err, database.ErrNoRows) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, err
+	}
+	return &authCtx, nil
+}
+
+func (s *PostgresSessionStore) ListByAccount(ctx context.Context, accountID uuid.UUID) ([]Session, error) {
+	query := `
+		SELECT id, token, account_id, user_agent, client_ip, created_at, rotated_at, revoked_at
+		FROM auth_session
+		WHERE account_id = $1 AND revoked_at IS NULL
+		ORDER BY rotated_at DESC
+	`
+	var sessions []Session
+	if err := pgxscan.Select(ctx, s.db.Conn(ctx), &sessions, query, accountID); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (s *PostgresSessionStore) RevokeSession(
+	ctx context.Context,
+	accountID, sessionID uuid.UUID,
+) ([]RevokedSession, error) {
+	query := `
+		UPDATE auth_session
+		SET revoked_at = NOW()
+		WHERE id = $1 and account_id = $2 AND revoked_at IS NULL
+		RETURNING id, account_id
+	`
+	return s.revoke(ctx, query, sessionID, accountID)
+}
+
+func (s *PostgresSessionStore) RevokeByToken(ctx context.Context, token string) ([]RevokedSession, error) {
+	query := `
+		UPDATE auth_session
+		SET revoked_at = NOW()
+		WHERE (token = $1 OR prev_token = $2) AND revoked_at IS NULL
+		RETURNING id, account_id
+	`
+	return s.revoke(ctx, query, token, token)
+}
+
+func (s *PostgresSessionStore) RevokeAccount(ctx context.Context, accountID uuid.UUID) ([]RevokedSession, error) {
+	query := `
+		UPDATE auth_session
+		SET revoked_at = NOW()
+		WHERE account_id = $1 AND revoked_at IS NULL
+		RETURNING id, account_id
+	`
+	return s.revoke(ctx, query, accountID)
+}
+
+func (s *PostgresSessionStore) RevokeOthers(
+	ctx context.Context,
+	accountID, sessionID uuid.UUID,
+) ([]RevokedSession, error) {
+	query := `
+		UPDATE auth_session
+		SET revoked_at = NOW()
+		WHERE account_id = $1 AND id <> $2 AND revoked_at IS NULL
+		RETURNING id, account_id
+	`
+	return s.revoke(ctx, query, accountID, sessionID)
+}
+
+// revoke runs query, an UPDATE revoking sessions and returning their id and account_id, and records a
+// SessionRevoked event per account with it.
+func (s *PostgresSessionStore) revoke(ctx context.Context, query string, args ...any) ([]RevokedSession, error) {
+	var sessions []RevokedSession
+	err := s.db.WithTx(ctx, func(ctx context.Context) error {
+		tx := s.db.Conn(ctx)
+		// a retried transaction runs this again
+		sessions = nil
+		if err := pgxscan.Select(ctx, tx, &sessions, query, args...); err != nil {
+			return err
+		}
+
+		var revoked []events.Event
+		byAccount := map[uuid.UUID]*events.SessionRevoked{}
+		for _, session := range sessions {
+			event, ok := byAccount[session.AccountID]
+			if !ok {
+				event = &events.SessionRevoked{AccountID: session.AccountID}
+				byAccount[session.AccountID] = event
+				revoked = append(revoked, event)
+			}
+			event.SessionIDs = append(event.SessionIDs, session.ID)
+		}
+		return events.Record(ctx, tx, revoked...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (s *PostgresSessionStore) CountActive(ctx context.Context, createdAfter, rotatedAfter time.Time) (int64, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM auth_session
+		WHERE revoked_at IS NULL
+		  AND created_at >= $1
+		  AND rotated_at >= $2
+	`
+	var count int64
+	err := s.db.Conn(ctx).QueryRow(ctx, query, createdAfter, rotatedAfter).Scan(&count)
+	return count, err
+}
+
+func (s *PostgresSessionStore) DeleteInactive(
+	ctx context.Context,
+	createdAfter, rotatedAfter time.Time,
+) (int64, error) {
+	query := `
+		DELETE FROM auth_session
+		WHERE revoked_at IS NOT NULL
+		   OR created_at < $1
+		   OR rotated_at < $2
+	`
+	tag, err := s.db.Conn(ctx).Exec(ctx, query, createdAfter, rotatedAfter)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// LookupAccount is an AccountLookup reading accounts from the database, for session stores kept elsewhere.
+func (s *PostgresSessionStore) LookupAccount(ctx context.Context, accountID uuid.UUID) (*SessionAccount, error) {
+	query := `
+		SELECT id, email, is_admin, language, status
+		FROM account
+		WHERE id = $1
+	`
+	var account SessionAccount
+	err := pgxscan.Get(ctx, s.db.Conn(ctx), &account, query, accountID)
+	if err != nil {
+		if errors.Is(err, database.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &account, nil
+}
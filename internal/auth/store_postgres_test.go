@@ -0,0 +1,55 @@
+package auth_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"monolith/internal/auth"
+	"monolith/internal/auth/authtest"
+	"monolith/internal/config"
+	"monolith/internal/database"
+	"monolith/migrations"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPostgresSessionStore runs the conformance suite against the database in TEST_DATABASE_URL. It deletes
+// every session there, so point it at a disposable database.
+func TestPostgresSessionStore(t *testing.T) {
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	db, err := database.New(context.Background(), config.DatabaseConfig{
+		URL:            databaseURL,
+		ConnectTimeout: 10 * time.Second,
+	})
+	require.NoError(t, err)
+	defer db.Close()
+	migrations.Up(stdlib.OpenDBFromPool(db.PgxPool()))
+
+	authtest.TestSessionStore(t, func(t *testing.T) (auth.SessionStore, authtest.AddAccountFunc) {
+		_, err := db.Pool.Exec(context.Background(), "DELETE FROM auth_session")
+		require.NoError(t, err)
+
+		return auth.NewPostgresSessionStore(db), func(t *testing.T, status string, isAdmin bool) uuid.UUID {
+			t.Helper()
+			name := uuid.NewString()
+			var id uuid.UUID
+			err := db.Pool.QueryRow(context.Background(), `
+				INSERT INTO account (username, email, is_admin, status)
+				VALUES ($1, $2, $3, $4)
+				RETURNING id
+			`, name, name+"@example.com", isAdmin, status).Scan(&id)
+			require.NoError(t, err)
+			t.Cleanup(func() {
+				_, _ = db.Pool.Exec(context.Background(), "DELETE FROM account WHERE id = $1", id)
+			})
+			return id
+		}
+	})
+}
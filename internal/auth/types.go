@@ -76,18 +76,20 @@ type AuthUser struct {
 	AccountID uuid.UUID
 	Email     string
 	IsAdmin   bool
+	Language  string
 	SessionID uuid.UUID
 }
 
 // AuthContext holds the complete authentication context from a consolidated query
 type AuthContext struct {
-	SessionID      uuid.UUID
-	SessionToken   string
-	AccountID      uuid.UUID
-	AccountEmail   string
-	AccountIsAdmin bool
-	AccountStatus  string
-	SessionCreated time.Time
-	SessionRotated time.Time
-	SessionRevoked *time.Time
+	SessionID       uuid.UUID
+	SessionToken    string
+	AccountID       uuid.UUID
+	AccountEmail    string
+	AccountIsAdmin  bool
+	AccountLanguage *string
+	AccountStatus   string
+	SessionCreated  time.Time
+	SessionRotated  time.Time
+	SessionRevoked  *time.Time
 }
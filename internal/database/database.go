@@ -2,45 +2,198 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"strconv"
+	"time"
+
+	"monolith/internal/config"
+	"monolith/internal/logger"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
 )
 
 var ErrNoRows = pgx.ErrNoRows
 
-type Pool interface {
+// uniqueViolationCode is the SQLSTATE Postgres reports when a unique constraint is violated.
+const uniqueViolationCode = "23505"
+
+// IsUniqueViolation reports whether err was caused by a unique constraint violation.
+func IsUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode
+}
+
+// Querier runs statements, on the pool or in a transaction. Begin starts a savepoint on a transaction.
+type Querier interface {
 	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
 	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
 	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
 	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+type Pool interface {
+	Querier
 	Close()
 }
 
 type DB struct {
 	Pool    Pool
 	pgxPool *pgxpool.Pool
+	// replica serves read-only queries when a read replica is configured.
+	replica        Pool
+	replicaPgxPool *pgxpool.Pool
+	// queryStats aggregates the statements run on both pools.
+	queryStats *QueryStats
 }
 
-func New(databaseURL string) (*DB, error) {
-	pool, err := pgxpool.New(context.Background(), databaseURL)
+const (
+	connectRetryBaseDelay = 500 * time.Millisecond
+	connectRetryMaxDelay  = 10 * time.Second
+)
+
+// New connects to the database, and to its read replica if cfg has one. It retries with backoff until the
+// database is reachable or cfg.ConnectTimeout has passed, so the app can start before the database does.
+func New(ctx context.Context, cfg config.DatabaseConfig) (*DB, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ConnectTimeout)
+	defer cancel()
+
+	stats := NewQueryStats()
+	tracer := &queryTracer{slowThreshold: cfg.SlowQueryThreshold, stats: stats}
+
+	pool, err := connect(ctx, cfg.URL, cfg, tracer)
+	if err != nil {
+		return nil, err
+	}
+	db := &DB{Pool: pool, pgxPool: pool, queryStats: stats}
+
+	if cfg.ReplicaURL != "" {
+		replica, err := connect(ctx, cfg.ReplicaURL, cfg, tracer)
+		if err != nil {
+			pool.Close()
+			return nil, fmt.Errorf("read replica: %w", err)
+		}
+		db.replica, db.replicaPgxPool = replica, replica
+	}
+	return db, nil
+}
+
+func connect(
+	ctx context.Context, databaseURL string, cfg config.DatabaseConfig, tracer pgx.QueryTracer,
+) (*pgxpool.Pool, error) {
+	poolConfig, err := newPoolConfig(databaseURL, cfg)
+	if err != nil {
+		return nil, err
+	}
+	poolConfig.ConnConfig.Tracer = tracer
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create connection pool: %w", err)
 	}
 
-	if pingErr := pool.Ping(context.Background()); pingErr != nil {
-		return nil, fmt.Errorf("failed to ping database: %w", pingErr)
+	delay := connectRetryBaseDelay
+	for {
+		pingErr := pool.Ping(ctx)
+		if pingErr == nil {
+			return pool, nil
+		}
+
+		logger.FromContext(ctx).Warn("Database not reachable, retrying",
+			"host", poolConfig.ConnConfig.Host, "delay", delay, "error", pingErr)
+		select {
+		case <-ctx.Done():
+			pool.Close()
+			return nil, fmt.Errorf("failed to ping database: %w", pingErr)
+		case <-time.After(delay):
+		}
+		delay = min(2*delay, connectRetryMaxDelay)
+	}
+}
+
+func newPoolConfig(databaseURL string, cfg config.DatabaseConfig) (*pgxpool.Config, error) {
+	poolConfig, err := pgxpool.ParseConfig(databaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse database URL: %w", err)
+	}
+
+	if cfg.MaxConns > 0 {
+		poolConfig.MaxConns = int32(cfg.MaxConns) //nolint:gosec // a pool size never overflows
+	}
+	if cfg.MinConns > 0 {
+		poolConfig.MinConns = int32(min(cfg.MinConns, int(poolConfig.MaxConns))) //nolint:gosec // bounded by MaxConns
+	}
+	if cfg.MaxConnLifetime > 0 {
+		poolConfig.MaxConnLifetime = cfg.MaxConnLifetime
+	}
+	if cfg.MaxConnIdleTime > 0 {
+		poolConfig.MaxConnIdleTime = cfg.MaxConnIdleTime
+	}
+	if cfg.ApplicationName != "" {
+		poolConfig.ConnConfig.RuntimeParams["application_name"] = cfg.ApplicationName
 	}
+	if cfg.StatementTimeout > 0 {
+		timeout := strconv.FormatInt(cfg.StatementTimeout.Milliseconds(), 10)
+		poolConfig.ConnConfig.RuntimeParams["statement_timeout"] = timeout
+	}
+	return poolConfig, nil
+}
 
-	return &DB{Pool: pool, pgxPool: pool}, nil
+// OpenMigrationDB opens a database/sql handle for running migrations, with the settings of the pool except
+// for the statement timeout, which a long index build or backfill would run into.
+func OpenMigrationDB(cfg config.DatabaseConfig) (*sql.DB, error) {
+	connConfig, err := migrationConnConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return stdlib.OpenDB(*connConfig), nil
+}
+
+func migrationConnConfig(cfg config.DatabaseConfig) (*pgx.ConnConfig, error) {
+	poolConfig, err := newPoolConfig(cfg.URL, cfg)
+	if err != nil {
+		return nil, err
+	}
+	delete(poolConfig.ConnConfig.RuntimeParams, "statement_timeout")
+	return poolConfig.ConnConfig, nil
+}
+
+// QueryStats returns the statistics of the statements run by this instance, or nil for a DB not made by New.
+func (db *DB) QueryStats() *QueryStats {
+	return db.queryStats
 }
 
 func (db *DB) PgxPool() *pgxpool.Pool {
 	return db.pgxPool
 }
 
+// ReplicaPgxPool returns the pool of the read replica, or nil without one.
+func (db *DB) ReplicaPgxPool() *pgxpool.Pool {
+	return db.replicaPgxPool
+}
+
+// Reader is Conn for read-only queries that tolerate replication lag, such as listings and exports. They go to
+// the read replica if there is one, unless ctx carries a transaction, whose own writes only it can see.
+func (db *DB) Reader(ctx context.Context) Querier {
+	if _, ok := ctx.Value(txKey{}).(pgx.Tx); ok || db.replica == nil {
+		return db.Conn(ctx)
+	}
+	return db.replica
+}
+
+// Ping verifies a connection to the database can be acquired and used.
+func (db *DB) Ping(ctx context.Context) error {
+	_, err := db.Pool.Exec(ctx, "SELECT 1")
+	return err
+}
+
 func (db *DB) Close() {
 	db.Pool.Close()
+	if db.replica != nil {
+		db.replica.Close()
+	}
 }
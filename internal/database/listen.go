@@ -0,0 +1,68 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"monolith/internal/logger"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	listenRetryBaseDelay = time.Second
+	listenRetryMaxDelay  = 30 * time.Second
+)
+
+// Listen calls handle with the payload of every notification sent on channel until ctx is canceled. It
+// listens on a dedicated connection outside the pool and reconnects when that connection is lost.
+// Notifications sent while it is disconnected are missed; onConnect, if not nil, is called once
+// listening has (re)started so callers can catch up.
+func (db *DB) Listen(ctx context.Context, channel string, onConnect func(), handle func(payload string)) {
+	delay := listenRetryBaseDelay
+	for {
+		started := time.Now()
+		err := db.listen(ctx, channel, onConnect, handle)
+		if ctx.Err() != nil {
+			return
+		}
+		if time.Since(started) > listenRetryMaxDelay {
+			// the connection was up for a while, so this is a new outage
+			delay = listenRetryBaseDelay
+		}
+		logger.FromContext(ctx).Warn("Listen connection lost, reconnecting",
+			"channel", channel, "delay", delay, "error", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(2*delay, listenRetryMaxDelay)
+	}
+}
+
+func (db *DB) listen(ctx context.Context, channel string, onConnect func(), handle func(payload string)) error {
+	conn, err := pgx.ConnectConfig(ctx, db.pgxPool.Config().ConnConfig)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = conn.Close(context.WithoutCancel(ctx))
+	}()
+
+	if _, err = conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+		return err
+	}
+	if onConnect != nil {
+		onConnect()
+	}
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		handle(notification.Payload)
+	}
+}
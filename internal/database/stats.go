@@ -0,0 +1,114 @@
+package database
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+	"time"
+)
+
+// maxQueryStats bounds the number of statements QueryStats tracks. Statements beyond it are counted under
+// otherQueries.
+const (
+	maxQueryStats = 1000
+	otherQueries  = "<other>"
+)
+
+// QueryStat aggregates the executions of one normalized statement.
+type QueryStat struct {
+	Query string `json:"query"`
+	Calls int64  `json:"calls"`
+	// Errors counts the executions that failed, not counting queries that found no rows.
+	Errors      int64   `json:"errors"`
+	TotalTimeMs float64 `json:"totalTimeMs"`
+	MeanTimeMs  float64 `json:"meanTimeMs"`
+	MaxTimeMs   float64 `json:"maxTimeMs"`
+}
+
+// QueryStatsReport is a snapshot of the statements executed by this instance.
+type QueryStatsReport struct {
+	// Since is when the statistics started being collected.
+	Since time.Time `json:"since"`
+	// Queries are ordered by total time, highest first.
+	Queries []QueryStat `json:"queries"`
+}
+
+type queryStat struct {
+	calls     int64
+	errors    int64
+	totalTime time.Duration
+	maxTime   time.Duration
+}
+
+// QueryStats aggregates the timings of the statements run through a DB, by normalized SQL.
+type QueryStats struct {
+	mu      sync.Mutex
+	since   time.Time
+	queries map[string]*queryStat
+}
+
+func NewQueryStats() *QueryStats {
+	return &QueryStats{
+		since:   time.Now(),
+		queries: map[string]*queryStat{},
+	}
+}
+
+func (s *QueryStats) record(query string, duration time.Duration, failed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stat, ok := s.queries[query]
+	if !ok {
+		if len(s.queries) >= maxQueryStats {
+			query = otherQueries
+			stat = s.queries[query]
+		}
+		if stat == nil {
+			stat = &queryStat{}
+			s.queries[query] = stat
+		}
+	}
+
+	stat.calls++
+	if failed {
+		stat.errors++
+	}
+	stat.totalTime += duration
+	stat.maxTime = max(stat.maxTime, duration)
+}
+
+// Report returns the statements recorded so far, ordered by total time.
+func (s *QueryStats) Report() QueryStatsReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	report := QueryStatsReport{Since: s.since, Queries: make([]QueryStat, 0, len(s.queries))}
+	for query, stat := range s.queries {
+		report.Queries = append(report.Queries, QueryStat{
+			Query:       query,
+			Calls:       stat.calls,
+			Errors:      stat.errors,
+			TotalTimeMs: milliseconds(stat.totalTime),
+			MeanTimeMs:  milliseconds(stat.totalTime / time.Duration(stat.calls)),
+			MaxTimeMs:   milliseconds(stat.maxTime),
+		})
+	}
+	slices.SortFunc(report.Queries, func(a, b QueryStat) int {
+		return cmp.Or(cmp.Compare(b.TotalTimeMs, a.TotalTimeMs), cmp.Compare(a.Query, b.Query))
+	})
+	return report
+}
+
+// Reset drops the statistics collected so far.
+func (s *QueryStats) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.since = time.Now()
+	s.queries = map[string]*queryStat{}
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
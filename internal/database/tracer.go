@@ -0,0 +1,159 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"monolith/internal/logger"
+	"monolith/internal/tracing"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type queryTraceKey struct{}
+
+type queryTrace struct {
+	sql   string
+	args  []any
+	start time.Time
+	// span is nil for queries outside a traced request.
+	span trace.Span
+}
+
+// queryTracer times every query for stats and logs the ones taking slowThreshold or longer; zero disables the
+// log. It also creates a client span for each query executed within a traced request. Queries without a
+// parent span (e.g. background jobs) are not traced to avoid a flood of single-span traces.
+type queryTracer struct {
+	slowThreshold time.Duration
+	stats         *QueryStats
+}
+
+func (t *queryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	qt := &queryTrace{sql: data.SQL, args: data.Args, start: time.Now()}
+
+	if trace.SpanContextFromContext(ctx).IsValid() {
+		ctx, qt.span = tracing.Tracer().Start(ctx, querySpanName(data.SQL),
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("db.system.name", "postgresql"),
+				attribute.String("db.query.text", data.SQL),
+			),
+		)
+	}
+
+	return context.WithValue(ctx, queryTraceKey{}, qt)
+}
+
+func (t *queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	qt, ok := ctx.Value(queryTraceKey{}).(*queryTrace)
+	if !ok {
+		return
+	}
+	duration := time.Since(qt.start)
+	failed := data.Err != nil && !errors.Is(data.Err, ErrNoRows)
+
+	query := normalizeSQL(qt.sql)
+	t.stats.record(query, duration, failed)
+	if t.slowThreshold > 0 && duration >= t.slowThreshold {
+		// the request ID comes with the context logger
+		logger.FromContext(ctx).Warn("Slow query",
+			"query", query,
+			"args", redactArgs(qt.args),
+			"duration", duration,
+			"rows", data.CommandTag.RowsAffected(),
+		)
+	}
+
+	if qt.span == nil {
+		return
+	}
+	qt.span.SetAttributes(attribute.Int64("db.response.returned_rows", data.CommandTag.RowsAffected()))
+	if failed {
+		qt.span.RecordError(data.Err)
+		qt.span.SetStatus(codes.Error, data.Err.Error())
+	}
+	qt.span.End()
+}
+
+// querySpanName returns the leading SQL keyword (SELECT, UPDATE, ...) as a low cardinality span name.
+func querySpanName(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return "db.query"
+	}
+	return "db." + strings.ToLower(fields[0])
+}
+
+// normalizeSQL collapses whitespace and replaces string and numeric literals with ?, so a statement is
+// aggregated the same however it is formatted and whatever constants it embeds. Parameters like $1 are kept.
+func normalizeSQL(sql string) string {
+	var b strings.Builder
+	b.Grow(len(sql))
+	pendingSpace := false
+	var prev byte
+
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' {
+			pendingSpace = b.Len() > 0
+			prev = c
+			continue
+		}
+		if pendingSpace {
+			b.WriteByte(' ')
+			pendingSpace = false
+		}
+
+		switch {
+		case c == '\'':
+			for i++; i < len(sql); i++ {
+				if sql[i] == '\'' {
+					if i+1 < len(sql) && sql[i+1] == '\'' {
+						i++
+						continue
+					}
+					break
+				}
+			}
+			b.WriteByte('?')
+		case c == '$':
+			b.WriteByte(c)
+			for i+1 < len(sql) && isDigit(sql[i+1]) {
+				i++
+				b.WriteByte(sql[i])
+			}
+		case isDigit(c) && !isIdentByte(prev):
+			for i+1 < len(sql) && (isDigit(sql[i+1]) || sql[i+1] == '.') {
+				i++
+			}
+			b.WriteByte('?')
+		default:
+			b.WriteByte(c)
+		}
+		prev = sql[i]
+	}
+	return b.String()
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || isDigit(c) || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// redactArgs describes query arguments by type only, since they may hold personal data or secrets.
+func redactArgs(args []any) []string {
+	types := make([]string, len(args))
+	for i, arg := range args {
+		types[i] = fmt.Sprintf("%T", arg)
+	}
+	return types
+}
@@ -0,0 +1,119 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"monolith/internal/logger"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeSQL(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want string
+	}{
+		{"SELECT id FROM account WHERE id = $1", "SELECT id FROM account WHERE id = $1"},
+		{"\n\t\tSELECT id\n\t\tFROM account\n\t\tWHERE id = $12\n\t", "SELECT id FROM account WHERE id = $12"},
+		{
+			"UPDATE account SET status = 'disabled' WHERE name = 'O''Brien'",
+			"UPDATE account SET status = ? WHERE name = ?",
+		},
+		{"SELECT * FROM jobs LIMIT 100 OFFSET 2.5", "SELECT * FROM jobs LIMIT ? OFFSET ?"},
+		{"SELECT v2.col1 FROM t2 AS v2", "SELECT v2.col1 FROM t2 AS v2"},
+		{"SELECT now() - interval '1 hour'", "SELECT now() - interval ?"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, normalizeSQL(tt.sql), tt.sql)
+	}
+}
+
+func TestQueryTracer(t *testing.T) {
+	trace := func(tracer *queryTracer, ctx context.Context, sql string, err error, args ...any) {
+		ctx = tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: sql, Args: args})
+		tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{CommandTag: pgconn.NewCommandTag("SELECT 1"), Err: err})
+	}
+
+	t.Run("aggregates statements by normalized SQL", func(t *testing.T) {
+		tracer := &queryTracer{stats: NewQueryStats()}
+		ctx := context.Background()
+
+		trace(tracer, ctx, "SELECT id FROM account WHERE status = 'active'", nil)
+		trace(tracer, ctx, "SELECT id\n  FROM account WHERE status = 'disabled'", nil)
+		trace(tracer, ctx, "SELECT id FROM account WHERE status = 'active'", ErrNoRows)
+		trace(tracer, ctx, "DELETE FROM session WHERE id = $1", errors.New("boom"), "id")
+
+		report := tracer.stats.Report()
+		require.Len(t, report.Queries, 2)
+		byQuery := map[string]QueryStat{}
+		for _, stat := range report.Queries {
+			byQuery[stat.Query] = stat
+		}
+
+		selectStat := byQuery["SELECT id FROM account WHERE status = ?"]
+		assert.Equal(t, int64(3), selectStat.Calls)
+		assert.Zero(t, selectStat.Errors)
+		assert.GreaterOrEqual(t, selectStat.TotalTimeMs, selectStat.MaxTimeMs)
+		assert.GreaterOrEqual(t, selectStat.MaxTimeMs, selectStat.MeanTimeMs)
+
+		deleteStat := byQuery["DELETE FROM session WHERE id = $1"]
+		assert.Equal(t, int64(1), deleteStat.Calls)
+		assert.Equal(t, int64(1), deleteStat.Errors)
+
+		tracer.stats.Reset()
+		assert.Empty(t, tracer.stats.Report().Queries)
+	})
+
+	t.Run("logs slow queries without their arguments", func(t *testing.T) {
+		var buf bytes.Buffer
+		log := slog.New(slog.NewJSONHandler(&buf, nil)).With("request_id", "req-1")
+		ctx := logger.WithContext(context.Background(), log)
+
+		tracer := &queryTracer{slowThreshold: time.Nanosecond, stats: NewQueryStats()}
+		trace(tracer, ctx, "SELECT id FROM account WHERE email = $1 AND status = 'active'", nil, "jane@example.com")
+
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+		assert.Equal(t, "Slow query", entry["msg"])
+		assert.Equal(t, "req-1", entry["request_id"])
+		assert.Equal(t, "SELECT id FROM account WHERE email = $1 AND status = ?", entry["query"])
+		assert.Equal(t, []any{"string"}, entry["args"])
+		assert.NotContains(t, buf.String(), "jane@example.com")
+	})
+
+	t.Run("doesn't log fast queries", func(t *testing.T) {
+		var buf bytes.Buffer
+		ctx := logger.WithContext(context.Background(), slog.New(slog.NewJSONHandler(&buf, nil)))
+
+		tracer := &queryTracer{slowThreshold: time.Hour, stats: NewQueryStats()}
+		trace(tracer, ctx, "SELECT 1", nil)
+		tracer = &queryTracer{stats: NewQueryStats()}
+		trace(tracer, ctx, "SELECT 1", nil)
+
+		assert.Empty(t, buf.String())
+	})
+}
+
+func TestQueryStats_BoundsStatements(t *testing.T) {
+	stats := NewQueryStats()
+	for i := range maxQueryStats + 5 {
+		stats.record(string(rune('a'+i%26))+time.Duration(i).String(), time.Millisecond, false)
+	}
+
+	report := stats.Report()
+	assert.Len(t, report.Queries, maxQueryStats+1)
+	for _, stat := range report.Queries {
+		if stat.Query == otherQueries {
+			assert.Equal(t, int64(5), stat.Calls)
+		}
+	}
+}
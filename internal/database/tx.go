@@ -0,0 +1,89 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"monolith/internal/logger"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// SQLSTATEs of the errors that are resolved by running the transaction again.
+const (
+	serializationFailureCode = "40001"
+	deadlockDetectedCode     = "40P01"
+)
+
+const (
+	txMaxAttempts    = 3
+	txRetryBaseDelay = 20 * time.Millisecond
+)
+
+type txKey struct{}
+
+// Conn returns the transaction started by WithTx that ctx carries, or the pool outside of one. Services
+// run their statements on it so they join the transaction of their caller, if any.
+func (db *DB) Conn(ctx context.Context) Querier {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
+	return db.Pool
+}
+
+// WithTx runs fn in a transaction, which the context passed to fn carries for Conn. The transaction is
+// committed if fn returns nil and rolled back otherwise.
+//
+// Inside another WithTx, fn runs in a savepoint instead: an error from fn only undoes its own changes and
+// the outer transaction can carry on. Serialization failures and deadlocks abort the whole transaction, so
+// only the outermost WithTx retries them, running fn again; fn must not have side effects outside the
+// database. The transaction must not be used from more than one goroutine at a time.
+func (db *DB) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return runTx(ctx, tx, fn)
+	}
+
+	delay := txRetryBaseDelay
+	for attempt := 1; ; attempt++ {
+		err := runTx(ctx, db.Pool, fn)
+		if err == nil || !isRetryable(err) || attempt == txMaxAttempts {
+			return err
+		}
+		logger.FromContext(ctx).Warn("Retrying transaction", "attempt", attempt, "error", err)
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay + rand.N(delay)):
+		}
+		delay *= 2
+	}
+}
+
+// WithoutTx returns a context that doesn't carry the transaction of ctx, for work that outlives it.
+func WithoutTx(ctx context.Context) context.Context {
+	return context.WithValue(ctx, txKey{}, nil)
+}
+
+func runTx(ctx context.Context, q Querier, fn func(ctx context.Context) error) error {
+	tx, err := q.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func isRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && (pgErr.Code == serializationFailureCode || pgErr.Code == deadlockDetectedCode)
+}
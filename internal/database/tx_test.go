@@ -0,0 +1,167 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDB_WithTx(t *testing.T) {
+	ctx := context.Background()
+
+	newDB := func(t *testing.T) (pgxmock.PgxPoolIface, *DB) {
+		t.Helper()
+		mock, err := pgxmock.NewPool()
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			assert.NoError(t, mock.ExpectationsWereMet())
+			mock.Close()
+		})
+		return mock, &DB{Pool: mock}
+	}
+
+	t.Run("commits and shares the transaction through the context", func(t *testing.T) {
+		mock, db := newDB(t)
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE account`).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		mock.ExpectCommit()
+		mock.ExpectRollback()
+
+		err := db.WithTx(ctx, func(ctx context.Context) error {
+			assert.NotEqual(t, db.Pool, db.Conn(ctx))
+			_, err := db.Conn(ctx).Exec(ctx, `UPDATE account SET name = 'x'`)
+			return err
+		})
+		require.NoError(t, err)
+		assert.Equal(t, db.Pool, db.Conn(ctx))
+	})
+
+	t.Run("rolls back when fn fails", func(t *testing.T) {
+		mock, db := newDB(t)
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+
+		err := db.WithTx(ctx, func(context.Context) error { return assert.AnError })
+		require.ErrorIs(t, err, assert.AnError)
+	})
+
+	t.Run("nests in a savepoint", func(t *testing.T) {
+		mock, db := newDB(t)
+		mock.ExpectBegin()
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+		mock.ExpectExec(`INSERT INTO outbox`).WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		mock.ExpectCommit()
+		mock.ExpectRollback()
+
+		err := db.WithTx(ctx, func(ctx context.Context) error {
+			// a failing savepoint leaves the outer transaction usable
+			err := db.WithTx(ctx, func(context.Context) error { return assert.AnError })
+			require.ErrorIs(t, err, assert.AnError)
+			_, err = db.Conn(ctx).Exec(ctx, `INSERT INTO outbox DEFAULT VALUES`)
+			return err
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("retries serialization failures and deadlocks", func(t *testing.T) {
+		mock, db := newDB(t)
+		codes := []string{serializationFailureCode, deadlockDetectedCode}
+		for range codes {
+			mock.ExpectBegin()
+			mock.ExpectRollback()
+		}
+		mock.ExpectBegin()
+		mock.ExpectCommit()
+		mock.ExpectRollback()
+
+		attempts := 0
+		err := db.WithTx(ctx, func(context.Context) error {
+			attempts++
+			if attempts <= len(codes) {
+				return &pgconn.PgError{Code: codes[attempts-1]}
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 3, attempts)
+	})
+
+	t.Run("gives up after the last attempt", func(t *testing.T) {
+		mock, db := newDB(t)
+		for range txMaxAttempts {
+			mock.ExpectBegin()
+			mock.ExpectRollback()
+		}
+
+		attempts := 0
+		err := db.WithTx(ctx, func(context.Context) error {
+			attempts++
+			return &pgconn.PgError{Code: serializationFailureCode}
+		})
+		var pgErr *pgconn.PgError
+		require.ErrorAs(t, err, &pgErr)
+		assert.Equal(t, txMaxAttempts, attempts)
+	})
+
+	t.Run("nested failures are retried by the outermost transaction only", func(t *testing.T) {
+		mock, db := newDB(t)
+		mock.ExpectBegin()
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+		mock.ExpectRollback()
+		mock.ExpectBegin()
+		mock.ExpectBegin()
+		mock.ExpectCommit()
+		mock.ExpectRollback()
+		mock.ExpectCommit()
+		mock.ExpectRollback()
+
+		attempts := 0
+		err := db.WithTx(ctx, func(ctx context.Context) error {
+			return db.WithTx(ctx, func(context.Context) error {
+				attempts++
+				if attempts == 1 {
+					return &pgconn.PgError{Code: deadlockDetectedCode}
+				}
+				return nil
+			})
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 2, attempts)
+	})
+
+	t.Run("doesn't retry other errors", func(t *testing.T) {
+		mock, db := newDB(t)
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+
+		attempts := 0
+		err := db.WithTx(ctx, func(context.Context) error {
+			attempts++
+			return &pgconn.PgError{Code: uniqueViolationCode}
+		})
+		require.True(t, IsUniqueViolation(err))
+		assert.Equal(t, 1, attempts)
+	})
+
+	t.Run("WithoutTx detaches from the transaction", func(t *testing.T) {
+		mock, db := newDB(t)
+		mock.ExpectBegin()
+		mock.ExpectCommit()
+		mock.ExpectRollback()
+
+		err := db.WithTx(ctx, func(ctx context.Context) error {
+			if db.Conn(WithoutTx(ctx)) != db.Pool {
+				return errors.New("WithoutTx kept the transaction")
+			}
+			return nil
+		})
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,192 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"monolith/internal/config"
+	"monolith/internal/database"
+	"monolith/internal/logger"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+)
+
+const (
+	// batchSize is how many events are read for a subscriber at a time.
+	batchSize = 100
+	// pruneInterval is how often events past the retention period are deleted.
+	pruneInterval = time.Hour
+)
+
+// Handler handles one event. Returning an error stops the subscriber at that event, which is handed to it
+// again on the next poll, so handlers must tolerate seeing an event more than once.
+type Handler func(ctx context.Context, event Envelope) error
+
+type subscriber struct {
+	name    string
+	types   []Type
+	handler Handler
+}
+
+func (s *subscriber) accepts(eventType Type) bool {
+	return len(s.types) == 0 || slices.Contains(s.types, eventType)
+}
+
+// Dispatcher delivers outbox events to subscribers. Each subscriber has its own checkpoint, so a failing
+// subscriber holds back only itself. The checkpoint row is locked while a batch is handled, so with
+// several instances running each event is dispatched by one of them at a time.
+type Dispatcher struct {
+	db          *database.DB
+	cfg         config.EventsConfig
+	subscribers []*subscriber
+}
+
+func NewDispatcher(db *database.DB, cfg config.EventsConfig) *Dispatcher {
+	return &Dispatcher{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+// Subscribe registers handler for events of the given types, or of every type if none are given. name
+// identifies the subscriber's checkpoint and must stay the same across restarts; a new subscriber starts
+// at the oldest retained event. Subscribe must be called before Run.
+func (d *Dispatcher) Subscribe(name string, handler Handler, types ...Type) {
+	d.subscribers = append(d.subscribers, &subscriber{name: name, types: types, handler: handler})
+}
+
+// Run dispatches events until ctx is canceled, polling the outbox every poll interval.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+	var lastPrune time.Time
+
+	for {
+		for _, sub := range d.subscribers {
+			for {
+				read, err := d.dispatch(ctx, sub)
+				if err != nil && ctx.Err() == nil {
+					logger.FromContext(ctx).Warn("Failed to dispatch events", "subscriber", sub.name, "error", err)
+				}
+				if err != nil || read < batchSize {
+					break
+				}
+			}
+		}
+
+		if time.Since(lastPrune) >= pruneInterval {
+			if err := d.Prune(ctx); err != nil && ctx.Err() == nil {
+				logger.FromContext(ctx).Warn("Failed to prune outbox", "error", err)
+			}
+			lastPrune = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// dispatch hands the next batch of events to sub and advances its checkpoint past the ones it handled,
+// returning how many events were read. Only events written by transactions older than every transaction
+// still running are read, so an event can't commit behind the checkpoint after it has moved on.
+func (d *Dispatcher) dispatch(ctx context.Context, sub *subscriber) (int, error) {
+	if _, err := d.db.Pool.Exec(ctx, `
+		INSERT INTO outbox_checkpoint (subscriber) VALUES ($1) ON CONFLICT DO NOTHING
+	`, sub.name); err != nil {
+		return 0, err
+	}
+
+	tx, err := d.db.Pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	var subscriberName string
+	err = tx.QueryRow(ctx, `
+		SELECT subscriber FROM outbox_checkpoint WHERE subscriber = $1 FOR UPDATE SKIP LOCKED
+	`, sub.name).Scan(&subscriberName)
+	if errors.Is(err, database.ErrNoRows) {
+		// another instance is dispatching to this subscriber
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	var batch []Envelope
+	err = pgxscan.Select(ctx, tx, &batch, `
+		SELECT o.id AS position, o.event_id AS id, o.type, o.payload, o.created_at
+		FROM outbox o
+		JOIN outbox_checkpoint c ON c.subscriber = $1
+		WHERE (o.txid, o.id) > (c.txid, c.position)
+		  AND o.txid < pg_snapshot_xmin(pg_current_snapshot())
+		ORDER BY o.txid, o.id
+		LIMIT $2
+	`, sub.name, batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	var handled int64
+	var handlerErr error
+	for _, event := range batch {
+		if sub.accepts(event.Type) {
+			if handlerErr = sub.handler(ctx, event); handlerErr != nil {
+				logger.FromContext(ctx).Warn("Event handler failed, will retry",
+					"subscriber", sub.name,
+					"event_id", event.ID,
+					"event_type", event.Type,
+					"error", handlerErr,
+				)
+				break
+			}
+		}
+		handled = event.Position
+	}
+
+	if handled != 0 {
+		if _, err := tx.Exec(ctx, `
+			UPDATE outbox_checkpoint
+			SET txid = (SELECT txid FROM outbox WHERE id = $2), position = $2, updated_at = NOW()
+			WHERE subscriber = $1
+		`, sub.name, handled); err != nil {
+			return 0, err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+
+	if handlerErr != nil {
+		// leave the rest of the batch for the next poll
+		return 0, nil
+	}
+	return len(batch), nil
+}
+
+// Prune deletes events older than the retention period that every subscriber has handled, i.e. that are
+// behind every checkpoint. Events a lagging subscriber hasn't reached yet are kept past the retention period.
+func (d *Dispatcher) Prune(ctx context.Context) error {
+	tag, err := d.db.Pool.Exec(ctx, `
+		DELETE FROM outbox o
+		WHERE o.created_at < $1
+		  AND NOT EXISTS (
+		      SELECT 1 FROM outbox_checkpoint c WHERE (o.txid, o.id) > (c.txid, c.position)
+		  )
+	`, time.Now().Add(-d.cfg.Retention))
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() > 0 {
+		logger.FromContext(ctx).Info("Outbox pruned", "deleted", tag.RowsAffected())
+	}
+	return nil
+}
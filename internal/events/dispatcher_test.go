@@ -0,0 +1,163 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"monolith/internal/config"
+	"monolith/internal/database"
+
+	"github.com/google/uuid"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDispatcher_dispatch(t *testing.T) {
+	now := time.Now()
+	columns := []string{"position", "id", "type", "payload", "created_at"}
+	batch := func() *pgxmock.Rows {
+		return pgxmock.NewRows(columns).
+			AddRow(int64(7), uuid.New(), TypeAccountCreated, json.RawMessage(`{}`), now).
+			AddRow(int64(9), uuid.New(), TypeSessionCreated, json.RawMessage(`{}`), now).
+			AddRow(int64(8), uuid.New(), TypeAccountDisabled, json.RawMessage(`{}`), now)
+	}
+	expectLocked := func(mock pgxmock.PgxPoolIface) {
+		mock.ExpectExec(`INSERT INTO outbox_checkpoint \(subscriber\) VALUES \(\$1\) ON CONFLICT DO NOTHING`).
+			WithArgs("test").
+			WillReturnResult(pgxmock.NewResult("INSERT", 0))
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT subscriber FROM outbox_checkpoint WHERE subscriber = \$1 FOR UPDATE SKIP LOCKED`).
+			WithArgs("test").
+			WillReturnRows(pgxmock.NewRows([]string{"subscriber"}).AddRow("test"))
+	}
+
+	tests := []struct {
+		name        string
+		types       []Type
+		failOn      Type
+		setupMock   func(mock pgxmock.PgxPoolIface)
+		wantRead    int
+		wantHandled []Type
+	}{
+		{
+			name: "hands events over in order and advances the checkpoint",
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				expectLocked(mock)
+				mock.ExpectQuery(`FROM outbox o\s+JOIN outbox_checkpoint c .+ ORDER BY o.txid, o.id`).
+					WithArgs("test", batchSize).
+					WillReturnRows(batch())
+				mock.ExpectExec(`UPDATE outbox_checkpoint`).
+					WithArgs("test", int64(8)).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				mock.ExpectCommit()
+				mock.ExpectRollback()
+			},
+			wantRead:    3,
+			wantHandled: []Type{TypeAccountCreated, TypeSessionCreated, TypeAccountDisabled},
+		},
+		{
+			name:  "skips events of other types",
+			types: []Type{TypeAccountDisabled},
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				expectLocked(mock)
+				mock.ExpectQuery(`FROM outbox o`).
+					WithArgs("test", batchSize).
+					WillReturnRows(batch())
+				mock.ExpectExec(`UPDATE outbox_checkpoint`).
+					WithArgs("test", int64(8)).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				mock.ExpectCommit()
+				mock.ExpectRollback()
+			},
+			wantRead:    3,
+			wantHandled: []Type{TypeAccountDisabled},
+		},
+		{
+			name:   "stops at a failing event and keeps it for the next poll",
+			failOn: TypeSessionCreated,
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				expectLocked(mock)
+				mock.ExpectQuery(`FROM outbox o`).
+					WithArgs("test", batchSize).
+					WillReturnRows(batch())
+				mock.ExpectExec(`UPDATE outbox_checkpoint`).
+					WithArgs("test", int64(7)).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				mock.ExpectCommit()
+				mock.ExpectRollback()
+			},
+			wantRead:    0,
+			wantHandled: []Type{TypeAccountCreated},
+		},
+		{
+			name: "leaves the checkpoint alone when nothing is pending",
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				expectLocked(mock)
+				mock.ExpectQuery(`FROM outbox o`).
+					WithArgs("test", batchSize).
+					WillReturnRows(pgxmock.NewRows(columns))
+				mock.ExpectCommit()
+				mock.ExpectRollback()
+			},
+			wantRead: 0,
+		},
+		{
+			name: "skips a subscriber another instance is dispatching to",
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectExec(`INSERT INTO outbox_checkpoint`).
+					WithArgs("test").
+					WillReturnResult(pgxmock.NewResult("INSERT", 0))
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT subscriber FROM outbox_checkpoint`).
+					WithArgs("test").
+					WillReturnError(database.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			wantRead: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mock.Close()
+			tt.setupMock(mock)
+
+			var handled []Type
+			d := NewDispatcher(&database.DB{Pool: mock}, config.EventsConfig{})
+			d.Subscribe("test", func(_ context.Context, event Envelope) error {
+				if event.Type == tt.failOn {
+					return errors.New("subscriber unavailable")
+				}
+				handled = append(handled, event.Type)
+				return nil
+			}, tt.types...)
+
+			read, err := d.dispatch(context.Background(), d.subscribers[0])
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantRead, read)
+			assert.Equal(t, tt.wantHandled, handled)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestDispatcher_Prune(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	mock.ExpectExec(`DELETE FROM outbox o\s+WHERE o.created_at < \$1\s+AND NOT EXISTS \(\s+` +
+		`SELECT 1 FROM outbox_checkpoint c WHERE \(o.txid, o.id\) > \(c.txid, c.position\)`).
+		WithArgs(pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("DELETE", 3))
+
+	d := NewDispatcher(&database.DB{Pool: mock}, config.EventsConfig{Retention: 24 * time.Hour})
+	require.NoError(t, d.Prune(context.Background()))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
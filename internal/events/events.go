@@ -0,0 +1,138 @@
+// Package events records domain events in a transactional outbox and dispatches them to in-process
+// subscribers. An event is written by the transaction making the change it describes, so it exists if
+// and only if the change commits; the dispatcher then hands it to every subscriber at least once, in the
+// order the transactions committed.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Type names an event. It is stored with the event and is what subscribers filter on.
+type Type string
+
+const (
+	TypeAccountCreated  Type = "account.created"
+	TypeAccountInvited  Type = "account.invited"
+	TypeAccountUpdated  Type = "account.updated"
+	TypeAccountDisabled Type = "account.disabled"
+	TypeAccountEnabled  Type = "account.enabled"
+	TypeAccountDeleted  Type = "account.deleted"
+	TypeSessionCreated  Type = "session.created"
+	TypeSessionRevoked  Type = "session.revoked"
+	// TypeNotificationCreated is a message for a user, shown by the web app.
+	TypeNotificationCreated Type = "notification.created"
+)
+
+// Event is a domain event. Its JSON encoding is the stored payload.
+type Event interface {
+	EventType() Type
+}
+
+type AccountCreated struct {
+	AccountID uuid.UUID `json:"accountId"`
+	Username  string    `json:"username"`
+	Email     string    `json:"email"`
+	Name      *string   `json:"name"`
+	IsAdmin   bool      `json:"isAdmin"`
+	Status    string    `json:"status"`
+}
+
+// AccountInvited is recorded instead of AccountCreated for accounts created by an invitation.
+type AccountInvited struct {
+	AccountID uuid.UUID `json:"accountId"`
+	Username  string    `json:"username"`
+	Email     string    `json:"email"`
+	IsAdmin   bool      `json:"isAdmin"`
+}
+
+// AccountUpdated is recorded when an administrator changes the profile of an account.
+type AccountUpdated struct {
+	AccountID uuid.UUID `json:"accountId"`
+	Username  string    `json:"username"`
+	Email     string    `json:"email"`
+	Name      *string   `json:"name"`
+}
+
+type AccountDisabled struct {
+	AccountID uuid.UUID `json:"accountId"`
+}
+
+type AccountEnabled struct {
+	AccountID uuid.UUID `json:"accountId"`
+}
+
+type AccountDeleted struct {
+	AccountID uuid.UUID `json:"accountId"`
+}
+
+type SessionCreated struct {
+	SessionID uuid.UUID `json:"sessionId"`
+	AccountID uuid.UUID `json:"accountId"`
+	UserAgent string    `json:"userAgent"`
+	ClientIP  string    `json:"clientIp"`
+}
+
+// SessionRevoked is recorded once per revocation, which may cover several sessions of the account.
+type SessionRevoked struct {
+	AccountID  uuid.UUID   `json:"accountId"`
+	SessionIDs []uuid.UUID `json:"sessionIds"`
+}
+
+type NotificationCreated struct {
+	AccountID uuid.UUID `json:"accountId"`
+	Title     string    `json:"title"`
+	Body      string    `json:"body"`
+}
+
+func (AccountCreated) EventType() Type      { return TypeAccountCreated }
+func (AccountInvited) EventType() Type      { return TypeAccountInvited }
+func (AccountUpdated) EventType() Type      { return TypeAccountUpdated }
+func (AccountDisabled) EventType() Type     { return TypeAccountDisabled }
+func (AccountEnabled) EventType() Type      { return TypeAccountEnabled }
+func (AccountDeleted) EventType() Type      { return TypeAccountDeleted }
+func (SessionCreated) EventType() Type      { return TypeSessionCreated }
+func (SessionRevoked) EventType() Type      { return TypeSessionRevoked }
+func (NotificationCreated) EventType() Type { return TypeNotificationCreated }
+
+// Envelope is a recorded event as subscribers receive it.
+type Envelope struct {
+	// Position orders events within a transaction; across transactions they are ordered by commit.
+	Position  int64
+	ID        uuid.UUID
+	Type      Type
+	Payload   json.RawMessage
+	CreatedAt time.Time
+}
+
+// Decode unmarshals the payload into v, which should point to the event type matching e.Type.
+func (e Envelope) Decode(v any) error {
+	return json.Unmarshal(e.Payload, v)
+}
+
+type execer interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
+// Record writes events to the outbox. q should be the transaction making the change the events describe.
+func Record(ctx context.Context, q execer, events ...Event) error {
+	for _, event := range events {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		_, err = q.Exec(ctx, `
+			INSERT INTO outbox (event_id, type, payload)
+			VALUES ($1, $2, $3)
+		`, uuid.New(), string(event.EventType()), json.RawMessage(payload))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
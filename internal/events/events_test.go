@@ -0,0 +1,33 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecord(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	accountID := uuid.MustParse("5b0f4c2e-8f43-4a55-9d53-3f1f0a6c2b11")
+	mock.ExpectExec(`INSERT INTO outbox \(event_id, type, payload\)`).
+		WithArgs(pgxmock.AnyArg(), "account.disabled",
+			json.RawMessage(`{"accountId":"5b0f4c2e-8f43-4a55-9d53-3f1f0a6c2b11"}`)).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectExec(`INSERT INTO outbox \(event_id, type, payload\)`).
+		WithArgs(pgxmock.AnyArg(), "session.revoked", pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+	err = Record(context.Background(), mock,
+		AccountDisabled{AccountID: accountID},
+		&SessionRevoked{AccountID: accountID, SessionIDs: []uuid.UUID{uuid.New()}},
+	)
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,144 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"monolith/internal/logger"
+)
+
+// Check statuses reported by the registry
+const (
+	StatusOK       = "ok"
+	StatusFailing  = "failing"
+	StatusNotReady = "not_ready"
+)
+
+// DefaultTimeout bounds a single check so one hung dependency can't stall the readiness probe.
+const DefaultTimeout = 2 * time.Second
+
+// HealthChecker reports whether a dependency or subsystem is ready to serve traffic.
+// A nil error means healthy.
+type HealthChecker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc adapts a function to the HealthChecker interface.
+type CheckerFunc func(ctx context.Context) error
+
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// CheckResult is the outcome of a single named check. The error of a failing check is logged rather than
+// reported, since readiness is served to unauthenticated callers.
+type CheckResult struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latencyMs"`
+}
+
+// Report aggregates the results of all registered checks.
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// Ready reports whether every check passed and the service isn't shutting down.
+func (r Report) Ready() bool {
+	return r.Status == StatusOK
+}
+
+type namedChecker struct {
+	name    string
+	checker HealthChecker
+}
+
+// Registry holds the readiness checks subsystems register at startup.
+type Registry struct {
+	mu           sync.RWMutex
+	checkers     []namedChecker
+	timeout      time.Duration
+	shuttingDown atomic.Bool
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		timeout: DefaultTimeout,
+	}
+}
+
+// Register adds a named readiness check. Registering a name twice replaces the previous check.
+func (r *Registry) Register(name string, checker HealthChecker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.checkers {
+		if r.checkers[i].name == name {
+			r.checkers[i].checker = checker
+			return
+		}
+	}
+	r.checkers = append(r.checkers, namedChecker{name: name, checker: checker})
+}
+
+// SetShuttingDown makes readiness fail so load balancers stop routing new traffic while in-flight requests drain.
+func (r *Registry) SetShuttingDown() {
+	r.shuttingDown.Store(true)
+}
+
+// ShuttingDown reports whether SetShuttingDown has been called.
+func (r *Registry) ShuttingDown() bool {
+	return r.shuttingDown.Load()
+}
+
+// Check runs all registered checks concurrently, each bounded by the registry timeout.
+func (r *Registry) Check(ctx context.Context) Report {
+	r.mu.RLock()
+	checkers := make([]namedChecker, len(r.checkers))
+	copy(checkers, r.checkers)
+	r.mu.RUnlock()
+
+	results := make([]CheckResult, len(checkers))
+
+	var wg sync.WaitGroup
+	for i, nc := range checkers {
+		wg.Go(func() {
+			results[i] = r.run(ctx, nc)
+		})
+	}
+	wg.Wait()
+
+	report := Report{
+		Status: StatusOK,
+		Checks: make(map[string]CheckResult, len(checkers)),
+	}
+	for i, nc := range checkers {
+		report.Checks[nc.name] = results[i]
+		if results[i].Status != StatusOK {
+			report.Status = StatusFailing
+		}
+	}
+
+	if r.ShuttingDown() {
+		report.Status = StatusNotReady
+	}
+
+	return report
+}
+
+func (r *Registry) run(ctx context.Context, nc namedChecker) CheckResult {
+	checkCtx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := nc.checker.Check(checkCtx)
+	latency := float64(time.Since(start).Microseconds()) / 1000
+
+	if err != nil {
+		logger.FromContext(ctx).Warn("Readiness check failed", "check", nc.name, "error", err)
+		return CheckResult{Status: StatusFailing, LatencyMs: latency}
+	}
+	return CheckResult{Status: StatusOK, LatencyMs: latency}
+}
@@ -0,0 +1,112 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_Check(t *testing.T) {
+	tests := []struct {
+		name         string
+		checkers     map[string]HealthChecker
+		shuttingDown bool
+		wantStatus   string
+		wantChecks   map[string]string
+	}{
+		{
+			name:       "no checks",
+			checkers:   map[string]HealthChecker{},
+			wantStatus: StatusOK,
+			wantChecks: map[string]string{},
+		},
+		{
+			name: "all passing",
+			checkers: map[string]HealthChecker{
+				"database":   CheckerFunc(func(context.Context) error { return nil }),
+				"migrations": CheckerFunc(func(context.Context) error { return nil }),
+			},
+			wantStatus: StatusOK,
+			wantChecks: map[string]string{"database": StatusOK, "migrations": StatusOK},
+		},
+		{
+			name: "one failing",
+			checkers: map[string]HealthChecker{
+				"database":   CheckerFunc(func(context.Context) error { return errors.New("connection refused") }),
+				"migrations": CheckerFunc(func(context.Context) error { return nil }),
+			},
+			wantStatus: StatusFailing,
+			wantChecks: map[string]string{"database": StatusFailing, "migrations": StatusOK},
+		},
+		{
+			name: "shutting down",
+			checkers: map[string]HealthChecker{
+				"database": CheckerFunc(func(context.Context) error { return nil }),
+			},
+			shuttingDown: true,
+			wantStatus:   StatusNotReady,
+			wantChecks:   map[string]string{"database": StatusOK},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRegistry()
+			for name, checker := range tt.checkers {
+				r.Register(name, checker)
+			}
+			if tt.shuttingDown {
+				r.SetShuttingDown()
+			}
+
+			report := r.Check(context.Background())
+
+			assert.Equal(t, tt.wantStatus, report.Status)
+			assert.Equal(t, tt.wantStatus == StatusOK, report.Ready())
+			require.Len(t, report.Checks, len(tt.wantChecks))
+			for name, status := range tt.wantChecks {
+				assert.Equal(t, status, report.Checks[name].Status, name)
+			}
+		})
+	}
+}
+
+func TestRegistry_CheckTimeout(t *testing.T) {
+	r := NewRegistry()
+	r.timeout = 10 * time.Millisecond
+	r.Register("slow", CheckerFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+
+	report := r.Check(context.Background())
+
+	assert.Equal(t, StatusFailing, report.Status)
+	assert.Equal(t, StatusFailing, report.Checks["slow"].Status)
+}
+
+func TestRegistry_RegisterReplaces(t *testing.T) {
+	r := NewRegistry()
+	r.Register("database", CheckerFunc(func(context.Context) error { return errors.New("down") }))
+	r.Register("database", CheckerFunc(func(context.Context) error { return nil }))
+
+	report := r.Check(context.Background())
+
+	assert.True(t, report.Ready())
+	assert.Len(t, report.Checks, 1)
+}
+
+func TestHeartbeat(t *testing.T) {
+	h := NewHeartbeat(time.Minute)
+	require.Error(t, h.Check(context.Background()), "heartbeat should fail before the first beat")
+
+	h.Beat()
+	require.NoError(t, h.Check(context.Background()))
+
+	h.lastBeat.Store(time.Now().Add(-2 * time.Minute).UnixNano())
+	assert.Error(t, h.Check(context.Background()))
+}
@@ -0,0 +1,37 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Heartbeat tracks the liveness of a background worker. The worker calls Beat after each
+// iteration and the check fails when no beat was seen within maxAge.
+type Heartbeat struct {
+	maxAge   time.Duration
+	lastBeat atomic.Int64
+}
+
+func NewHeartbeat(maxAge time.Duration) *Heartbeat {
+	return &Heartbeat{maxAge: maxAge}
+}
+
+// Beat records that the worker is alive.
+func (h *Heartbeat) Beat() {
+	h.lastBeat.Store(time.Now().UnixNano())
+}
+
+func (h *Heartbeat) Check(_ context.Context) error {
+	last := h.lastBeat.Load()
+	if last == 0 {
+		return errors.New("worker has not started")
+	}
+
+	if age := time.Since(time.Unix(0, last)); age > h.maxAge {
+		return fmt.Errorf("last heartbeat %s ago exceeds %s", age.Round(time.Second), h.maxAge)
+	}
+	return nil
+}
@@ -0,0 +1,9 @@
+package idempotency
+
+import "errors"
+
+var (
+	ErrInvalidKey        = errors.New("invalid idempotency key")
+	ErrKeyReused         = errors.New("idempotency key reused with a different request")
+	ErrRequestInProgress = errors.New("request with this idempotency key is still in progress")
+)
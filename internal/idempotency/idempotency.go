@@ -0,0 +1,149 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"time"
+
+	"monolith/internal/database"
+	"monolith/internal/logger"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+)
+
+// HeaderKey is the request header carrying the client generated idempotency key.
+const HeaderKey = "Idempotency-Key"
+
+// HeaderReplayed is set on responses replayed from a stored record.
+const HeaderReplayed = "Idempotent-Replayed"
+
+// MaxKeyLength bounds client supplied keys; UUIDs and similar random tokens fit comfortably.
+const MaxKeyLength = 255
+
+// AppliesTo reports whether requests with method are deduplicated by their idempotency key. Safe methods
+// and PUT are idempotent by definition.
+func AppliesTo(method string) bool {
+	return method == http.MethodPost || method == http.MethodPatch || method == http.MethodDelete
+}
+
+// Record is a stored request outcome. Status is nil while the original request is still being processed.
+type Record struct {
+	Fingerprint string  `db:"fingerprint"`
+	Status      *int    `db:"response_status"`
+	ContentType *string `db:"response_content_type"`
+	Body        []byte  `db:"response_body"`
+}
+
+// Completed reports whether the original request finished and its response can be replayed.
+func (r *Record) Completed() bool {
+	return r.Status != nil
+}
+
+// Store keeps idempotency records in Postgres for ttl after the first request.
+type Store struct {
+	db  *database.DB
+	ttl time.Duration
+}
+
+func NewStore(db *database.DB, ttl time.Duration) *Store {
+	return &Store{
+		db:  db,
+		ttl: ttl,
+	}
+}
+
+// Fingerprint identifies a request by method, path and body so a reused key with a different payload
+// can be detected.
+func Fingerprint(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method))
+	h.Write([]byte{0})
+	h.Write([]byte(path))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Reserve claims key within scope for a request with the given fingerprint. It returns nil when the caller
+// owns the key and must process the request, or the existing record otherwise. Expired records are
+// reclaimed.
+//
+// Returns:
+// - ErrKeyReused if the key was used for a request with a different fingerprint
+// - ErrRequestInProgress if the original request hasn't completed yet
+func (s *Store) Reserve(ctx context.Context, scope, key, fingerprint string) (*Record, error) {
+	var claimed string
+	err := pgxscan.Get(ctx, s.db.Pool, &claimed, `
+		INSERT INTO idempotency_key (scope, key, fingerprint, expires_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (scope, key) DO UPDATE
+		SET fingerprint = EXCLUDED.fingerprint,
+		    response_status = NULL,
+		    response_content_type = NULL,
+		    response_body = NULL,
+		    created_at = NOW(),
+		    expires_at = EXCLUDED.expires_at
+		WHERE idempotency_key.expires_at < NOW()
+		RETURNING key
+	`, scope, key, fingerprint, time.Now().Add(s.ttl))
+	if err == nil {
+		return nil, nil
+	}
+	if !errors.Is(err, database.ErrNoRows) {
+		return nil, err
+	}
+
+	var record Record
+	err = pgxscan.Get(ctx, s.db.Pool, &record, `
+		SELECT fingerprint, response_status, response_content_type, response_body
+		FROM idempotency_key
+		WHERE scope = $1 AND key = $2
+	`, scope, key)
+	if err != nil {
+		if errors.Is(err, database.ErrNoRows) {
+			// released by the original request between our insert and select
+			return nil, ErrRequestInProgress
+		}
+		return nil, err
+	}
+
+	if record.Fingerprint != fingerprint {
+		return nil, ErrKeyReused
+	}
+	if !record.Completed() {
+		return nil, ErrRequestInProgress
+	}
+	return &record, nil
+}
+
+// Complete stores the response of a reserved request so retries can replay it.
+func (s *Store) Complete(ctx context.Context, scope, key string, status int, contentType string, body []byte) error {
+	_, err := s.db.Pool.Exec(ctx, `
+		UPDATE idempotency_key
+		SET response_status = $3, response_content_type = $4, response_body = $5
+		WHERE scope = $1 AND key = $2
+	`, scope, key, status, contentType, body)
+	return err
+}
+
+// Release forgets a reserved key so the request can be retried, e.g. after a server error.
+func (s *Store) Release(ctx context.Context, scope, key string) error {
+	_, err := s.db.Pool.Exec(ctx, `
+		DELETE FROM idempotency_key WHERE scope = $1 AND key = $2 AND response_status IS NULL
+	`, scope, key)
+	return err
+}
+
+// DeleteExpired removes records past their TTL.
+func (s *Store) DeleteExpired(ctx context.Context) error {
+	tag, err := s.db.Pool.Exec(ctx, `DELETE FROM idempotency_key WHERE expires_at < NOW()`)
+	if err != nil {
+		return err
+	}
+
+	logger.FromContext(ctx).Info("Idempotency keys cleaned up", "deleted", tag.RowsAffected())
+	return nil
+}
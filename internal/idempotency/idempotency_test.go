@@ -0,0 +1,113 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"monolith/internal/database"
+
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	reserveQuery = `INSERT INTO idempotency_key`
+	selectQuery  = `SELECT fingerprint, response_status, response_content_type, response_body FROM idempotency_key`
+)
+
+func TestFingerprint(t *testing.T) {
+	base := Fingerprint(http.MethodPost, "/api/v1/accounts", []byte(`{"name":"a"}`))
+
+	assert.Equal(t, base, Fingerprint(http.MethodPost, "/api/v1/accounts", []byte(`{"name":"a"}`)))
+	assert.NotEqual(t, base, Fingerprint(http.MethodPost, "/api/v1/accounts", []byte(`{"name":"b"}`)))
+	assert.NotEqual(t, base, Fingerprint(http.MethodPatch, "/api/v1/accounts", []byte(`{"name":"a"}`)))
+	assert.NotEqual(t, base, Fingerprint(http.MethodPost, "/api/v1/accounts/1", []byte(`{"name":"a"}`)))
+}
+
+func TestStore_Reserve(t *testing.T) {
+	status := http.StatusCreated
+	contentType := "application/json"
+	columns := []string{"fingerprint", "response_status", "response_content_type", "response_body"}
+
+	tests := []struct {
+		name       string
+		setupMock  func(mock pgxmock.PgxPoolIface)
+		wantRecord bool
+		wantErr    error
+	}{
+		{
+			name: "new key is claimed",
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery(reserveQuery).
+					WithArgs("scope", "key", "fp", pgxmock.AnyArg()).
+					WillReturnRows(pgxmock.NewRows([]string{"key"}).AddRow("key"))
+			},
+		},
+		{
+			name: "completed request is replayed",
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery(reserveQuery).
+					WithArgs("scope", "key", "fp", pgxmock.AnyArg()).
+					WillReturnError(database.ErrNoRows)
+				mock.ExpectQuery(selectQuery).
+					WithArgs("scope", "key").
+					WillReturnRows(pgxmock.NewRows(columns).AddRow("fp", &status, &contentType, []byte(`{}`)))
+			},
+			wantRecord: true,
+		},
+		{
+			name: "different payload",
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery(reserveQuery).
+					WithArgs("scope", "key", "fp", pgxmock.AnyArg()).
+					WillReturnError(database.ErrNoRows)
+				mock.ExpectQuery(selectQuery).
+					WithArgs("scope", "key").
+					WillReturnRows(pgxmock.NewRows(columns).AddRow("other", &status, &contentType, []byte(`{}`)))
+			},
+			wantErr: ErrKeyReused,
+		},
+		{
+			name: "original request still running",
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery(reserveQuery).
+					WithArgs("scope", "key", "fp", pgxmock.AnyArg()).
+					WillReturnError(database.ErrNoRows)
+				mock.ExpectQuery(selectQuery).
+					WithArgs("scope", "key").
+					WillReturnRows(pgxmock.NewRows(columns).AddRow("fp", (*int)(nil), (*string)(nil), []byte(nil)))
+			},
+			wantErr: ErrRequestInProgress,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mock.Close()
+
+			tt.setupMock(mock)
+			store := NewStore(&database.DB{Pool: mock}, time.Hour)
+
+			record, err := store.Reserve(context.Background(), "scope", "key", "fp")
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			if tt.wantRecord {
+				require.NotNil(t, record)
+				assert.True(t, record.Completed())
+				assert.Equal(t, status, *record.Status)
+				assert.JSONEq(t, `{}`, string(record.Body))
+			} else {
+				assert.Nil(t, record)
+			}
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
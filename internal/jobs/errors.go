@@ -0,0 +1,8 @@
+package jobs
+
+import "errors"
+
+var (
+	ErrUnknownKind     = errors.New("no handler is registered for the job kind")
+	ErrJobNotRetryable = errors.New("only pending and dead jobs can be retried")
+)
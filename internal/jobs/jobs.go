@@ -0,0 +1,186 @@
+// Package jobs runs background work from a Postgres-backed queue. Jobs are rows in the job table that a
+// pool of workers claims with FOR UPDATE SKIP LOCKED, so any number of instances can share the queue.
+// Failed jobs are retried with exponential backoff and dead-lettered once they run out of attempts.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"monolith/internal/config"
+	"monolith/internal/database"
+	"monolith/internal/logger"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/google/uuid"
+)
+
+const (
+	// jobColumns are the columns scanned into a Job.
+	jobColumns = `id, kind, payload, status, unique_key, attempts, max_attempts, run_at, locked_until,
+		last_error, created_at, updated_at, finished_at`
+	// defaultListLimit is how many jobs ListJobs returns unless asked otherwise.
+	defaultListLimit = 100
+)
+
+// handlerFunc runs a job from its stored payload.
+type handlerFunc func(ctx context.Context, payload json.RawMessage) error
+
+type Queue struct {
+	db       *database.DB
+	cfg      config.JobsConfig
+	handlers map[string]handlerFunc
+}
+
+func NewQueue(db *database.DB, cfg config.JobsConfig) *Queue {
+	return &Queue{
+		db:       db,
+		cfg:      cfg,
+		handlers: map[string]handlerFunc{},
+	}
+}
+
+// Register makes the queue run jobs of T's kind with handle. A job may run more than once, for example
+// when its worker dies before recording the outcome, so handle must be safe to repeat. Register must be
+// called before Run.
+func Register[T Args](q *Queue, handle func(ctx context.Context, args T) error) {
+	var zero T
+	q.handlers[zero.Kind()] = func(ctx context.Context, payload json.RawMessage) error {
+		var args T
+		if err := json.Unmarshal(payload, &args); err != nil {
+			return fmt.Errorf("decode job arguments: %w", err)
+		}
+		return handle(ctx, args)
+	}
+}
+
+// Enqueue queues a job with args.
+//
+// Returns:
+// - ErrUnknownKind if no handler is registered for the kind of args
+func (q *Queue) Enqueue(ctx context.Context, args Args, opts EnqueueOptions) (*Job, error) {
+	kind := args.Kind()
+	if _, ok := q.handlers[kind]; !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKind, kind)
+	}
+	payload, err := json.Marshal(args)
+	if err != nil {
+		return nil, err
+	}
+
+	maxAttempts := opts.MaxAttempts
+	if maxAttempts == 0 {
+		maxAttempts = q.cfg.MaxAttempts
+	}
+	runAt := opts.RunAt
+	if runAt.IsZero() {
+		runAt = time.Now()
+	}
+	var uniqueKey *string
+	if opts.UniqueKey != "" {
+		uniqueKey = &opts.UniqueKey
+	}
+
+	var job Job
+	err = pgxscan.Get(ctx, q.db.Conn(ctx), &job, `
+		INSERT INTO job (kind, payload, unique_key, max_attempts, run_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (kind, unique_key) WHERE unique_key IS NOT NULL AND status IN ('pending', 'running')
+		DO NOTHING
+		RETURNING `+jobColumns, kind, json.RawMessage(payload), uniqueKey, maxAttempts, runAt)
+	if errors.Is(err, database.ErrNoRows) && uniqueKey != nil {
+		// the same unique job is already queued
+		err = pgxscan.Get(ctx, q.db.Conn(ctx), &job, `
+			SELECT `+jobColumns+`
+			FROM job
+			WHERE kind = $1 AND unique_key = $2 AND status IN ('pending', 'running')
+		`, kind, *uniqueKey)
+		if err == nil {
+			return &job, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	logger.FromContext(ctx).Debug("Job enqueued", "job_id", job.ID, "kind", kind, "run_at", job.RunAt)
+	return &job, nil
+}
+
+// ListJobs returns the most recently created jobs, newest first.
+func (q *Queue) ListJobs(ctx context.Context, req ListJobsRequest) ([]Job, error) {
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultListLimit
+	}
+
+	jobs := []Job{}
+	err := pgxscan.Select(ctx, q.db.Conn(ctx), &jobs, `
+		SELECT `+jobColumns+`
+		FROM job
+		WHERE ($1 = '' OR status = $1) AND ($2 = '' OR kind = $2)
+		ORDER BY created_at DESC
+		LIMIT $3
+	`, string(req.Status), req.Kind, limit)
+	if err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func (q *Queue) GetJob(ctx context.Context, id uuid.UUID) (*Job, error) {
+	var job Job
+	err := pgxscan.Get(ctx, q.db.Conn(ctx), &job, `SELECT `+jobColumns+` FROM job WHERE id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Retry makes a pending or dead job due now with its attempts reset.
+//
+// Returns:
+// - ErrJobNotRetryable if the job is running or has succeeded, or a job with its unique key is queued
+func (q *Queue) Retry(ctx context.Context, id uuid.UUID) (*Job, error) {
+	current, err := q.GetJob(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if current.Status != StatusPending && current.Status != StatusDead {
+		return nil, ErrJobNotRetryable
+	}
+
+	var job Job
+	err = pgxscan.Get(ctx, q.db.Conn(ctx), &job, `
+		UPDATE job
+		SET status = $2, attempts = 0, run_at = NOW(), locked_until = NULL, finished_at = NULL, updated_at = NOW()
+		WHERE id = $1 AND status IN ($2, $3)
+		RETURNING `+jobColumns, id, StatusPending, StatusDead)
+	switch {
+	case errors.Is(err, database.ErrNoRows), database.IsUniqueViolation(err):
+		// claimed by a worker since it was read, or another job with its unique key is queued
+		return nil, ErrJobNotRetryable
+	case err != nil:
+		return nil, err
+	}
+
+	logger.FromContext(ctx).Info("Job retried", "job_id", id, "kind", job.Kind)
+	return &job, nil
+}
+
+// Prune deletes jobs that finished longer ago than the retention period.
+func (q *Queue) Prune(ctx context.Context) error {
+	tag, err := q.db.Conn(ctx).Exec(ctx, `
+		DELETE FROM job WHERE finished_at < $1
+	`, time.Now().Add(-q.cfg.Retention))
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() > 0 {
+		logger.FromContext(ctx).Info("Finished jobs pruned", "deleted", tag.RowsAffected())
+	}
+	return nil
+}
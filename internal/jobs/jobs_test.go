@@ -0,0 +1,197 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"monolith/internal/config"
+	"monolith/internal/database"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sendEmailArgs struct {
+	To string `json:"to"`
+}
+
+func (sendEmailArgs) Kind() string { return "send_email" }
+
+type unregisteredArgs struct{}
+
+func (unregisteredArgs) Kind() string { return "unregistered" }
+
+var testConfig = config.JobsConfig{
+	Workers:         1,
+	PollInterval:    time.Millisecond,
+	Timeout:         time.Second,
+	MaxAttempts:     3,
+	ShutdownTimeout: time.Second,
+	Retention:       time.Hour,
+}
+
+var jobColumnNames = []string{
+	"id", "kind", "payload", "status", "unique_key", "attempts", "max_attempts", "run_at", "locked_until",
+	"last_error", "created_at", "updated_at", "finished_at",
+}
+
+func jobRow(id uuid.UUID, status Status, attempts, maxAttempts int, payload string) *pgxmock.Rows {
+	now := time.Now()
+	return pgxmock.NewRows(jobColumnNames).AddRow(
+		id, "send_email", json.RawMessage(payload), status, (*string)(nil), attempts, maxAttempts, now,
+		(*time.Time)(nil), (*string)(nil), now, now, (*time.Time)(nil),
+	)
+}
+
+func newTestQueue(mock pgxmock.PgxPoolIface, handle func(ctx context.Context, args sendEmailArgs) error) *Queue {
+	q := NewQueue(&database.DB{Pool: mock}, testConfig)
+	Register(q, handle)
+	return q
+}
+
+func TestQueue_Enqueue(t *testing.T) {
+	jobID := uuid.New()
+	payload := json.RawMessage(`{"to":"user@example.com"}`)
+
+	tests := []struct {
+		name      string
+		args      Args
+		opts      EnqueueOptions
+		setupMock func(mock pgxmock.PgxPoolIface)
+		wantErr   error
+	}{
+		{
+			name: "queues the job with the configured attempts",
+			args: sendEmailArgs{To: "user@example.com"},
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery(`INSERT INTO job .+ ON CONFLICT \(kind, unique_key\)`).
+					WithArgs("send_email", payload, (*string)(nil), 3, pgxmock.AnyArg()).
+					WillReturnRows(jobRow(jobID, StatusPending, 0, 3, string(payload)))
+			},
+		},
+		{
+			name: "returns the queued job when a unique job exists",
+			args: sendEmailArgs{To: "user@example.com"},
+			opts: EnqueueOptions{UniqueKey: "welcome", MaxAttempts: 5},
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery(`INSERT INTO job`).
+					WithArgs("send_email", payload, new("welcome"), 5, pgxmock.AnyArg()).
+					WillReturnError(database.ErrNoRows)
+				mock.ExpectQuery(`SELECT .+ FROM job WHERE kind = \$1 AND unique_key = \$2`).
+					WithArgs("send_email", "welcome").
+					WillReturnRows(jobRow(jobID, StatusRunning, 1, 5, string(payload)))
+			},
+		},
+		{
+			name:      "unknown kind",
+			args:      unregisteredArgs{},
+			setupMock: func(mock pgxmock.PgxPoolIface) {},
+			wantErr:   ErrUnknownKind,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mock.Close()
+			tt.setupMock(mock)
+
+			q := newTestQueue(mock, func(context.Context, sendEmailArgs) error { return nil })
+			job, err := q.Enqueue(context.Background(), tt.args, tt.opts)
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, jobID, job.ID)
+			}
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestQueue_Retry(t *testing.T) {
+	jobID := uuid.New()
+	expectJob := func(mock pgxmock.PgxPoolIface, status Status) {
+		mock.ExpectQuery(`SELECT .+ FROM job WHERE id = \$1`).
+			WithArgs(jobID).
+			WillReturnRows(jobRow(jobID, status, 3, 3, `{}`))
+	}
+
+	tests := []struct {
+		name      string
+		setupMock func(mock pgxmock.PgxPoolIface)
+		wantErr   error
+	}{
+		{
+			name: "dead job is queued again",
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				expectJob(mock, StatusDead)
+				mock.ExpectQuery(`UPDATE job SET status = \$2, attempts = 0, run_at = NOW\(\)`).
+					WithArgs(jobID, StatusPending, StatusDead).
+					WillReturnRows(jobRow(jobID, StatusPending, 0, 3, `{}`))
+			},
+		},
+		{
+			name: "running job can't be retried",
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				expectJob(mock, StatusRunning)
+			},
+			wantErr: ErrJobNotRetryable,
+		},
+		{
+			name: "job claimed since it was read",
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				expectJob(mock, StatusPending)
+				mock.ExpectQuery(`UPDATE job`).
+					WithArgs(jobID, StatusPending, StatusDead).
+					WillReturnError(database.ErrNoRows)
+			},
+			wantErr: ErrJobNotRetryable,
+		},
+		{
+			name: "unique job already queued",
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				expectJob(mock, StatusDead)
+				mock.ExpectQuery(`UPDATE job`).
+					WithArgs(jobID, StatusPending, StatusDead).
+					WillReturnError(&pgconn.PgError{Code: "23505"})
+			},
+			wantErr: ErrJobNotRetryable,
+		},
+		{
+			name: "not found",
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery(`SELECT .+ FROM job WHERE id = \$1`).
+					WithArgs(jobID).
+					WillReturnError(database.ErrNoRows)
+			},
+			wantErr: database.ErrNoRows,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mock.Close()
+			tt.setupMock(mock)
+
+			q := newTestQueue(mock, func(context.Context, sendEmailArgs) error { return nil })
+			_, err = q.Retry(context.Background(), jobID)
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package jobs
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Args are the arguments of a job. Their JSON encoding is the stored payload and Kind picks the handler,
+// so Kind must not depend on the field values.
+type Args interface {
+	Kind() string
+}
+
+// Status is the state of a job. Pending jobs run once run_at has passed; failed attempts go back to
+// pending until the job runs out of attempts and is dead-lettered.
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusDead      Status = "dead"
+)
+
+type Job struct {
+	ID          uuid.UUID       `json:"id"`
+	Kind        string          `json:"kind"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      Status          `json:"status"`
+	UniqueKey   *string         `json:"uniqueKey"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"maxAttempts"`
+	RunAt       time.Time       `json:"runAt"`
+	LockedUntil *time.Time      `json:"lockedUntil"`
+	LastError   *string         `json:"lastError"`
+	CreatedAt   time.Time       `json:"createdAt"`
+	UpdatedAt   time.Time       `json:"updatedAt"`
+	FinishedAt  *time.Time      `json:"finishedAt"`
+}
+
+type EnqueueOptions struct {
+	// RunAt delays the job until then; zero runs it as soon as a worker is free.
+	RunAt time.Time
+	// MaxAttempts overrides the configured number of attempts before the job is dead-lettered.
+	MaxAttempts int
+	// UniqueKey keeps the job from being queued again while a job of the same kind and key is pending or
+	// running; enqueueing it returns the queued job instead.
+	UniqueKey string
+}
+
+type ListJobsRequest struct {
+	Status Status `query:"status" validate:"omitempty,oneof=pending running succeeded dead"`
+	Kind   string `query:"kind"`
+	Limit  int    `query:"limit"  validate:"omitempty,min=1,max=500"`
+}
@@ -0,0 +1,166 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"monolith/internal/database"
+	"monolith/internal/logger"
+	"monolith/internal/retry"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+)
+
+// pruneInterval is how often finished jobs past the retention period are deleted.
+const pruneInterval = time.Hour
+
+// retryBackoff spaces the attempts of a failing job.
+var retryBackoff = retry.Backoff{Base: 15 * time.Second, Max: time.Hour}
+
+// Run works the queue with the configured number of workers until ctx is canceled. Jobs running at that
+// point are given the shutdown timeout to finish before their context is canceled too; Run returns once
+// every worker has stopped. Finished jobs are pruned even when this instance has no kinds to work.
+func (q *Queue) Run(ctx context.Context) {
+	kinds := slices.Sorted(maps.Keys(q.handlers))
+	logger.FromContext(ctx).Info("Job workers started", "workers", q.cfg.Workers, "kinds", kinds)
+
+	var wg sync.WaitGroup
+	if len(kinds) > 0 {
+		for range q.cfg.Workers {
+			wg.Go(func() {
+				q.work(ctx, kinds)
+			})
+		}
+	}
+	wg.Go(func() {
+		q.prune(ctx)
+	})
+	wg.Wait()
+
+	logger.FromContext(ctx).Info("Job workers stopped")
+}
+
+func (q *Queue) work(ctx context.Context, kinds []string) {
+	for {
+		ran, err := q.runNext(ctx, kinds)
+		if err != nil && ctx.Err() == nil {
+			logger.FromContext(ctx).Warn("Failed to run job", "error", err)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if ran && err == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(q.cfg.PollInterval):
+		}
+	}
+}
+
+func (q *Queue) prune(ctx context.Context) {
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := q.Prune(ctx); err != nil && ctx.Err() == nil {
+				logger.FromContext(ctx).Warn("Failed to prune jobs", "error", err)
+			}
+		}
+	}
+}
+
+// runNext claims the next due job of one of kinds and runs it, reporting whether there was one. Claimed
+// jobs are leased for twice the job timeout, so another worker only picks them up if this one dies.
+func (q *Queue) runNext(ctx context.Context, kinds []string) (bool, error) {
+	var job Job
+	err := pgxscan.Get(ctx, q.db.Pool, &job, `
+		UPDATE job
+		SET status = $2, attempts = attempts + 1, locked_until = $3, updated_at = NOW()
+		WHERE id = (
+			SELECT id
+			FROM job
+			WHERE kind = ANY($1)
+			  AND ((status = $4 AND run_at <= NOW()) OR (status = $2 AND locked_until < NOW()))
+			ORDER BY run_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+jobColumns, kinds, StatusRunning, time.Now().Add(2*q.cfg.Timeout), StatusPending)
+	if err != nil {
+		if errors.Is(err, database.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	started := time.Now()
+	runErr := q.run(ctx, &job)
+	return true, q.finish(context.WithoutCancel(ctx), &job, runErr, time.Since(started))
+}
+
+// finish records the outcome of an attempt at job: success, a retry after the backoff, or dead-lettering
+// once the job is out of attempts. Its ctx isn't canceled on shutdown, so a finished attempt isn't left
+// running until its lease expires.
+func (q *Queue) finish(ctx context.Context, job *Job, runErr error, duration time.Duration) error {
+	log := logger.FromContext(ctx).With("job_id", job.ID, "kind", job.Kind, "attempt", job.Attempts)
+
+	if runErr == nil {
+		log.Info("Job succeeded", "duration", duration)
+		_, err := q.db.Pool.Exec(ctx, `
+			UPDATE job
+			SET status = $2, locked_until = NULL, last_error = NULL, finished_at = NOW(), updated_at = NOW()
+			WHERE id = $1
+		`, job.ID, StatusSucceeded)
+		return err
+	}
+
+	if job.Attempts >= job.MaxAttempts {
+		log.Error("Job failed, dead-lettering", "duration", duration, "error", runErr)
+		_, err := q.db.Pool.Exec(ctx, `
+			UPDATE job
+			SET status = $2, locked_until = NULL, last_error = $3, finished_at = NOW(), updated_at = NOW()
+			WHERE id = $1
+		`, job.ID, StatusDead, retry.ErrorText(runErr))
+		return err
+	}
+
+	runAt := time.Now().Add(retryBackoff.Delay(job.Attempts))
+	log.Warn("Job failed, will retry", "duration", duration, "retry_at", runAt, "error", runErr)
+	_, err := q.db.Pool.Exec(ctx, `
+		UPDATE job
+		SET status = $2, run_at = $3, locked_until = NULL, last_error = $4, updated_at = NOW()
+		WHERE id = $1
+	`, job.ID, StatusPending, runAt, retry.ErrorText(runErr))
+	return err
+}
+
+// run calls the job's handler within the job timeout. When ctx is canceled the handler gets the shutdown
+// timeout to finish before its own context is canceled. A panicking handler fails the job.
+func (q *Queue) run(ctx context.Context, job *Job) (err error) {
+	jobCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), q.cfg.Timeout)
+	defer cancel()
+	stop := context.AfterFunc(ctx, func() {
+		time.AfterFunc(q.cfg.ShutdownTimeout, cancel)
+	})
+	defer stop()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return q.handlers[job.Kind](jobCtx, job.Payload)
+}
@@ -0,0 +1,139 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"monolith/internal/database"
+
+	"github.com/google/uuid"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueue_runNext(t *testing.T) {
+	jobID := uuid.New()
+	payload := `{"to":"user@example.com"}`
+	expectClaim := func(mock pgxmock.PgxPoolIface, attempts int) {
+		mock.ExpectQuery(`UPDATE job SET status = \$2, attempts = attempts \+ 1, .+ FOR UPDATE SKIP LOCKED`).
+			WithArgs([]string{"send_email"}, StatusRunning, pgxmock.AnyArg(), StatusPending).
+			WillReturnRows(jobRow(jobID, StatusRunning, attempts, 3, payload))
+	}
+
+	tests := []struct {
+		name      string
+		handle    func(ctx context.Context, args sendEmailArgs) error
+		setupMock func(mock pgxmock.PgxPoolIface)
+		wantRan   bool
+	}{
+		{
+			name: "nothing due",
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery(`UPDATE job`).
+					WithArgs([]string{"send_email"}, StatusRunning, pgxmock.AnyArg(), StatusPending).
+					WillReturnError(database.ErrNoRows)
+			},
+		},
+		{
+			name: "success",
+			handle: func(_ context.Context, args sendEmailArgs) error {
+				if args.To != "user@example.com" {
+					return errors.New("unexpected arguments")
+				}
+				return nil
+			},
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				expectClaim(mock, 1)
+				mock.ExpectExec(`UPDATE job SET status = \$2, locked_until = NULL, last_error = NULL`).
+					WithArgs(jobID, StatusSucceeded).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+			wantRan: true,
+		},
+		{
+			name: "failure is retried with backoff",
+			handle: func(context.Context, sendEmailArgs) error {
+				return errors.New("smtp unavailable")
+			},
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				expectClaim(mock, 1)
+				mock.ExpectExec(`UPDATE job SET status = \$2, run_at = \$3`).
+					WithArgs(jobID, StatusPending, pgxmock.AnyArg(), "smtp unavailable").
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+			wantRan: true,
+		},
+		{
+			name: "last attempt dead-letters the job",
+			handle: func(context.Context, sendEmailArgs) error {
+				return errors.New("smtp unavailable")
+			},
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				expectClaim(mock, 3)
+				mock.ExpectExec(`UPDATE job SET status = \$2, locked_until = NULL, last_error = \$3`).
+					WithArgs(jobID, StatusDead, "smtp unavailable").
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+			wantRan: true,
+		},
+		{
+			name: "panic fails the job",
+			handle: func(context.Context, sendEmailArgs) error {
+				panic("nil template")
+			},
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				expectClaim(mock, 1)
+				mock.ExpectExec(`UPDATE job SET status = \$2, run_at = \$3`).
+					WithArgs(jobID, StatusPending, pgxmock.AnyArg(), "job panicked: nil template").
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+			wantRan: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mock.Close()
+			tt.setupMock(mock)
+
+			q := newTestQueue(mock, tt.handle)
+			ran, err := q.runNext(context.Background(), []string{"send_email"})
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantRan, ran)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestQueue_runDrainsOnShutdown(t *testing.T) {
+	t.Run("job finishes within the shutdown timeout", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		q := newTestQueue(nil, func(jobCtx context.Context, _ sendEmailArgs) error {
+			cancel()
+			time.Sleep(10 * time.Millisecond)
+			return jobCtx.Err()
+		})
+
+		err := q.run(ctx, &Job{Kind: "send_email", Payload: []byte(`{}`)})
+		require.NoError(t, err)
+	})
+
+	t.Run("job is canceled after the shutdown timeout", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		q := newTestQueue(nil, func(jobCtx context.Context, _ sendEmailArgs) error {
+			cancel()
+			<-jobCtx.Done()
+			return jobCtx.Err()
+		})
+		q.cfg.ShutdownTimeout = time.Millisecond
+
+		err := q.run(ctx, &Job{Kind: "send_email", Payload: []byte(`{}`)})
+		require.ErrorIs(t, err, context.Canceled)
+	})
+}
@@ -0,0 +1,36 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromContext(t *testing.T) {
+	assert.Same(t, slog.Default(), FromContext(context.Background()))
+
+	var buf bytes.Buffer
+	base := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	ctx := WithContext(context.Background(), base)
+	ctx = With(ctx, "request_id", "req-1")
+	ctx = With(ctx, "account_id", "acc-1")
+
+	FromContext(ctx).Info("hello")
+
+	assert.Contains(t, buf.String(), `"request_id":"req-1"`)
+	assert.Contains(t, buf.String(), `"account_id":"acc-1"`)
+}
+
+func TestTraceHandler_SkipsBoundTraceID(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(traceHandler{Handler: slog.NewJSONHandler(&buf, nil)}).With("trace_id", "bound")
+
+	log.InfoContext(sampledContext(t), "hello")
+
+	assert.Equal(t, 1, bytes.Count(buf.Bytes(), []byte(`"trace_id"`)))
+	assert.Contains(t, buf.String(), `"trace_id":"bound"`)
+}
@@ -12,41 +12,33 @@ import (
 )
 
 type Config struct {
-	Level slog.Level
+	Level  slog.Level
+	Redact RedactConfig
 }
 
 func New(cfg Config) *slog.Logger {
-	var handler slog.Handler
-
-	if monolith.IsDevEnv() {
-		handler = tint.NewHandler(os.Stdout, &tint.Options{
-			Level:      cfg.Level,
-			TimeFormat: "15:04:05",
-		})
-	} else {
-		handler = slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-			Level: cfg.Level,
-		})
-	}
-
-	return slog.New(handler)
+	return NewWithWriter(cfg, os.Stdout)
 }
 
 func NewWithWriter(cfg Config, w io.Writer) *slog.Logger {
 	var handler slog.Handler
 
+	replaceAttr := NewRedactor(cfg.Redact)
+
 	if monolith.IsDevEnv() {
 		handler = tint.NewHandler(w, &tint.Options{
-			Level:      cfg.Level,
-			TimeFormat: "15:04:05",
+			Level:       cfg.Level,
+			TimeFormat:  "15:04:05",
+			ReplaceAttr: replaceAttr,
 		})
 	} else {
 		handler = slog.NewJSONHandler(w, &slog.HandlerOptions{
-			Level: cfg.Level,
+			Level:       cfg.Level,
+			ReplaceAttr: replaceAttr,
 		})
 	}
 
-	return slog.New(handler)
+	return slog.New(traceHandler{Handler: handler})
 }
 
 func GetLevel(level string) slog.Level {
@@ -0,0 +1,78 @@
+package logger
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+const redactedValue = "[REDACTED]"
+
+// DefaultRedactKeys are attribute key fragments whose values are always masked.
+var DefaultRedactKeys = []string{
+	"password",
+	"token",
+	"secret",
+	"cookie",
+	"authorization",
+	"api_key",
+	"apikey",
+}
+
+var emailPattern = regexp.MustCompile(`([A-Za-z0-9._%+\-])[A-Za-z0-9._%+\-]*@([A-Za-z0-9.\-]+\.[A-Za-z]{2,})`)
+
+// RedactConfig controls which attributes are masked before a record is written.
+type RedactConfig struct {
+	// Keys are matched case-insensitively as substrings of attribute keys, e.g. "token" masks "session_token".
+	Keys []string
+	// MaskEmails replaces the local part of email addresses found in string and error values.
+	MaskEmails bool
+}
+
+// NewRedactor returns a slog ReplaceAttr function applying cfg.
+func NewRedactor(cfg RedactConfig) func(groups []string, a slog.Attr) slog.Attr {
+	keys := make([]string, 0, len(cfg.Keys))
+	for _, key := range cfg.Keys {
+		if key = strings.TrimSpace(strings.ToLower(key)); key != "" {
+			keys = append(keys, key)
+		}
+	}
+
+	return func(_ []string, a slog.Attr) slog.Attr {
+		if a.Value.Kind() == slog.KindGroup {
+			return a
+		}
+
+		lowerKey := strings.ToLower(a.Key)
+		for _, key := range keys {
+			if strings.Contains(lowerKey, key) {
+				return slog.String(a.Key, redactedValue)
+			}
+		}
+
+		if !cfg.MaskEmails {
+			return a
+		}
+
+		switch a.Value.Kind() {
+		case slog.KindString:
+			if value := a.Value.String(); strings.Contains(value, "@") {
+				return slog.String(a.Key, MaskEmails(value))
+			}
+		case slog.KindAny:
+			if err, ok := a.Value.Any().(error); ok {
+				if msg := err.Error(); strings.Contains(msg, "@") {
+					return slog.String(a.Key, MaskEmails(msg))
+				}
+			}
+		default:
+		}
+
+		return a
+	}
+}
+
+// MaskEmails replaces every email address in s with its first character and domain, e.g. j***@example.com.
+func MaskEmails(s string) string {
+	return emailPattern.ReplaceAllString(s, "$1***@$2")
+}
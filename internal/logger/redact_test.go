@@ -0,0 +1,92 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRedactor(t *testing.T) {
+	tests := []struct {
+		name       string
+		cfg        RedactConfig
+		attr       slog.Attr
+		wantString string
+	}{
+		{
+			name:       "password key",
+			cfg:        RedactConfig{Keys: DefaultRedactKeys},
+			attr:       slog.String("password", "hunter22"),
+			wantString: redactedValue,
+		},
+		{
+			name:       "key fragment is case insensitive",
+			cfg:        RedactConfig{Keys: DefaultRedactKeys},
+			attr:       slog.String("Session_Token", "abc123"),
+			wantString: redactedValue,
+		},
+		{
+			name:       "cookie key with non string value",
+			cfg:        RedactConfig{Keys: DefaultRedactKeys},
+			attr:       slog.Any("cookie", []string{"a", "b"}),
+			wantString: redactedValue,
+		},
+		{
+			name:       "custom key",
+			cfg:        RedactConfig{Keys: []string{" SSN "}},
+			attr:       slog.String("user_ssn", "123-45-6789"),
+			wantString: redactedValue,
+		},
+		{
+			name:       "unrelated key untouched",
+			cfg:        RedactConfig{Keys: DefaultRedactKeys},
+			attr:       slog.String("method", "GET"),
+			wantString: "GET",
+		},
+		{
+			name:       "email masked",
+			cfg:        RedactConfig{MaskEmails: true},
+			attr:       slog.String("email", "john.doe@example.com"),
+			wantString: "j***@example.com",
+		},
+		{
+			name:       "email inside error masked",
+			cfg:        RedactConfig{MaskEmails: true},
+			attr:       slog.Any("error", errors.New(`Key (email)=(jane@example.org) already exists`)),
+			wantString: `Key (email)=(j***@example.org) already exists`,
+		},
+		{
+			name:       "email kept when masking disabled",
+			cfg:        RedactConfig{},
+			attr:       slog.String("email", "john@example.com"),
+			wantString: "john@example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			replace := NewRedactor(tt.cfg)
+
+			got := replace(nil, tt.attr)
+
+			assert.Equal(t, tt.attr.Key, got.Key)
+			assert.Equal(t, tt.wantString, got.Value.String())
+		})
+	}
+}
+
+func TestNewRedactor_Handler(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: NewRedactor(RedactConfig{Keys: DefaultRedactKeys, MaskEmails: true}),
+	}))
+
+	log.Info("login for admin@example.com", slog.Group("req", slog.String("authorization", "Bearer abc")))
+
+	assert.Contains(t, buf.String(), `"msg":"login for a***@example.com"`)
+	assert.Contains(t, buf.String(), `"authorization":"[REDACTED]"`)
+	assert.NotContains(t, buf.String(), "Bearer abc")
+}
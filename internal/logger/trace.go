@@ -0,0 +1,45 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+
+	"monolith/internal/tracing"
+)
+
+const (
+	traceIDKey = "trace_id"
+	spanIDKey  = "span_id"
+)
+
+// traceHandler adds the trace and span IDs of the active span to records logged with a context,
+// so log lines can be correlated with traces.
+type traceHandler struct {
+	slog.Handler
+	// hasTraceID is set once a trace ID was bound via With, e.g. by the request-scoped logger,
+	// so records don't carry the attribute twice.
+	hasTraceID bool
+}
+
+func (h traceHandler) Handle(ctx context.Context, r slog.Record) error {
+	if !h.hasTraceID {
+		if traceID, spanID := tracing.IDs(ctx); traceID != "" {
+			r.AddAttrs(slog.String(traceIDKey, traceID), slog.String(spanIDKey, spanID))
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h traceHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	hasTraceID := h.hasTraceID
+	for _, attr := range attrs {
+		if attr.Key == traceIDKey {
+			hasTraceID = true
+		}
+	}
+	return traceHandler{Handler: h.Handler.WithAttrs(attrs), hasTraceID: hasTraceID}
+}
+
+func (h traceHandler) WithGroup(name string) slog.Handler {
+	return traceHandler{Handler: h.Handler.WithGroup(name), hasTraceID: h.hasTraceID}
+}
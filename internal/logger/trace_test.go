@@ -0,0 +1,42 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func sampledContext(t *testing.T) context.Context {
+	t.Helper()
+
+	traceID, err := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	require.NoError(t, err)
+	spanID, err := trace.SpanIDFromHex("00f067aa0ba902b7")
+	require.NoError(t, err)
+
+	return trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+}
+
+func TestTraceHandler(t *testing.T) {
+	ctx := sampledContext(t)
+
+	var buf bytes.Buffer
+	log := slog.New(traceHandler{Handler: slog.NewJSONHandler(&buf, nil)})
+
+	log.InfoContext(ctx, "with span")
+	assert.Contains(t, buf.String(), `"trace_id":"4bf92f3577b34da6a3ce929d0e0e4736"`)
+	assert.Contains(t, buf.String(), `"span_id":"00f067aa0ba902b7"`)
+
+	buf.Reset()
+	log.InfoContext(context.Background(), "without span")
+	assert.NotContains(t, buf.String(), "trace_id")
+}
@@ -4,36 +4,52 @@ import (
 	"context"
 
 	"monolith/internal/account"
-	"monolith/internal/database"
+	"monolith/internal/metrics"
+	"monolith/internal/tracing"
+
+	"github.com/google/uuid"
 )
 
+// AccountService is the part of account.Service that logging in depends on.
+type AccountService interface {
+	GetAccountByLogin(ctx context.Context, login string) (*account.Account, error)
+	ValidatePassword(hashedPassword, password string) error
+	UpdateLastSeen(ctx context.Context, id uuid.UUID) error
+}
+
 type Service struct {
-	db             *database.DB
-	accountService *account.Service
+	accountService AccountService
 }
 
-func NewService(db *database.DB, accountService *account.Service) *Service {
+func NewService(accountService AccountService) *Service {
 	return &Service{
-		db:             db,
 		accountService: accountService,
 	}
 }
 
-func (s *Service) Login(ctx context.Context, req UserLoginRequest) (*account.Account, error) {
+func (s *Service) Login(ctx context.Context, req UserLoginRequest) (_ *account.Account, err error) {
+	ctx, span := tracing.Start(ctx, "login.Service.Login")
+	defer func() { tracing.End(span, err) }()
+
 	account, err := s.accountService.GetAccountByLogin(ctx, req.Login)
 	if err != nil {
+		metrics.ObserveLogin(metrics.LoginResultFailure)
 		return nil, ErrInvalidCredentials
 	}
 
 	err = s.accountService.ValidatePassword(account.Password, req.Password)
 	if err != nil {
+		metrics.ObserveLogin(metrics.LoginResultFailure)
 		return nil, ErrInvalidCredentials
 	}
 
 	err = s.accountService.UpdateLastSeen(ctx, account.ID)
 	if err != nil {
+		metrics.ObserveLogin(metrics.LoginResultError)
 		return nil, err
 	}
 
+	metrics.ObserveLogin(metrics.LoginResultSuccess)
+
 	return account, nil
 }
@@ -146,8 +146,7 @@ func TestService_Login(t *testing.T) {
 			tt.setupMock(mock)
 
 			db := &database.DB{Pool: mock}
-			accountSvc := account.NewService(db)
-			s := NewService(db, accountSvc)
+			s := NewService(account.NewService(db, account.NewPostgresRepository(db), nil))
 
 			acc, err := s.Login(context.Background(), tt.req)
 			if tt.wantErr != nil {
@@ -166,3 +165,24 @@ func TestService_Login(t *testing.T) {
 		})
 	}
 }
+
+func TestService_LoginWithMemoryRepository(t *testing.T) {
+	ctx := context.Background()
+	accounts := account.NewService(nil, account.NewMemoryRepository(), nil)
+	created, err := accounts.CreateAccount(ctx, account.CreateAccountRequest{
+		Username: "jane", Name: "Jane", Email: "jane@example.com", Password: new("password123"),
+	})
+	require.NoError(t, err)
+	s := NewService(accounts)
+
+	acc, err := s.Login(ctx, UserLoginRequest{Login: "jane@example.com", Password: "password123"})
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, acc.ID)
+
+	_, err = s.Login(ctx, UserLoginRequest{Login: "jane", Password: "wrong-password"})
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+
+	require.NoError(t, accounts.DisableAccount(ctx, created.ID))
+	_, err = s.Login(ctx, UserLoginRequest{Login: "jane", Password: "password123"})
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+}
@@ -0,0 +1,225 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"monolith"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "monolith"
+
+// Login attempt results
+const (
+	LoginResultSuccess = "success"
+	LoginResultFailure = "failure"
+	LoginResultError   = "error"
+)
+
+// Session lifecycle events
+const (
+	SessionEventCreated = "created"
+	SessionEventRotated = "rotated"
+	SessionEventRevoked = "revoked"
+)
+
+// Auth context cache lookup results
+const (
+	AuthCacheHit  = "hit"
+	AuthCacheMiss = "miss"
+)
+
+const (
+	// activeSessionsInterval is how often the active session count is refreshed.
+	activeSessionsInterval = time.Minute
+	// activeSessionsTimeout bounds the query counting active sessions.
+	activeSessionsTimeout = 5 * time.Second
+)
+
+// Registry holds every collector exposed on the metrics endpoint. A dedicated registry is used
+// instead of the global default so tests and multiple servers don't collide on registration.
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of HTTP requests by method, route template and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	httpRequestsInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_in_flight",
+		Help:      "Number of HTTP requests currently being served.",
+	})
+
+	httpDeprecatedRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "deprecated_requests_total",
+		Help:      "Number of requests served by deprecated routes by method and route template.",
+	}, []string{"method", "route"})
+
+	loginAttempts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "auth",
+		Name:      "login_attempts_total",
+		Help:      "Number of login attempts by result.",
+	}, []string{"result"})
+
+	sessionEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "auth",
+		Name:      "session_events_total",
+		Help:      "Number of session lifecycle events by type.",
+	}, []string{"event"})
+
+	authCacheLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "auth",
+		Name:      "context_cache_lookups_total",
+		Help:      "Number of auth context cache lookups by result.",
+	}, []string{"result"})
+
+	authCacheInvalidations = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "auth",
+		Name:      "context_cache_invalidations_total",
+		Help:      "Number of times cached auth contexts were invalidated after a session or account change.",
+	})
+
+	buildInfo = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "build_info",
+		Help:      "Build information of the running binary. Always 1.",
+	}, []string{"version", "commit", "build_time"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequestDuration,
+		httpRequestsInFlight,
+		httpDeprecatedRequests,
+		loginAttempts,
+		sessionEvents,
+		authCacheLookups,
+		authCacheInvalidations,
+		buildInfo,
+	)
+
+	info := monolith.GetVersionInfo()
+	buildInfo.WithLabelValues(info.Version, info.Commit, info.BuildTime).Set(1)
+
+	for _, result := range []string{LoginResultSuccess, LoginResultFailure, LoginResultError} {
+		loginAttempts.WithLabelValues(result)
+	}
+	for _, event := range []string{SessionEventCreated, SessionEventRotated, SessionEventRevoked} {
+		sessionEvents.WithLabelValues(event)
+	}
+	for _, result := range []string{AuthCacheHit, AuthCacheMiss} {
+		authCacheLookups.WithLabelValues(result)
+	}
+}
+
+// Handler returns the HTTP handler serving the metrics in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// ObserveHTTPRequest records the duration of a served HTTP request.
+func ObserveHTTPRequest(method, route string, status int, duration time.Duration) {
+	httpRequestDuration.WithLabelValues(method, route, strconv.Itoa(status)).Observe(duration.Seconds())
+}
+
+// HTTPRequestStarted increments the in-flight gauge. The returned function must be called once the request is done.
+func HTTPRequestStarted() func() {
+	httpRequestsInFlight.Inc()
+	return httpRequestsInFlight.Dec
+}
+
+// ObserveDeprecatedRequest records a request served by a deprecated route.
+func ObserveDeprecatedRequest(method, route string) {
+	httpDeprecatedRequests.WithLabelValues(method, route).Inc()
+}
+
+// ObserveLogin records a login attempt with one of the LoginResult* values.
+func ObserveLogin(result string) {
+	loginAttempts.WithLabelValues(result).Inc()
+}
+
+// ObserveSessionEvent records count session lifecycle events of the given SessionEvent* type.
+func ObserveSessionEvent(event string, count int64) {
+	if count <= 0 {
+		return
+	}
+	sessionEvents.WithLabelValues(event).Add(float64(count))
+}
+
+// ObserveAuthCacheLookup records an auth context cache lookup with one of the AuthCache* results.
+func ObserveAuthCacheLookup(result string) {
+	authCacheLookups.WithLabelValues(result).Inc()
+}
+
+// ObserveAuthCacheInvalidation records an invalidation of cached auth contexts.
+func ObserveAuthCacheInvalidation() {
+	authCacheInvalidations.Inc()
+}
+
+// RegisterActiveSessions exposes the number of active sessions, computed by countFn now and then every
+// activeSessionsInterval until ctx is canceled. Scrapes read the last count rather than querying, so
+// frequent scrapes of many instances don't add load on the session table. A failed count keeps the last
+// one.
+func RegisterActiveSessions(ctx context.Context, countFn func(ctx context.Context) (int64, error)) {
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "auth",
+		Name:      "active_sessions",
+		Help:      "Number of sessions that are neither revoked nor expired.",
+	})
+	Registry.MustRegister(gauge)
+
+	refresh := func() {
+		ctx, cancel := context.WithTimeout(ctx, activeSessionsTimeout)
+		defer cancel()
+
+		count, err := countFn(ctx)
+		if err != nil {
+			slog.Warn("Failed to count active sessions", "error", err)
+			return
+		}
+		gauge.Set(float64(count))
+	}
+
+	refresh()
+	go func() {
+		ticker := time.NewTicker(activeSessionsInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				refresh()
+			}
+		}
+	}()
+}
+
+// RegisterDBPool exposes the connection pool statistics of the given pgx pool, labeled with its name.
+func RegisterDBPool(name string, pool *pgxpool.Pool) {
+	Registry.MustRegister(newPoolCollector(name, pool))
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"monolith/internal/logger"
+	"monolith/internal/tracing"
+
+	"github.com/labstack/echo/v5"
+)
+
+// ContextLogger stores a request-scoped logger in the request context, carrying the request ID and
+// trace ID. It must run after the RequestID and Tracing middleware; SessionAuth later adds the account
+// and session IDs. Handlers and services retrieve it with logger.FromContext.
+func ContextLogger() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c *echo.Context) error {
+			req := c.Request()
+
+			args := []any{"request_id", GetRequestID(c)}
+			if traceID, spanID := tracing.IDs(req.Context()); traceID != "" {
+				args = append(args, "trace_id", traceID, "span_id", spanID)
+			}
+
+			c.SetRequest(req.WithContext(logger.With(req.Context(), args...)))
+
+			return next(c)
+		}
+	}
+}
+
+// GetRequestID returns the ID assigned by the RequestID middleware, or the one supplied by the client.
+func GetRequestID(c *echo.Context) string {
+	if id := c.Response().Header().Get(echo.HeaderXRequestID); id != "" {
+		return id
+	}
+	return c.Request().Header.Get(echo.HeaderXRequestID)
+}
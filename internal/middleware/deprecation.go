@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"monolith/internal/logger"
+	"monolith/internal/metrics"
+
+	"github.com/labstack/echo/v5"
+)
+
+// DeprecationConfig describes a deprecated route or group of routes.
+type DeprecationConfig struct {
+	// Since is when the routes were deprecated, sent as the Deprecation header (RFC 9745).
+	Since time.Time
+	// Sunset is when the routes stop working, sent as the Sunset header (RFC 8594). Zero omits it.
+	Sunset time.Time
+	// Successor returns the replacement URL for a request, linked with rel="successor-version".
+	// Nil omits the link.
+	Successor func(r *http.Request) string
+}
+
+// Deprecation announces that the wrapped routes are deprecated and logs every use, so remaining clients
+// can be identified before the routes are removed.
+func Deprecation(cfg DeprecationConfig) echo.MiddlewareFunc {
+	deprecation := "@" + strconv.FormatInt(cfg.Since.Unix(), 10)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c *echo.Context) error {
+			req := c.Request()
+			header := c.Response().Header()
+
+			header.Set("Deprecation", deprecation)
+			if !cfg.Sunset.IsZero() {
+				header.Set("Sunset", cfg.Sunset.UTC().Format(http.TimeFormat))
+			}
+			if cfg.Successor != nil {
+				header.Add("Link", "<"+cfg.Successor(req)+`>; rel="successor-version"`)
+			}
+
+			metrics.ObserveDeprecatedRequest(req.Method, c.Path())
+			logger.FromContext(req.Context()).InfoContext(req.Context(), "Deprecated route used",
+				"method", req.Method,
+				"route", c.Path(),
+				"user_agent", req.UserAgent(),
+			)
+
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"monolith/internal/metrics"
+
+	"github.com/labstack/echo/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeprecation(t *testing.T) {
+	since := time.Date(2026, time.January, 2, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2026, time.July, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		cfg        DeprecationConfig
+		wantSunset string
+		wantLink   string
+	}{
+		{
+			name: "with sunset and successor",
+			cfg: DeprecationConfig{
+				Since:  since,
+				Sunset: sunset,
+				Successor: func(r *http.Request) string {
+					return "/api/v1" + strings.TrimPrefix(r.URL.Path, "/api")
+				},
+			},
+			wantSunset: "Wed, 01 Jul 2026 00:00:00 GMT",
+			wantLink:   `</api/v1/items/42>; rel="successor-version"`,
+		},
+		{
+			name: "deprecation only",
+			cfg:  DeprecationConfig{Since: since},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			e.GET("/api/items/:id", func(c *echo.Context) error {
+				return c.NoContent(http.StatusNoContent)
+			}, Deprecation(tt.cfg))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/items/42", nil)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusNoContent, rec.Code)
+			assert.Equal(t, "@1767312000", rec.Header().Get("Deprecation"))
+			assert.Equal(t, tt.wantSunset, rec.Header().Get("Sunset"))
+			assert.Equal(t, tt.wantLink, rec.Header().Get("Link"))
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(),
+		`monolith_http_deprecated_requests_total{method="GET",route="/api/items/:id"} 2`)
+}
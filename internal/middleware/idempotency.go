@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"monolith/internal/auth"
+	"monolith/internal/idempotency"
+	"monolith/internal/logger"
+
+	"github.com/labstack/echo/v5"
+)
+
+// idempotencyReleaseTimeout bounds releasing or completing a key after the request context is gone.
+const idempotencyReleaseTimeout = 5 * time.Second
+
+// Idempotency makes POST, PATCH and DELETE requests carrying an Idempotency-Key header safe to retry.
+// The first request with a key is processed and its response stored; retries with the same key and payload
+// replay that response, and a retry with a different payload is rejected. Keys are scoped to the
+// authenticated account, so it must run after SessionAuth. Client errors are stored and replayed like
+// successes; 5xx responses and responses that couldn't be sent release the key, so they are processed
+// again on retry.
+func Idempotency(store *idempotency.Store) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c *echo.Context) error {
+			req := c.Request()
+			key := req.Header.Get(idempotency.HeaderKey)
+			if key == "" || !idempotency.AppliesTo(req.Method) {
+				return next(c)
+			}
+			if len(key) > idempotency.MaxKeyLength {
+				return idempotency.ErrInvalidKey
+			}
+
+			user, ok := c.Get("user").(*auth.AuthUser)
+			if !ok {
+				return auth.ErrAuthenticationRequired
+			}
+			scope := user.AccountID.String()
+
+			body, err := io.ReadAll(req.Body)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, "Failed to read request body").Wrap(err)
+			}
+			req.Body = io.NopCloser(bytes.NewReader(body))
+
+			fingerprint := idempotency.Fingerprint(req.Method, req.URL.Path, body)
+			record, err := store.Reserve(req.Context(), scope, key, fingerprint)
+			if err != nil {
+				return err
+			}
+			if record != nil {
+				return replay(c, record)
+			}
+
+			recorder := &bodyRecorder{ResponseWriter: c.Response()}
+			c.SetResponse(recorder)
+			err = next(c)
+			if err != nil {
+				// The error is rendered while recording so client errors are stored like successes. It's
+				// still returned for logging; the error handler skips responses that are already committed.
+				c.Echo().HTTPErrorHandler(c, err)
+			}
+			c.SetResponse(recorder.ResponseWriter)
+
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(req.Context()), idempotencyReleaseTimeout)
+			defer cancel()
+
+			resp, status := echo.ResolveResponseStatus(recorder.ResponseWriter, err)
+			sent := resp != nil && resp.Committed && req.Context().Err() == nil
+			if status >= http.StatusInternalServerError || !sent {
+				if releaseErr := store.Release(ctx, scope, key); releaseErr != nil {
+					logger.FromContext(ctx).Warn("Failed to release idempotency key", "error", releaseErr)
+				}
+				return err
+			}
+
+			contentType := recorder.Header().Get(echo.HeaderContentType)
+			completeErr := store.Complete(ctx, scope, key, status, contentType, recorder.body.Bytes())
+			if completeErr != nil {
+				logger.FromContext(ctx).Warn("Failed to store idempotent response", "error", completeErr)
+			}
+			return err
+		}
+	}
+}
+
+func replay(c *echo.Context, record *idempotency.Record) error {
+	c.Response().Header().Set(idempotency.HeaderReplayed, strconv.FormatBool(true))
+	if record.ContentType == nil || *record.ContentType == "" || len(record.Body) == 0 {
+		return c.NoContent(*record.Status)
+	}
+	return c.Blob(*record.Status, *record.ContentType, record.Body)
+}
+
+// bodyRecorder copies everything written to the response so it can be stored.
+type bodyRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *bodyRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *bodyRecorder) Flush() {
+	_ = http.NewResponseController(r.ResponseWriter).Flush()
+}
+
+func (r *bodyRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,261 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"monolith/internal/auth"
+	"monolith/internal/database"
+	"monolith/internal/idempotency"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotency(t *testing.T) {
+	accountID := uuid.New()
+	body := `{"name":"test"}`
+	status := http.StatusCreated
+	contentType := echo.MIMEApplicationJSON
+	stored := []byte(`{"id":"stored"}`)
+	columns := []string{"fingerprint", "response_status", "response_content_type", "response_body"}
+	fingerprint := idempotency.Fingerprint(http.MethodPost, "/accounts", []byte(body))
+
+	expectReserve := func(mock pgxmock.PgxPoolIface, claimed bool) {
+		query := mock.ExpectQuery(`INSERT INTO idempotency_key`).
+			WithArgs(accountID.String(), "key-1", fingerprint, pgxmock.AnyArg())
+		if claimed {
+			query.WillReturnRows(pgxmock.NewRows([]string{"key"}).AddRow("key-1"))
+		} else {
+			query.WillReturnError(database.ErrNoRows)
+		}
+	}
+
+	tests := []struct {
+		name         string
+		method       string
+		key          string
+		handlerErr   error
+		setupMock    func(mock pgxmock.PgxPoolIface)
+		wantErr      error
+		wantStatus   int
+		wantBody     string
+		wantReplayed bool
+		handlerCalls int
+	}{
+		{
+			name:         "without key",
+			method:       http.MethodPost,
+			setupMock:    func(mock pgxmock.PgxPoolIface) {},
+			wantStatus:   http.StatusCreated,
+			wantBody:     `{"id":"new"}`,
+			handlerCalls: 1,
+		},
+		{
+			name:         "safe method",
+			method:       http.MethodGet,
+			key:          "key-1",
+			setupMock:    func(mock pgxmock.PgxPoolIface) {},
+			wantStatus:   http.StatusCreated,
+			wantBody:     `{"id":"new"}`,
+			handlerCalls: 1,
+		},
+		{
+			name:         "key too long",
+			method:       http.MethodPost,
+			key:          strings.Repeat("k", idempotency.MaxKeyLength+1),
+			setupMock:    func(mock pgxmock.PgxPoolIface) {},
+			wantErr:      idempotency.ErrInvalidKey,
+			handlerCalls: 0,
+		},
+		{
+			name:   "first request stores the response",
+			method: http.MethodPost,
+			key:    "key-1",
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				expectReserve(mock, true)
+				mock.ExpectExec(`UPDATE idempotency_key`).
+					WithArgs(accountID.String(), "key-1", http.StatusCreated, echo.MIMEApplicationJSON,
+						[]byte(`{"id":"new"}`+"\n")).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+			wantStatus:   http.StatusCreated,
+			wantBody:     `{"id":"new"}`,
+			handlerCalls: 1,
+		},
+		{
+			name:   "retry replays the stored response",
+			method: http.MethodPost,
+			key:    "key-1",
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				expectReserve(mock, false)
+				mock.ExpectQuery(`SELECT fingerprint`).
+					WithArgs(accountID.String(), "key-1").
+					WillReturnRows(pgxmock.NewRows(columns).AddRow(fingerprint, &status, &contentType, stored))
+			},
+			wantStatus:   http.StatusCreated,
+			wantBody:     `{"id":"stored"}`,
+			wantReplayed: true,
+			handlerCalls: 0,
+		},
+		{
+			name:   "reused with a different payload",
+			method: http.MethodPost,
+			key:    "key-1",
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				expectReserve(mock, false)
+				mock.ExpectQuery(`SELECT fingerprint`).
+					WithArgs(accountID.String(), "key-1").
+					WillReturnRows(pgxmock.NewRows(columns).AddRow("other", &status, &contentType, stored))
+			},
+			wantErr:      idempotency.ErrKeyReused,
+			handlerCalls: 0,
+		},
+		{
+			name:       "failed request releases the key",
+			method:     http.MethodPost,
+			key:        "key-1",
+			handlerErr: errors.New("boom"),
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				expectReserve(mock, true)
+				mock.ExpectExec(`DELETE FROM idempotency_key`).
+					WithArgs(accountID.String(), "key-1").
+					WillReturnResult(pgxmock.NewResult("DELETE", 1))
+			},
+			handlerCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mock.Close()
+
+			tt.setupMock(mock)
+			store := idempotency.NewStore(&database.DB{Pool: mock}, time.Hour)
+
+			e := echo.New()
+			req := httptest.NewRequest(tt.method, "/accounts", strings.NewReader(body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			if tt.key != "" {
+				req.Header.Set(idempotency.HeaderKey, tt.key)
+			}
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.Set("user", &auth.AuthUser{AccountID: accountID})
+
+			handlerCalled := 0
+			handler := func(c *echo.Context) error {
+				handlerCalled++
+				if tt.handlerErr != nil {
+					return tt.handlerErr
+				}
+				return c.JSON(http.StatusCreated, map[string]string{"id": "new"})
+			}
+
+			err = Idempotency(store)(handler)(c)
+
+			switch {
+			case tt.handlerErr != nil:
+				require.ErrorIs(t, err, tt.handlerErr)
+			case tt.wantErr != nil:
+				require.ErrorIs(t, err, tt.wantErr)
+			default:
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantStatus, rec.Code)
+				assert.JSONEq(t, tt.wantBody, rec.Body.String())
+			}
+			assert.Equal(t, tt.handlerCalls, handlerCalled)
+			if tt.wantReplayed {
+				assert.Equal(t, "true", rec.Header().Get(idempotency.HeaderReplayed))
+			} else {
+				assert.Empty(t, rec.Header().Get(idempotency.HeaderReplayed))
+			}
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestIdempotency_ClientErrorIsReplayed(t *testing.T) {
+	accountID := uuid.New()
+	body := `{"name":"test"}`
+	fingerprint := idempotency.Fingerprint(http.MethodPost, "/accounts", []byte(body))
+	conflict := `{"message":"already exists"}` + "\n"
+
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	// the 409 is stored rather than releasing the key
+	mock.ExpectQuery(`INSERT INTO idempotency_key`).
+		WithArgs(accountID.String(), "key-1", fingerprint, pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows([]string{"key"}).AddRow("key-1"))
+	mock.ExpectExec(`UPDATE idempotency_key`).
+		WithArgs(accountID.String(), "key-1", http.StatusConflict, echo.MIMEApplicationJSON, []byte(conflict)).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	// a retry with the same payload replays it
+	status := http.StatusConflict
+	contentType := echo.MIMEApplicationJSON
+	columns := []string{"fingerprint", "response_status", "response_content_type", "response_body"}
+	mock.ExpectQuery(`INSERT INTO idempotency_key`).
+		WithArgs(accountID.String(), "key-1", fingerprint, pgxmock.AnyArg()).
+		WillReturnError(database.ErrNoRows)
+	mock.ExpectQuery(`SELECT fingerprint`).
+		WithArgs(accountID.String(), "key-1").
+		WillReturnRows(pgxmock.NewRows(columns).AddRow(fingerprint, &status, &contentType, []byte(conflict)))
+
+	// and a retry with a changed payload is rejected
+	changed := `{"name":"other"}`
+	mock.ExpectQuery(`INSERT INTO idempotency_key`).
+		WithArgs(accountID.String(), "key-1", idempotency.Fingerprint(http.MethodPost, "/accounts", []byte(changed)),
+			pgxmock.AnyArg()).
+		WillReturnError(database.ErrNoRows)
+	mock.ExpectQuery(`SELECT fingerprint`).
+		WithArgs(accountID.String(), "key-1").
+		WillReturnRows(pgxmock.NewRows(columns).AddRow(fingerprint, &status, &contentType, []byte(conflict)))
+
+	store := idempotency.NewStore(&database.DB{Pool: mock}, time.Hour)
+	e := echo.New()
+
+	handlerCalled := 0
+	handler := Idempotency(store)(func(c *echo.Context) error {
+		handlerCalled++
+		return echo.NewHTTPError(http.StatusConflict, "already exists")
+	})
+
+	send := func(payload string) (*httptest.ResponseRecorder, error) {
+		req := httptest.NewRequest(http.MethodPost, "/accounts", strings.NewReader(payload))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(idempotency.HeaderKey, "key-1")
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.Set("user", &auth.AuthUser{AccountID: accountID})
+		return rec, handler(c)
+	}
+
+	rec, err := send(body)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.JSONEq(t, conflict, rec.Body.String())
+
+	rec, err = send(body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.JSONEq(t, conflict, rec.Body.String())
+	assert.Equal(t, "true", rec.Header().Get(idempotency.HeaderReplayed))
+
+	_, err = send(changed)
+	require.ErrorIs(t, err, idempotency.ErrKeyReused)
+
+	assert.Equal(t, 1, handlerCalled)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -1,7 +1,7 @@
 package middleware
 
 import (
-	"log/slog"
+	"monolith/internal/logger"
 
 	"github.com/labstack/echo/v5"
 	"github.com/labstack/echo/v5/middleware"
@@ -17,7 +17,7 @@ func Logger() echo.MiddlewareFunc {
 		LogMethod:       true,
 		LogResponseSize: true,
 		HandleError:     true,
-		LogValuesFunc: func(_ *echo.Context, v middleware.RequestLoggerValues) error {
+		LogValuesFunc: func(c *echo.Context, v middleware.RequestLoggerValues) error {
 			fields := []any{
 				"remote_ip", v.RemoteIP,
 				"method", v.Method,
@@ -31,13 +31,16 @@ func Logger() echo.MiddlewareFunc {
 				fields = append(fields, "error", v.Error)
 			}
 
+			ctx := c.Request().Context()
+			log := logger.FromContext(ctx)
+
 			switch {
 			case v.Status >= 500:
-				slog.Error("http request", fields...)
+				log.ErrorContext(ctx, "http request", fields...)
 			case v.Status >= 400:
-				slog.Warn("http request", fields...)
+				log.WarnContext(ctx, "http request", fields...)
 			default:
-				slog.Info("http request", fields...)
+				log.InfoContext(ctx, "http request", fields...)
 			}
 
 			return nil
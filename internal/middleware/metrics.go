@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"time"
+
+	"monolith/internal/metrics"
+
+	"github.com/labstack/echo/v5"
+)
+
+// unmatchedRoute labels requests that did not match any registered route, keeping label cardinality bounded.
+const unmatchedRoute = "unmatched"
+
+// Metrics records request counts and latencies labeled by the route template (e.g. /api/accounts/:id).
+func Metrics() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c *echo.Context) error {
+			done := metrics.HTTPRequestStarted()
+			defer done()
+
+			start := time.Now()
+			err := next(c)
+
+			route := c.Path()
+			if route == "" {
+				route = unmatchedRoute
+			}
+			_, status := echo.ResolveResponseStatus(c.Response(), err)
+
+			metrics.ObserveHTTPRequest(c.Request().Method, route, status, time.Since(start))
+
+			return err
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"monolith/internal/metrics"
+
+	"github.com/labstack/echo/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	e := echo.New()
+	e.Use(Metrics())
+	e.GET("/api/accounts/:id", func(c *echo.Context) error {
+		return c.String(http.StatusOK, "OK")
+	})
+	e.GET("/api/missing/:id", func(c *echo.Context) error {
+		return echo.NewHTTPError(http.StatusNotFound, "Not Found")
+	})
+
+	for _, path := range []string{"/api/accounts/1", "/api/accounts/2", "/api/missing/3"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	body := rec.Body.String()
+	assert.Contains(t, body,
+		`monolith_http_request_duration_seconds_count{method="GET",route="/api/accounts/:id",status="200"} 2`)
+	assert.Contains(t, body,
+		`monolith_http_request_duration_seconds_count{method="GET",route="/api/missing/:id",status="404"} 1`)
+	assert.False(t, strings.Contains(body, `route="/api/accounts/1"`), "raw paths must not be used as labels")
+}
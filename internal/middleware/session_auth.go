@@ -1,26 +1,33 @@
 package middleware
 
 import (
-	"net/http"
+	"context"
+	"fmt"
 
 	"monolith/internal/auth"
 	"monolith/internal/config"
+	"monolith/internal/logger"
 
 	"github.com/labstack/echo/v5"
 )
 
-func SessionAuth(authService *auth.Service, securityConfig config.SecurityConfig) echo.MiddlewareFunc {
+// AuthContextProvider resolves a session token to its auth context, as auth.Service does.
+type AuthContextProvider interface {
+	GetAuthContextByToken(ctx context.Context, unhashedToken string) (*auth.AuthContext, error)
+}
+
+func SessionAuth(authService AuthContextProvider, securityConfig config.SecurityConfig) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c *echo.Context) error {
 			cookie, err := c.Cookie(securityConfig.LoginCookieName)
 			if err != nil {
-				return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Authentication required"})
+				return auth.ErrAuthenticationRequired
 			}
 
 			// Get all auth context in a single database query
 			authCtx, err := authService.GetAuthContextByToken(c.Request().Context(), cookie.Value)
 			if err != nil {
-				return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Failed to retrieve session"})
+				return fmt.Errorf("%w: %w", auth.ErrAuthenticationRequired, err)
 			}
 
 			user := &auth.AuthUser{
@@ -29,9 +36,18 @@ func SessionAuth(authService *auth.Service, securityConfig config.SecurityConfig
 				IsAdmin:   authCtx.AccountIsAdmin,
 				SessionID: authCtx.SessionID,
 			}
+			if authCtx.AccountLanguage != nil {
+				user.Language = *authCtx.AccountLanguage
+			}
 
 			c.Set("user", user)
 
+			ctx := logger.With(c.Request().Context(),
+				"account_id", user.AccountID,
+				"session_id", user.SessionID,
+			)
+			c.SetRequest(c.Request().WithContext(ctx))
+
 			return next(c)
 		}
 	}
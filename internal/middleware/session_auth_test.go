@@ -121,7 +121,7 @@ func TestSessionAuth(t *testing.T) {
 			tt.setupMock(mock)
 
 			db := &database.DB{Pool: mock}
-			authService := auth.NewService(db, cfg)
+			authService := auth.NewService(auth.NewPostgresSessionStore(db), cfg, nil)
 
 			e := echo.New()
 			req := httptest.NewRequest(http.MethodGet, "/", nil)
@@ -142,9 +142,11 @@ func TestSessionAuth(t *testing.T) {
 
 			if tt.wantStatus == http.StatusOK {
 				require.NoError(t, err)
+				assert.Equal(t, tt.wantStatus, rec.Code)
+			} else {
+				require.ErrorIs(t, err, auth.ErrAuthenticationRequired)
 			}
 
-			assert.Equal(t, tt.wantStatus, rec.Code)
 			assert.Equal(t, tt.handlerCalls, handlerCalled)
 
 			if tt.wantUserSet {
@@ -0,0 +1,221 @@
+// Package openapi contains a minimal OpenAPI 3.1 document model and a reflection based schema generator,
+// so the specification is built from the same Go types the handlers bind and return.
+package openapi
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Version is the OpenAPI specification version documents are written against.
+const Version = "3.1.0"
+
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Servers    []Server            `json:"servers,omitempty"`
+	Tags       []Tag               `json:"tags,omitempty"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+
+	schemaTypes map[string]reflect.Type
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type Server struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem maps lower-case HTTP methods to operations.
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []SecurityRequirement `json:"security"`
+	Deprecated  bool                  `json:"deprecated,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+type Response struct {
+	Ref         string               `json:"$ref,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Headers     map[string]Header    `json:"headers,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type Header struct {
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas,omitempty"`
+	Responses       map[string]Response       `json:"responses,omitempty"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Description  string `json:"description,omitempty"`
+	Name         string `json:"name,omitempty"`
+	In           string `json:"in,omitempty"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// SecurityRequirement names the schemes (and scopes) an operation accepts. An empty list on an operation
+// marks it as public.
+type SecurityRequirement map[string][]string
+
+// NewDocument returns an empty document with the given title and version.
+func NewDocument(title, version string) *Document {
+	return &Document{
+		OpenAPI: Version,
+		Info:    Info{Title: title, Version: version},
+		Paths:   map[string]PathItem{},
+		Components: Components{
+			Schemas:         map[string]*Schema{},
+			Responses:       map[string]Response{},
+			SecuritySchemes: map[string]SecurityScheme{},
+		},
+		schemaTypes: map[string]reflect.Type{},
+	}
+}
+
+// AddOperation registers op under method and path. Echo style path parameters (":id") are converted to
+// OpenAPI templates ("{id}") and declared as required path parameters when op doesn't declare them.
+func (d *Document) AddOperation(method, path string, op *Operation) {
+	path, params := convertPath(path)
+
+	for _, name := range params {
+		if !hasParameter(op.Parameters, name, "path") {
+			op.Parameters = append(op.Parameters, PathParameter(name, ""))
+		}
+	}
+	if op.Security == nil {
+		op.Security = []SecurityRequirement{}
+	}
+
+	item, ok := d.Paths[path]
+	if !ok {
+		item = PathItem{}
+		d.Paths[path] = item
+	}
+	item[strings.ToLower(method)] = op
+}
+
+// Operation returns the operation registered for method and an Echo or OpenAPI style path.
+func (d *Document) Operation(method, path string) (*Operation, bool) {
+	path, _ = convertPath(path)
+	op, ok := d.Paths[path][strings.ToLower(method)]
+	return op, ok
+}
+
+// PathParameter returns a required string path parameter.
+func PathParameter(name, description string) Parameter {
+	return Parameter{Name: name, In: "path", Description: description, Required: true, Schema: &Schema{Type: "string"}}
+}
+
+// QueryParameter returns an optional query parameter with the given schema.
+func QueryParameter(name, description string, schema *Schema) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Schema: schema}
+}
+
+// HeaderParameter returns an optional request header parameter with the given schema.
+func HeaderParameter(name, description string, schema *Schema) Parameter {
+	return Parameter{Name: name, In: "header", Description: description, Schema: schema}
+}
+
+// JSONBody returns a required request body described by schema.
+func JSONBody(schema *Schema) *RequestBody {
+	return &RequestBody{Required: true, Content: map[string]MediaType{"application/json": {Schema: schema}}}
+}
+
+// MultipartBody returns a required multipart/form-data request body described by schema.
+func MultipartBody(schema *Schema) *RequestBody {
+	return &RequestBody{Required: true, Content: map[string]MediaType{"multipart/form-data": {Schema: schema}}}
+}
+
+// JSONResponse returns a response with an application/json body described by schema.
+func JSONResponse(description string, schema *Schema) Response {
+	return ContentResponse(description, "application/json", schema)
+}
+
+// ContentResponse returns a response with a body of the given media type.
+func ContentResponse(description, mediaType string, schema *Schema) Response {
+	return Response{Description: description, Content: map[string]MediaType{mediaType: {Schema: schema}}}
+}
+
+// NoContent returns a response without a body.
+func NoContent(description string) Response {
+	return Response{Description: description}
+}
+
+// ResponseRef references a response declared in the components section.
+func ResponseRef(name string) Response {
+	return Response{Ref: "#/components/responses/" + name}
+}
+
+// StatusKey formats an HTTP status code as a responses map key.
+func StatusKey(status int) string {
+	return strconv.Itoa(status)
+}
+
+func convertPath(path string) (string, []string) {
+	segments := strings.Split(path, "/")
+	var params []string
+	for i, segment := range segments {
+		switch {
+		case strings.HasPrefix(segment, ":"):
+			name := segment[1:]
+			params = append(params, name)
+			segments[i] = "{" + name + "}"
+		case strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}"):
+			params = append(params, segment[1:len(segment)-1])
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+func hasParameter(params []Parameter, name, in string) bool {
+	for _, p := range params {
+		if p.Name == name && p.In == in {
+			return true
+		}
+	}
+	return false
+}
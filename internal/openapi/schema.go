@@ -0,0 +1,310 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Schema is a JSON Schema (2020-12) object as used by OpenAPI 3.1. Type is either a single type name or a
+// list, e.g. ["string", "null"] for nullable values.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 any                `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ReadOnly             bool               `json:"readOnly,omitempty"`
+}
+
+// Ref returns a schema referencing the named component schema.
+func Ref(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// ArrayOf returns an array schema of items.
+func ArrayOf(items *Schema) *Schema {
+	return &Schema{Type: "array", Items: items}
+}
+
+// Object returns an object schema with the given properties, all of them required.
+func Object(properties map[string]*Schema) *Schema {
+	required := make([]string, 0, len(properties))
+	for name := range properties {
+		required = append(required, name)
+	}
+	slices.Sort(required)
+	return &Schema{Type: "object", Properties: properties, Required: required}
+}
+
+// String returns a plain string schema.
+func String() *Schema {
+	return &Schema{Type: "string"}
+}
+
+// Binary returns a schema for raw file content.
+func Binary() *Schema {
+	return &Schema{Type: "string", Format: "binary"}
+}
+
+var (
+	timeType      = reflect.TypeFor[time.Time]()
+	uuidType      = reflect.TypeFor[uuid.UUID]()
+	rawJSONType   = reflect.TypeFor[json.RawMessage]()
+	marshalerType = reflect.TypeFor[json.Marshaler]()
+)
+
+// SchemaFor returns the schema of v's type. Named struct types are registered once under
+// components/schemas and referenced; the struct's json tags name properties and its validate tags
+// contribute required fields and constraints.
+func (d *Document) SchemaFor(v any) *Schema {
+	return d.schemaForType(reflect.TypeOf(v))
+}
+
+func (d *Document) schemaForType(t reflect.Type) *Schema {
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case uuidType:
+		return &Schema{Type: "string", Format: "uuid"}
+	case rawJSONType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return nullable(d.schemaForType(t.Elem()))
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16,
+		reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return ArrayOf(d.schemaForType(t.Elem()))
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: d.schemaForType(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return d.structSchema(t)
+		}
+		return d.namedStructSchema(t)
+	default:
+		// interfaces, custom marshalers and anything else accept any JSON value
+		return &Schema{}
+	}
+}
+
+func (d *Document) namedStructSchema(t reflect.Type) *Schema {
+	if t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType) {
+		return &Schema{}
+	}
+
+	name := t.Name()
+	if existing, ok := d.schemaTypes[name]; ok && existing != t {
+		// two packages export the same type name; qualify the second one with its package
+		name = pascalCase(pathBase(t.PkgPath())) + name
+	}
+
+	if _, ok := d.schemaTypes[name]; !ok {
+		d.schemaTypes[name] = t
+		// register a placeholder first so recursive types terminate
+		d.Components.Schemas[name] = &Schema{}
+		*d.Components.Schemas[name] = *d.structSchema(t)
+	}
+	return Ref(name)
+}
+
+// structSchema builds an object schema from t's exported fields. Request types declare required fields
+// through validate tags; types without any validate tag are responses, where every non-pointer field
+// without omitempty is always present.
+func (d *Document) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	isRequest := hasValidateTags(t)
+
+	for field := range t.Fields() {
+		if !field.IsExported() {
+			continue
+		}
+
+		name, omitEmpty, skip := jsonName(field)
+		if skip {
+			continue
+		}
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				inner := d.structSchema(embedded)
+				for propName, prop := range inner.Properties {
+					schema.Properties[propName] = prop
+				}
+				schema.Required = append(schema.Required, inner.Required...)
+				continue
+			}
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		prop := d.schemaForType(field.Type)
+		required := applyValidateTag(prop, field.Tag.Get("validate"))
+		schema.Properties[name] = prop
+
+		if required || (!isRequest && !omitEmpty && field.Type.Kind() != reflect.Pointer) {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+
+	slices.Sort(schema.Required)
+	return schema
+}
+
+func hasValidateTags(t reflect.Type) bool {
+	for field := range t.Fields() {
+		if field.Tag.Get("validate") != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// applyValidateTag maps the go-playground validator rules that have a JSON Schema equivalent and reports
+// whether the field is required.
+func applyValidateTag(schema *Schema, tag string) bool {
+	if tag == "" {
+		return false
+	}
+
+	rules, _, _ := strings.Cut(tag, ",dive")
+	required := false
+	for rule := range strings.SplitSeq(rules, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			required = true
+		case "email":
+			schema.Format = "email"
+		case "uuid", "uuid4":
+			schema.Format = "uuid"
+		case "url", "uri":
+			schema.Format = "uri"
+		case "oneof":
+			for value := range strings.FieldsSeq(param) {
+				schema.Enum = append(schema.Enum, value)
+			}
+		case "min", "max", "gte", "lte":
+			applyBound(schema, name, param)
+		}
+	}
+
+	if _, itemRules, ok := strings.Cut(tag, ",dive,"); ok && schema.Items != nil {
+		applyValidateTag(schema.Items, itemRules)
+	}
+	return required
+}
+
+func applyBound(schema *Schema, rule, param string) {
+	n, err := strconv.Atoi(param)
+	if err != nil {
+		return
+	}
+	isMin := rule == "min" || rule == "gte"
+
+	switch schemaType(schema) {
+	case "string":
+		if isMin {
+			schema.MinLength = &n
+		} else {
+			schema.MaxLength = &n
+		}
+	case "array":
+		if isMin {
+			schema.MinItems = &n
+		} else {
+			schema.MaxItems = &n
+		}
+	case "integer", "number":
+		f := float64(n)
+		if isMin {
+			schema.Minimum = &f
+		} else {
+			schema.Maximum = &f
+		}
+	}
+}
+
+func schemaType(schema *Schema) string {
+	switch t := schema.Type.(type) {
+	case string:
+		return t
+	case []string:
+		return t[0]
+	default:
+		return ""
+	}
+}
+
+// nullable allows null in addition to schema. References can't carry siblings in every tool, so they are
+// wrapped in oneOf.
+func nullable(schema *Schema) *Schema {
+	switch t := schema.Type.(type) {
+	case string:
+		schema.Type = []string{t, "null"}
+		return schema
+	case nil:
+		if schema.Ref == "" {
+			return schema
+		}
+		return &Schema{OneOf: []*Schema{schema, {Type: "null"}}}
+	default:
+		return schema
+	}
+}
+
+func jsonName(field reflect.StructField) (name string, omitEmpty, skip bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+	name, opts, _ := strings.Cut(tag, ",")
+	return name, strings.Contains(opts, "omitempty") || strings.Contains(opts, "omitzero"), false
+}
+
+func pathBase(pkgPath string) string {
+	return pkgPath[strings.LastIndex(pkgPath, "/")+1:]
+}
+
+func pascalCase(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
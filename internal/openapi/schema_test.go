@@ -0,0 +1,91 @@
+package openapi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testAddress struct {
+	City string `json:"city"`
+}
+
+type testRequest struct {
+	Email   string   `json:"email"            validate:"required,email"`
+	Name    string   `json:"name"`
+	Role    string   `json:"role"             validate:"oneof=admin user"`
+	Tags    []string `json:"tags"             validate:"required,min=1,dive,min=2"`
+	Comment *string  `json:"comment"`
+	Secret  string   `json:"-"`
+}
+
+type testResponse struct {
+	ID        uuid.UUID    `json:"id"`
+	CreatedAt time.Time    `json:"createdAt"`
+	Address   *testAddress `json:"address"`
+	Count     int64        `json:"count,omitempty"`
+	Labels    map[string]string
+}
+
+func TestSchemaFor(t *testing.T) {
+	doc := NewDocument("test", "1.0.0")
+
+	t.Run("request struct uses validate tags", func(t *testing.T) {
+		ref := doc.SchemaFor(testRequest{})
+		assert.Equal(t, "#/components/schemas/testRequest", ref.Ref)
+
+		schema := doc.Components.Schemas["testRequest"]
+		require.NotNil(t, schema)
+		assert.Equal(t, []string{"email", "tags"}, schema.Required)
+		assert.Equal(t, "email", schema.Properties["email"].Format)
+		assert.Equal(t, []any{"admin", "user"}, schema.Properties["role"].Enum)
+		assert.Equal(t, 1, *schema.Properties["tags"].MinItems)
+		assert.Equal(t, 2, *schema.Properties["tags"].Items.MinLength)
+		assert.Equal(t, []string{"string", "null"}, schema.Properties["comment"].Type)
+		assert.NotContains(t, schema.Properties, "Secret")
+	})
+
+	t.Run("response struct requires non-optional fields", func(t *testing.T) {
+		doc.SchemaFor(testResponse{})
+
+		schema := doc.Components.Schemas["testResponse"]
+		require.NotNil(t, schema)
+		assert.Equal(t, []string{"Labels", "createdAt", "id"}, schema.Required)
+		assert.Equal(t, "uuid", schema.Properties["id"].Format)
+		assert.Equal(t, "date-time", schema.Properties["createdAt"].Format)
+		assert.Equal(t, "int64", schema.Properties["count"].Format)
+		assert.Equal(t, "string", schema.Properties["Labels"].AdditionalProperties.Type)
+
+		address := schema.Properties["address"]
+		require.Len(t, address.OneOf, 2)
+		assert.Equal(t, "#/components/schemas/testAddress", address.OneOf[0].Ref)
+		assert.Equal(t, "null", address.OneOf[1].Type)
+		assert.Contains(t, doc.Components.Schemas, "testAddress")
+	})
+
+	t.Run("slices reference the element schema", func(t *testing.T) {
+		schema := doc.SchemaFor([]testAddress{})
+		assert.Equal(t, "array", schema.Type)
+		assert.Equal(t, "#/components/schemas/testAddress", schema.Items.Ref)
+	})
+}
+
+func TestAddOperation(t *testing.T) {
+	doc := NewDocument("test", "1.0.0")
+	doc.AddOperation("GET", "/api/accounts/:id/sessions/:sessionId", &Operation{OperationID: "get"})
+
+	op, ok := doc.Operation("GET", "/api/accounts/{id}/sessions/{sessionId}")
+	require.True(t, ok)
+	assert.Same(t, op, doc.Paths["/api/accounts/{id}/sessions/{sessionId}"]["get"])
+	require.Len(t, op.Parameters, 2)
+	assert.Equal(t, "id", op.Parameters[0].Name)
+	assert.Equal(t, "path", op.Parameters[0].In)
+	assert.True(t, op.Parameters[0].Required)
+	assert.NotNil(t, op.Security)
+
+	_, ok = doc.Operation("POST", "/api/accounts/:id/sessions/:sessionId")
+	assert.False(t, ok)
+}
@@ -0,0 +1,221 @@
+// Package realtime pushes events to the browser sessions of a user as they happen. Domain events are
+// turned into stream messages by a single outbox subscriber, which publishes them with Postgres NOTIFY;
+// every instance LISTENs and fans them out to the sessions connected to it.
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+
+	"monolith/internal/database"
+	"monolith/internal/events"
+	"monolith/internal/logger"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/google/uuid"
+)
+
+const (
+	// channel is the NOTIFY channel messages are published on.
+	channel = "realtime"
+	// maxNotifyPayload keeps messages below the 8000 byte NOTIFY payload limit.
+	maxNotifyPayload = 7900
+	// subscriptionBuffer is how many messages a subscriber may fall behind before it is dropped.
+	subscriptionBuffer = 16
+	// replayLimit caps how many missed messages are sent to a reconnecting subscriber.
+	replayLimit = 100
+)
+
+// Subscription receives the messages for one session.
+type Subscription struct {
+	accountID uuid.UUID
+	sessionID uuid.UUID
+	messages  chan Message
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// Messages delivers the messages for the session.
+func (s *Subscription) Messages() <-chan Message {
+	return s.messages
+}
+
+// Closed is closed when the broker drops the subscription, because the subscriber fell too far behind or
+// the broker stopped. The subscriber should then end its stream; a reconnecting client catches up with
+// Last-Event-ID.
+func (s *Subscription) Closed() <-chan struct{} {
+	return s.closed
+}
+
+func (s *Subscription) close() {
+	s.closeOnce.Do(func() {
+		close(s.closed)
+	})
+}
+
+// Broker fans messages out to the sessions connected to this instance.
+type Broker struct {
+	db            *database.DB
+	mu            sync.Mutex
+	subscriptions map[uuid.UUID]map[*Subscription]struct{}
+	stopped       bool
+}
+
+func NewBroker(db *database.DB) *Broker {
+	return &Broker{
+		db:            db,
+		subscriptions: map[uuid.UUID]map[*Subscription]struct{}{},
+	}
+}
+
+// HandleEvent publishes the stream message for a domain event to every instance.
+func (b *Broker) HandleEvent(ctx context.Context, e events.Envelope) error {
+	msg, err := messageFor(e)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if len(payload) > maxNotifyPayload {
+		// clients still learn that something happened and can fetch the details
+		msg.Data = nil
+		if payload, err = json.Marshal(msg); err != nil {
+			return err
+		}
+	}
+
+	_, err = b.db.Pool.Exec(ctx, `SELECT pg_notify($1, $2)`, channel, string(payload))
+	return err
+}
+
+// Run delivers published messages to the subscriptions of this instance until ctx is canceled, then
+// closes them all.
+func (b *Broker) Run(ctx context.Context) {
+	b.db.Listen(ctx, channel, nil, func(payload string) {
+		var msg Message
+		if err := json.Unmarshal([]byte(payload), &msg); err != nil {
+			logger.FromContext(ctx).Warn("Failed to decode realtime message", "error", err)
+			return
+		}
+		b.publish(msg)
+	})
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.stopped = true
+	for accountID, subs := range b.subscriptions {
+		for sub := range subs {
+			sub.close()
+		}
+		delete(b.subscriptions, accountID)
+	}
+}
+
+// publish hands msg to the subscriptions it is meant for. A subscription whose buffer is full is dropped
+// rather than holding up everyone else.
+func (b *Broker) publish(msg Message) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.subscriptions[msg.AccountID] {
+		if !msg.isFor(sub.sessionID) {
+			continue
+		}
+		select {
+		case sub.messages <- msg:
+		default:
+			b.remove(sub)
+			sub.close()
+		}
+	}
+}
+
+// Subscribe starts delivering the messages for a session. The caller must Unsubscribe when done.
+func (b *Broker) Subscribe(accountID, sessionID uuid.UUID) *Subscription {
+	sub := &Subscription{
+		accountID: accountID,
+		sessionID: sessionID,
+		messages:  make(chan Message, subscriptionBuffer),
+		closed:    make(chan struct{}),
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.stopped {
+		sub.close()
+		return sub
+	}
+	if b.subscriptions[accountID] == nil {
+		b.subscriptions[accountID] = map[*Subscription]struct{}{}
+	}
+	b.subscriptions[accountID][sub] = struct{}{}
+	return sub
+}
+
+func (b *Broker) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.remove(sub)
+	sub.close()
+}
+
+// remove must be called with mu held.
+func (b *Broker) remove(sub *Subscription) {
+	subs := b.subscriptions[sub.accountID]
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(b.subscriptions, sub.accountID)
+	}
+}
+
+// Replay returns the messages for a session after the one with the given ID, in the order the dispatcher
+// hands events over, from the events still in the outbox. That order is by transaction first, so an event
+// with a lower ID that committed later isn't skipped. It returns at most replayLimit messages, and none when
+// the given event has been pruned.
+func (b *Broker) Replay(ctx context.Context, accountID, sessionID uuid.UUID, after int64) ([]Message, error) {
+	var missed []events.Envelope
+	err := pgxscan.Select(ctx, b.db.Pool, &missed, `
+		SELECT id AS position, event_id AS id, type, payload, created_at
+		FROM outbox
+		WHERE (txid, id) > (SELECT txid, id FROM outbox WHERE id = $1)
+		  AND type = ANY($2) AND payload ->> 'accountId' = $3
+		ORDER BY txid, id
+		LIMIT $4
+	`, after, sourceTypeNames(), accountID.String(), replayLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]Message, 0, len(missed))
+	for _, e := range missed {
+		msg, err := messageFor(e)
+		if err != nil {
+			return nil, err
+		}
+		if msg.isFor(sessionID) {
+			messages = append(messages, *msg)
+		}
+	}
+	return messages, nil
+}
+
+func sourceTypeNames() []string {
+	names := make([]string, len(SourceTypes))
+	for i, t := range SourceTypes {
+		names[i] = string(t)
+	}
+	return names
+}
+
+// ParseLastEventID parses a Last-Event-ID header, returning 0 for a missing or malformed one.
+func ParseLastEventID(value string) int64 {
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || id < 0 {
+		return 0
+	}
+	return id
+}
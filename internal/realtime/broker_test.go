@@ -0,0 +1,185 @@
+package realtime
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"monolith/internal/database"
+	"monolith/internal/events"
+
+	"github.com/google/uuid"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func envelope(position int64, eventType events.Type, payload any) events.Envelope {
+	data, _ := json.Marshal(payload)
+	return events.Envelope{Position: position, ID: uuid.New(), Type: eventType, Payload: data, CreatedAt: time.Now()}
+}
+
+func TestMessageFor(t *testing.T) {
+	accountID := uuid.New()
+	sessionID := uuid.New()
+
+	tests := []struct {
+		name           string
+		envelope       events.Envelope
+		wantEvent      string
+		wantSessionIDs []uuid.UUID
+		wantData       string
+	}{
+		{
+			name: "revocation targets the revoked sessions",
+			envelope: envelope(3, events.TypeSessionRevoked, events.SessionRevoked{
+				AccountID: accountID, SessionIDs: []uuid.UUID{sessionID},
+			}),
+			wantEvent:      EventSessionRevoked,
+			wantSessionIDs: []uuid.UUID{sessionID},
+			wantData:       `{"accountId":"` + accountID.String() + `","sessionIds":["` + sessionID.String() + `"]}`,
+		},
+		{
+			name:      "account changes become account updates",
+			envelope:  envelope(3, events.TypeAccountDisabled, events.AccountDisabled{AccountID: accountID}),
+			wantEvent: EventAccountUpdated,
+			wantData:  `{"accountId":"` + accountID.String() + `","change":"disabled"}`,
+		},
+		{
+			name: "notification",
+			envelope: envelope(3, events.TypeNotificationCreated, events.NotificationCreated{
+				AccountID: accountID, Title: "Export ready", Body: "Your export can be downloaded.",
+			}),
+			wantEvent: EventNotification,
+			wantData: `{"accountId":"` + accountID.String() +
+				`","title":"Export ready","body":"Your export can be downloaded."}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := messageFor(tt.envelope)
+			require.NoError(t, err)
+
+			assert.Equal(t, int64(3), msg.ID)
+			assert.Equal(t, tt.wantEvent, msg.Event)
+			assert.Equal(t, accountID, msg.AccountID)
+			assert.Equal(t, tt.wantSessionIDs, msg.SessionIDs)
+			assert.JSONEq(t, tt.wantData, string(msg.Data))
+		})
+	}
+
+	_, err := messageFor(envelope(3, events.TypeSessionCreated, events.SessionCreated{AccountID: accountID}))
+	require.Error(t, err)
+}
+
+func TestBroker_publish(t *testing.T) {
+	b := NewBroker(nil)
+	alice, bob := uuid.New(), uuid.New()
+	aliceLaptop := b.Subscribe(alice, uuid.New())
+	alicePhone := b.Subscribe(alice, uuid.New())
+	bobLaptop := b.Subscribe(bob, uuid.New())
+
+	b.publish(Message{ID: 1, Event: EventAccountUpdated, AccountID: alice})
+	b.publish(Message{
+		ID: 2, Event: EventSessionRevoked, AccountID: alice, SessionIDs: []uuid.UUID{alicePhone.sessionID},
+	})
+
+	assert.Equal(t, []int64{1}, drain(aliceLaptop))
+	assert.Equal(t, []int64{1, 2}, drain(alicePhone))
+	assert.Empty(t, drain(bobLaptop))
+
+	b.Unsubscribe(aliceLaptop)
+	b.publish(Message{ID: 3, Event: EventAccountUpdated, AccountID: alice})
+	assert.Empty(t, drain(aliceLaptop))
+	assert.Equal(t, []int64{3}, drain(alicePhone))
+}
+
+func TestBroker_publishDropsSlowSubscriber(t *testing.T) {
+	b := NewBroker(nil)
+	accountID := uuid.New()
+	sub := b.Subscribe(accountID, uuid.New())
+
+	for i := range subscriptionBuffer + 1 {
+		b.publish(Message{ID: int64(i), Event: EventNotification, AccountID: accountID})
+	}
+
+	select {
+	case <-sub.Closed():
+	default:
+		t.Fatal("the subscription should be closed")
+	}
+	assert.Empty(t, b.subscriptions)
+}
+
+func TestBroker_HandleEvent(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	accountID := uuid.New()
+	mock.ExpectExec(`SELECT pg_notify\(\$1, \$2\)`).
+		WithArgs("realtime", `{"id":5,"event":"account.updated","accountId":"`+accountID.String()+
+			`","data":{"accountId":"`+accountID.String()+`","change":"enabled"}}`).
+		WillReturnResult(pgxmock.NewResult("SELECT", 1))
+
+	b := NewBroker(&database.DB{Pool: mock})
+	err = b.HandleEvent(context.Background(), envelope(5, events.TypeAccountEnabled, events.AccountEnabled{
+		AccountID: accountID,
+	}))
+
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBroker_Replay(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	accountID := uuid.New()
+	sessionID := uuid.New()
+	revoked := envelope(8, events.TypeSessionRevoked, events.SessionRevoked{
+		AccountID: accountID, SessionIDs: []uuid.UUID{uuid.New()},
+	})
+	updated := envelope(9, events.TypeAccountUpdated, events.AccountUpdated{AccountID: accountID})
+	mock.ExpectQuery(`SELECT id AS position, event_id AS id, type, payload, created_at FROM outbox\s+`+
+		`WHERE \(txid, id\) > \(SELECT txid, id FROM outbox WHERE id = \$1\).+ORDER BY txid, id`).
+		WithArgs(int64(7), pgxmock.AnyArg(), accountID.String(), replayLimit).
+		WillReturnRows(pgxmock.NewRows([]string{"position", "id", "type", "payload", "created_at"}).
+			AddRow(revoked.Position, revoked.ID, revoked.Type, revoked.Payload, revoked.CreatedAt).
+			AddRow(updated.Position, updated.ID, updated.Type, updated.Payload, updated.CreatedAt))
+
+	b := NewBroker(&database.DB{Pool: mock})
+	messages, err := b.Replay(context.Background(), accountID, sessionID, 7)
+
+	require.NoError(t, err)
+	// another session's revocation is skipped
+	require.Len(t, messages, 1)
+	assert.Equal(t, int64(9), messages[0].ID)
+	assert.Equal(t, EventAccountUpdated, messages[0].Event)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWriteMessage(t *testing.T) {
+	var buf bytes.Buffer
+	data := json.RawMessage("{\n  \"title\": \"Hi\"\n}")
+	err := WriteMessage(&buf, Message{ID: 12, Event: EventNotification, Data: data})
+
+	require.NoError(t, err)
+	assert.Equal(t, "id: 12\nevent: notification\ndata: {\"title\":\"Hi\"}\n\n", buf.String())
+}
+
+func drain(sub *Subscription) []int64 {
+	var ids []int64
+	for {
+		select {
+		case msg := <-sub.Messages():
+			ids = append(ids, msg.ID)
+		default:
+			return ids
+		}
+	}
+}
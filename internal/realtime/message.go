@@ -0,0 +1,96 @@
+package realtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"monolith/internal/events"
+
+	"github.com/google/uuid"
+)
+
+// Event names sent on the stream.
+const (
+	// EventSessionRevoked tells a session it has been revoked, so the web app can log out.
+	EventSessionRevoked = "session.revoked"
+	// EventAccountUpdated tells the sessions of an account it changed, so the web app can refresh it.
+	EventAccountUpdated = "account.updated"
+	// EventNotification carries a message for the user.
+	EventNotification = "notification"
+)
+
+// SourceTypes are the domain event types that are sent on the stream; HandleEvent is subscribed to them.
+var SourceTypes = []events.Type{
+	events.TypeSessionRevoked,
+	events.TypeAccountUpdated,
+	events.TypeAccountDisabled,
+	events.TypeAccountEnabled,
+	events.TypeAccountDeleted,
+	events.TypeNotificationCreated,
+}
+
+// Message is an event for the sessions of one account.
+type Message struct {
+	// ID is the position of the domain event the message was made from. Clients send the last one they
+	// saw back as Last-Event-ID when they reconnect.
+	ID        int64     `json:"id"`
+	Event     string    `json:"event"`
+	AccountID uuid.UUID `json:"accountId"`
+	// SessionIDs limits the message to these sessions of the account; empty means every session.
+	SessionIDs []uuid.UUID     `json:"sessionIds,omitempty"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// isFor reports whether the message is meant for the given session of its account.
+func (m *Message) isFor(sessionID uuid.UUID) bool {
+	return len(m.SessionIDs) == 0 || slices.Contains(m.SessionIDs, sessionID)
+}
+
+// AccountChange is the data of an account.updated message.
+type AccountChange struct {
+	AccountID uuid.UUID `json:"accountId"`
+	// Change is what happened to the account: updated, disabled, enabled or deleted.
+	Change string `json:"change"`
+}
+
+// messageFor turns a domain event into a stream message.
+func messageFor(e events.Envelope) (*Message, error) {
+	var target struct {
+		AccountID  uuid.UUID   `json:"accountId"`
+		SessionIDs []uuid.UUID `json:"sessionIds"`
+	}
+	if err := e.Decode(&target); err != nil {
+		return nil, err
+	}
+	msg := &Message{ID: e.Position, AccountID: target.AccountID, Data: e.Payload}
+
+	switch e.Type {
+	case events.TypeSessionRevoked:
+		msg.Event = EventSessionRevoked
+		msg.SessionIDs = target.SessionIDs
+	case events.TypeAccountUpdated, events.TypeAccountDisabled, events.TypeAccountEnabled,
+		events.TypeAccountDeleted:
+		msg.Event = EventAccountUpdated
+		data, err := json.Marshal(AccountChange{
+			AccountID: target.AccountID,
+			Change:    accountChanges[e.Type],
+		})
+		if err != nil {
+			return nil, err
+		}
+		msg.Data = data
+	case events.TypeNotificationCreated:
+		msg.Event = EventNotification
+	default:
+		return nil, fmt.Errorf("event type %q is not streamed", e.Type)
+	}
+	return msg, nil
+}
+
+var accountChanges = map[events.Type]string{
+	events.TypeAccountUpdated:  "updated",
+	events.TypeAccountDisabled: "disabled",
+	events.TypeAccountEnabled:  "enabled",
+	events.TypeAccountDeleted:  "deleted",
+}
@@ -0,0 +1,28 @@
+package realtime
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// WriteMessage writes msg as a server-sent event, with the message ID as the event ID.
+func WriteMessage(w io.Writer, msg Message) error {
+	// a data line must not contain newlines
+	var data bytes.Buffer
+	if len(msg.Data) == 0 {
+		data.WriteString("null")
+	} else if err := json.Compact(&data, msg.Data); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", msg.ID, msg.Event, data.Bytes())
+	return err
+}
+
+// WriteHeartbeat writes a comment line, which keeps proxies from closing an idle stream and lets the
+// server notice a client that has gone away.
+func WriteHeartbeat(w io.Writer) error {
+	_, err := io.WriteString(w, ": heartbeat\n\n")
+	return err
+}
@@ -0,0 +1,39 @@
+// Package retry holds what background work shares about failed attempts: when to try again and what is
+// kept of the error.
+package retry
+
+import (
+	"strings"
+	"time"
+)
+
+// maxErrorLength caps the error text kept with a failed attempt.
+const maxErrorLength = 1024
+
+// Backoff is an exponential backoff: the delay after the first failed attempt is Base and each further
+// failure doubles it, up to Max.
+type Backoff struct {
+	Base time.Duration
+	Max  time.Duration
+}
+
+// Delay returns how long to wait before trying again after attempts failed attempts.
+func (b Backoff) Delay(attempts int) time.Duration {
+	delay := b.Base
+	for range attempts - 1 {
+		delay *= 2
+		if delay >= b.Max {
+			return b.Max
+		}
+	}
+	return delay
+}
+
+// ErrorText returns the message of err as it is kept with a failed attempt, cut to a bounded length.
+func ErrorText(err error) string {
+	text := err.Error()
+	if len(text) > maxErrorLength {
+		text = strings.ToValidUTF8(text[:maxErrorLength], "")
+	}
+	return text
+}
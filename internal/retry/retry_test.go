@@ -0,0 +1,26 @@
+package retry
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoff_Delay(t *testing.T) {
+	backoff := Backoff{Base: 15 * time.Second, Max: time.Hour}
+
+	assert.Equal(t, 15*time.Second, backoff.Delay(1))
+	assert.Equal(t, 30*time.Second, backoff.Delay(2))
+	assert.Equal(t, 2*time.Minute, backoff.Delay(4))
+	assert.Equal(t, time.Hour, backoff.Delay(12))
+}
+
+func TestErrorText(t *testing.T) {
+	assert.Equal(t, "connection refused", ErrorText(errors.New("connection refused")))
+
+	long := ErrorText(errors.New(strings.Repeat("a", maxErrorLength-1) + "é"))
+	assert.Equal(t, strings.Repeat("a", maxErrorLength-1), long)
+}
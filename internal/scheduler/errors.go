@@ -0,0 +1,8 @@
+package scheduler
+
+import "errors"
+
+var (
+	ErrInvalidSchedule = errors.New("invalid cron expression")
+	ErrDuplicateTask   = errors.New("a task with this name is already registered")
+)
@@ -0,0 +1,218 @@
+// Package scheduler runs named periodic tasks on cron schedules. Every instance runs a scheduler, and the
+// scheduled_task table makes each firing of a task run on only one of them: the instance that advances
+// the task's next run time also takes a lease on it, which keeps the task from overlapping itself.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"monolith/internal/config"
+	"monolith/internal/database"
+	"monolith/internal/health"
+	"monolith/internal/logger"
+	"monolith/internal/retry"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+)
+
+// Func is the work of a scheduled task. Its context is canceled when the task timeout passes or the
+// scheduler shuts down.
+type Func func(ctx context.Context) error
+
+type task struct {
+	name     string
+	schedule *Schedule
+	run      Func
+}
+
+type Scheduler struct {
+	db        *database.DB
+	cfg       config.SchedulerConfig
+	tasks     []*task
+	heartbeat *health.Heartbeat
+}
+
+func NewScheduler(db *database.DB, cfg config.SchedulerConfig) *Scheduler {
+	return &Scheduler{
+		db:        db,
+		cfg:       cfg,
+		heartbeat: health.NewHeartbeat(2 * cfg.PollInterval),
+	}
+}
+
+// Register schedules run under name with the cron expression spec; see ParseSchedule for the syntax.
+// name identifies the task's shared state and must stay the same across restarts. Register must be
+// called before Run.
+//
+// Returns:
+// - ErrInvalidSchedule if spec isn't a valid cron expression
+// - ErrDuplicateTask if a task with name is already registered
+func (s *Scheduler) Register(name, spec string, run Func) error {
+	for _, t := range s.tasks {
+		if t.name == name {
+			return fmt.Errorf("%w: %q", ErrDuplicateTask, name)
+		}
+	}
+	schedule, err := ParseSchedule(spec)
+	if err != nil {
+		return err
+	}
+	s.tasks = append(s.tasks, &task{name: name, schedule: schedule, run: run})
+	return nil
+}
+
+// Run fires due tasks until ctx is canceled, checking every poll interval, and returns once the tasks
+// it started have stopped.
+func (s *Scheduler) Run(ctx context.Context) {
+	if len(s.tasks) == 0 {
+		return
+	}
+	logger.FromContext(ctx).Info("Scheduler started", "tasks", len(s.tasks))
+
+	var wg sync.WaitGroup
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+	synced := false
+
+	for {
+		if !synced {
+			if err := s.sync(ctx); err != nil {
+				if ctx.Err() == nil {
+					logger.FromContext(ctx).Warn("Failed to sync scheduled tasks", "error", err)
+				}
+			} else {
+				synced = true
+			}
+		}
+		if synced {
+			s.tick(ctx, &wg)
+		}
+		s.heartbeat.Beat()
+
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			logger.FromContext(ctx).Info("Scheduler stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check reports whether the scheduler of this instance is running.
+func (s *Scheduler) Check(ctx context.Context) error {
+	return s.heartbeat.Check(ctx)
+}
+
+// sync records the registered tasks. A task whose schedule changed is rescheduled by the new one; an
+// unchanged task keeps its next run time, so a firing missed while every instance was down runs once.
+func (s *Scheduler) sync(ctx context.Context) error {
+	for _, t := range s.tasks {
+		_, err := s.db.Pool.Exec(ctx, `
+			INSERT INTO scheduled_task (name, schedule, next_run_at)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (name) DO UPDATE
+			SET schedule    = EXCLUDED.schedule,
+			    next_run_at = CASE
+			                      WHEN scheduled_task.schedule = EXCLUDED.schedule THEN scheduled_task.next_run_at
+			                      ELSE EXCLUDED.next_run_at
+			                  END
+		`, t.name, t.schedule.String(), t.schedule.Next(time.Now()))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tick starts every due task this instance manages to claim.
+func (s *Scheduler) tick(ctx context.Context, wg *sync.WaitGroup) {
+	for _, t := range s.tasks {
+		claimed, err := s.claim(ctx, t)
+		if err != nil {
+			if ctx.Err() == nil {
+				logger.FromContext(ctx).Warn("Failed to claim scheduled task", "task", t.name, "error", err)
+			}
+			continue
+		}
+		if claimed {
+			wg.Go(func() {
+				s.fire(ctx, t)
+			})
+		}
+	}
+}
+
+// claim advances a due task to its next run time and takes its lease for the task timeout, reporting
+// whether this instance got it.
+func (s *Scheduler) claim(ctx context.Context, t *task) (bool, error) {
+	now := time.Now()
+	tag, err := s.db.Pool.Exec(ctx, `
+		UPDATE scheduled_task
+		SET next_run_at = $2, locked_until = $3, last_started_at = NOW()
+		WHERE name = $1
+		  AND next_run_at <= NOW()
+		  AND (locked_until IS NULL OR locked_until < NOW())
+	`, t.name, t.schedule.Next(now), now.Add(s.cfg.TaskTimeout))
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// fire runs a claimed task and records the outcome, releasing its lease. The outcome is written with a
+// context that shutdown doesn't cancel, so an interrupted run doesn't hold the lease for the task timeout.
+func (s *Scheduler) fire(ctx context.Context, t *task) {
+	log := logger.FromContext(ctx).With("task", t.name)
+	started := time.Now()
+	runErr := s.run(ctx, t)
+	duration := time.Since(started)
+
+	var lastError *string
+	if runErr != nil {
+		log.Error("Scheduled task failed", "duration", duration, "error", runErr)
+		lastError = new(retry.ErrorText(runErr))
+	} else {
+		log.Info("Scheduled task finished", "duration", duration)
+	}
+
+	_, err := s.db.Pool.Exec(context.WithoutCancel(ctx), `
+		UPDATE scheduled_task
+		SET locked_until = NULL, last_finished_at = NOW(), last_duration_ms = $2, last_error = $3
+		WHERE name = $1
+	`, t.name, duration.Milliseconds(), lastError)
+	if err != nil {
+		log.Warn("Failed to record scheduled task run", "error", err)
+	}
+}
+
+// run calls the task within the task timeout. A panicking task fails the run.
+func (s *Scheduler) run(ctx context.Context, t *task) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.TaskTimeout)
+	defer cancel()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("task panicked: %v", r)
+		}
+	}()
+	return t.run(ctx)
+}
+
+// ListTasks returns the state of every task known to the cluster, by name.
+func (s *Scheduler) ListTasks(ctx context.Context) ([]Task, error) {
+	tasks := []Task{}
+	err := pgxscan.Select(ctx, s.db.Pool, &tasks, `
+		SELECT name, schedule, next_run_at, COALESCE(locked_until > NOW(), FALSE) AS running,
+		       last_started_at, last_finished_at, last_duration_ms, last_error
+		FROM scheduled_task
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
@@ -0,0 +1,124 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"monolith/internal/config"
+	"monolith/internal/database"
+
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testConfig = config.SchedulerConfig{
+	PollInterval: time.Millisecond,
+	TaskTimeout:  time.Second,
+}
+
+func TestScheduler_Register(t *testing.T) {
+	s := NewScheduler(nil, testConfig)
+	noop := func(context.Context) error { return nil }
+
+	require.NoError(t, s.Register("cleanup", "@hourly", noop))
+	require.ErrorIs(t, s.Register("cleanup", "@daily", noop), ErrDuplicateTask)
+	require.ErrorIs(t, s.Register("report", "@sometimes", noop), ErrInvalidSchedule)
+}
+
+func TestScheduler_tick(t *testing.T) {
+	expectClaim := func(mock pgxmock.PgxPoolIface, claimed int64) {
+		mock.ExpectExec(`UPDATE scheduled_task SET next_run_at = \$2, locked_until = \$3`).
+			WithArgs("cleanup", pgxmock.AnyArg(), pgxmock.AnyArg()).
+			WillReturnResult(pgxmock.NewResult("UPDATE", claimed))
+	}
+
+	tests := []struct {
+		name      string
+		run       Func
+		setupMock func(mock pgxmock.PgxPoolIface)
+		wantRuns  int
+	}{
+		{
+			name: "another instance has the task",
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				expectClaim(mock, 0)
+			},
+		},
+		{
+			name: "success is recorded",
+			run:  func(context.Context) error { return nil },
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				expectClaim(mock, 1)
+				mock.ExpectExec(`UPDATE scheduled_task SET locked_until = NULL, last_finished_at = NOW\(\)`).
+					WithArgs("cleanup", pgxmock.AnyArg(), (*string)(nil)).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+			wantRuns: 1,
+		},
+		{
+			name: "error is recorded",
+			run:  func(context.Context) error { return errors.New("database unavailable") },
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				expectClaim(mock, 1)
+				mock.ExpectExec(`UPDATE scheduled_task SET locked_until = NULL`).
+					WithArgs("cleanup", pgxmock.AnyArg(), new("database unavailable")).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+			wantRuns: 1,
+		},
+		{
+			name: "panic is recorded",
+			run:  func(context.Context) error { panic("nil store") },
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				expectClaim(mock, 1)
+				mock.ExpectExec(`UPDATE scheduled_task SET locked_until = NULL`).
+					WithArgs("cleanup", pgxmock.AnyArg(), new("task panicked: nil store")).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+			wantRuns: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mock.Close()
+			tt.setupMock(mock)
+
+			runs := 0
+			s := NewScheduler(&database.DB{Pool: mock}, testConfig)
+			require.NoError(t, s.Register("cleanup", "@hourly", func(ctx context.Context) error {
+				runs++
+				return tt.run(ctx)
+			}))
+
+			var wg sync.WaitGroup
+			s.tick(context.Background(), &wg)
+			wg.Wait()
+
+			assert.Equal(t, tt.wantRuns, runs)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestScheduler_sync(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	s := NewScheduler(&database.DB{Pool: mock}, testConfig)
+	require.NoError(t, s.Register("cleanup", "@hourly", func(context.Context) error { return nil }))
+
+	mock.ExpectExec(`INSERT INTO scheduled_task .+ ON CONFLICT \(name\) DO UPDATE`).
+		WithArgs("cleanup", "@hourly", pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+	require.NoError(t, s.sync(context.Background()))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
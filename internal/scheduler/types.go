@@ -0,0 +1,17 @@
+package scheduler
+
+import "time"
+
+// Task is the state of a scheduled task, shared by all instances.
+type Task struct {
+	Name      string    `json:"name"`
+	Schedule  string    `json:"schedule"`
+	NextRunAt time.Time `json:"nextRunAt"`
+	// Running is set while an instance holds the task's lease.
+	Running        bool       `json:"running"`
+	LastStartedAt  *time.Time `json:"lastStartedAt"`
+	LastFinishedAt *time.Time `json:"lastFinishedAt"`
+	LastDurationMs *int64     `json:"lastDurationMs"`
+	// LastError is the error of the last run, or null if it succeeded.
+	LastError *string `json:"lastError"`
+}
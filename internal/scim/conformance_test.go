@@ -0,0 +1,251 @@
+package scim
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testToken = "scim-test-token"
+
+// scimClient sends requests to the SCIM endpoints mounted over an in-memory store.
+type scimClient struct {
+	t *testing.T
+	e *echo.Echo
+}
+
+func newSCIMClient(t *testing.T) *scimClient {
+	e := echo.New()
+	Mount(e.Group("/scim/v2"), NewMemoryStore(), testToken)
+	return &scimClient{t: t, e: e}
+}
+
+func (c *scimClient) do(method, path, body string, token string) (*httptest.ResponseRecorder, map[string]any) {
+	c.t.Helper()
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, MIMEApplicationSCIMJSON)
+	if token != "" {
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	c.e.ServeHTTP(rec, req)
+
+	var decoded map[string]any
+	if rec.Body.Len() > 0 {
+		require.NoError(c.t, json.Unmarshal(rec.Body.Bytes(), &decoded), rec.Body.String())
+		assert.Equal(c.t, MIMEApplicationSCIMJSON, rec.Header().Get(echo.HeaderContentType))
+	}
+	return rec, decoded
+}
+
+func (c *scimClient) request(method, path, body string) (*httptest.ResponseRecorder, map[string]any) {
+	c.t.Helper()
+	return c.do(method, path, body, testToken)
+}
+
+// assertError checks a response is a SCIM error with the status and scimType of want.
+func assertError(t *testing.T, rec *httptest.ResponseRecorder, body map[string]any, want *Error) {
+	t.Helper()
+	assert.Equal(t, want.Status, rec.Code)
+	assert.Equal(t, []any{SchemaError}, body["schemas"])
+	assert.Equal(t, strconv.Itoa(want.Status), body["status"])
+	if want.ScimType != "" {
+		assert.Equal(t, want.ScimType, body["scimType"])
+	}
+}
+
+const janeJSON = `{
+	"schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+	"externalId": "00u1",
+	"userName": "jane.doe",
+	"name": {"givenName": "Jane", "familyName": "Doe"},
+	"emails": [{"value": "jane@example.com", "type": "work", "primary": true}],
+	"preferredLanguage": "en",
+	"active": true
+}`
+
+// TestConformance runs a provisioning lifecycle the way identity providers drive it: discovery,
+// create, lookup by filter, patch, replace and delete.
+func TestConformance(t *testing.T) {
+	c := newSCIMClient(t)
+	var janeID, janeLocation string
+
+	t.Run("requests without the token are rejected", func(t *testing.T) {
+		rec, body := c.do(http.MethodGet, "/scim/v2/Users", "", "")
+		assertError(t, rec, body, ErrUnauthorized)
+		assert.Contains(t, rec.Header().Get(echo.HeaderWWWAuthenticate), "Bearer")
+
+		rec, body = c.do(http.MethodGet, "/scim/v2/Users", "", "wrong-token")
+		assertError(t, rec, body, ErrUnauthorized)
+	})
+
+	t.Run("service provider config", func(t *testing.T) {
+		rec, body := c.request(http.MethodGet, "/scim/v2/ServiceProviderConfig", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, []any{SchemaServiceProviderConfig}, body["schemas"])
+		assert.Equal(t, map[string]any{"supported": true}, body["patch"])
+		assert.Equal(t, true, body["filter"].(map[string]any)["supported"])
+		assert.Equal(t, false, body["bulk"].(map[string]any)["supported"])
+	})
+
+	t.Run("resource types and schemas", func(t *testing.T) {
+		rec, body := c.request(http.MethodGet, "/scim/v2/ResourceTypes", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.EqualValues(t, 1, body["totalResults"])
+
+		rec, body = c.request(http.MethodGet, "/scim/v2/ResourceTypes/User", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "/Users", body["endpoint"])
+		assert.Equal(t, SchemaUser, body["schema"])
+
+		rec, body = c.request(http.MethodGet, "/scim/v2/Schemas/"+SchemaUser, "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.NotEmpty(t, body["attributes"])
+
+		rec, body = c.request(http.MethodGet, "/scim/v2/Schemas/urn:example:unknown", "")
+		assertError(t, rec, body, ErrNotFound)
+	})
+
+	t.Run("create", func(t *testing.T) {
+		rec, body := c.request(http.MethodPost, "/scim/v2/Users", janeJSON)
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+		janeID, _ = body["id"].(string)
+		require.NotEmpty(t, janeID)
+		janeLocation = "http://example.com/scim/v2/Users/" + janeID
+		assert.Equal(t, janeLocation, rec.Header().Get(echo.HeaderLocation))
+		assert.Equal(t, "jane.doe", body["userName"])
+		assert.Equal(t, "00u1", body["externalId"])
+		assert.Equal(t, "Jane Doe", body["displayName"])
+		assert.Equal(t, true, body["active"])
+		assert.NotContains(t, body, "password")
+
+		meta := body["meta"].(map[string]any)
+		assert.Equal(t, "User", meta["resourceType"])
+		assert.Equal(t, janeLocation, meta["location"])
+		assert.NotEmpty(t, meta["created"])
+	})
+
+	t.Run("create rejects duplicates and missing attributes", func(t *testing.T) {
+		rec, body := c.request(http.MethodPost, "/scim/v2/Users", janeJSON)
+		assertError(t, rec, body, ErrUniqueness)
+
+		rec, body = c.request(http.MethodPost, "/scim/v2/Users", `{"emails":[{"value":"x@example.com"}]}`)
+		assertError(t, rec, body, ErrInvalidValue)
+
+		rec, body = c.request(http.MethodPost, "/scim/v2/Users", `{"userName":`)
+		assertError(t, rec, body, ErrInvalidSyntax)
+	})
+
+	t.Run("get", func(t *testing.T) {
+		rec, body := c.request(http.MethodGet, "/scim/v2/Users/"+janeID, "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, janeID, body["id"])
+
+		rec, body = c.request(http.MethodGet, "/scim/v2/Users/0b7e4d52-1f2a-4e3c-8a9b-6c5d4e3f2a1b", "")
+		assertError(t, rec, body, ErrNotFound)
+
+		rec, body = c.request(http.MethodGet, "/scim/v2/Users/not-an-id", "")
+		assertError(t, rec, body, ErrNotFound)
+	})
+
+	t.Run("list with filter and pagination", func(t *testing.T) {
+		rec, _ := c.request(http.MethodPost, "/scim/v2/Users",
+			`{"userName":"john","emails":[{"value":"john@example.com"}],"active":false}`)
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+		rec, body := c.request(http.MethodGet, `/scim/v2/Users?filter=userName+eq+%22Jane.Doe%22`, "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, []any{SchemaListResponse}, body["schemas"])
+		assert.EqualValues(t, 1, body["totalResults"])
+		assert.Equal(t, janeID, body["Resources"].([]any)[0].(map[string]any)["id"])
+
+		rec, body = c.request(http.MethodGet, `/scim/v2/Users?filter=externalId+eq+%22missing%22`, "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.EqualValues(t, 0, body["totalResults"])
+		assert.Equal(t, []any{}, body["Resources"])
+
+		rec, body = c.request(http.MethodGet, "/scim/v2/Users?startIndex=2&count=1", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.EqualValues(t, 2, body["totalResults"])
+		assert.EqualValues(t, 2, body["startIndex"])
+		assert.EqualValues(t, 1, body["itemsPerPage"])
+		assert.Equal(t, "john", body["Resources"].([]any)[0].(map[string]any)["userName"])
+
+		rec, body = c.request(http.MethodGet, `/scim/v2/Users?filter=nickName+eq+%22jd%22`, "")
+		assertError(t, rec, body, ErrInvalidFilter)
+	})
+
+	t.Run("patch", func(t *testing.T) {
+		rec, body := c.request(http.MethodPatch, "/scim/v2/Users/"+janeID, `{
+			"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+			"Operations": [
+				{"op": "Replace", "path": "active", "value": "False"},
+				{"op": "replace", "path": "emails[type eq \"work\"].value", "value": "jane.doe@example.com"},
+				{"op": "add", "value": {"displayName": "Jane D.", "timezone": "Europe/Istanbul"}},
+				{"op": "remove", "path": "preferredLanguage"}
+			]
+		}`)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Equal(t, false, body["active"])
+		assert.Equal(t, "jane.doe@example.com", body["emails"].([]any)[0].(map[string]any)["value"])
+		assert.Equal(t, "Jane D.", body["displayName"])
+		assert.Equal(t, "Europe/Istanbul", body["timezone"])
+		assert.NotContains(t, body, "preferredLanguage")
+
+		rec, body = c.request(http.MethodGet, `/scim/v2/Users?filter=active+eq+false`, "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.EqualValues(t, 2, body["totalResults"])
+	})
+
+	t.Run("patch rejects invalid operations", func(t *testing.T) {
+		rec, body := c.request(http.MethodPatch, "/scim/v2/Users/"+janeID,
+			`{"Operations":[{"op":"replace","path":"nickName","value":"jd"}]}`)
+		assertError(t, rec, body, ErrInvalidPath)
+
+		rec, body = c.request(http.MethodPatch, "/scim/v2/Users/"+janeID,
+			`{"Operations":[{"op":"remove","path":"userName"}]}`)
+		assertError(t, rec, body, ErrInvalidValue)
+
+		rec, body = c.request(http.MethodPatch, "/scim/v2/Users/"+janeID,
+			`{"Operations":[{"op":"move","path":"userName","value":"x"}]}`)
+		assertError(t, rec, body, ErrInvalidSyntax)
+
+		// a rejected patch leaves the user unchanged
+		_, body = c.request(http.MethodGet, "/scim/v2/Users/"+janeID, "")
+		assert.Equal(t, "jane.doe", body["userName"])
+	})
+
+	t.Run("replace", func(t *testing.T) {
+		rec, body := c.request(http.MethodPut, "/scim/v2/Users/"+janeID,
+			`{"userName":"jane","emails":[{"value":"jane@example.com"}],"active":true}`)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Equal(t, "jane", body["userName"])
+		assert.Equal(t, true, body["active"])
+		assert.NotContains(t, body, "externalId")
+		assert.NotContains(t, body, "timezone")
+
+		rec, body = c.request(http.MethodPut, "/scim/v2/Users/"+janeID,
+			`{"userName":"john","emails":[{"value":"jane@example.com"}]}`)
+		assertError(t, rec, body, ErrUniqueness)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		rec, _ := c.request(http.MethodDelete, "/scim/v2/Users/"+janeID, "")
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+
+		rec, body := c.request(http.MethodGet, "/scim/v2/Users/"+janeID, "")
+		assertError(t, rec, body, ErrNotFound)
+
+		rec, body = c.request(http.MethodDelete, "/scim/v2/Users/"+janeID, "")
+		assertError(t, rec, body, ErrNotFound)
+	})
+}
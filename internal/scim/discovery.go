@@ -0,0 +1,192 @@
+package scim
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v5"
+)
+
+// discoveryMeta is the meta attribute of the discovery resources, which have no timestamps.
+type discoveryMeta struct {
+	ResourceType string `json:"resourceType"`
+	Location     string `json:"location"`
+}
+
+type supported struct {
+	Supported bool `json:"supported"`
+}
+
+type filterSupport struct {
+	Supported  bool `json:"supported"`
+	MaxResults int  `json:"maxResults"`
+}
+
+type bulkSupport struct {
+	Supported      bool `json:"supported"`
+	MaxOperations  int  `json:"maxOperations"`
+	MaxPayloadSize int  `json:"maxPayloadSize"`
+}
+
+type authenticationScheme struct {
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Primary     bool   `json:"primary"`
+}
+
+type serviceProviderConfig struct {
+	Schemas               []string               `json:"schemas"`
+	Patch                 supported              `json:"patch"`
+	Bulk                  bulkSupport            `json:"bulk"`
+	Filter                filterSupport          `json:"filter"`
+	ChangePassword        supported              `json:"changePassword"`
+	Sort                  supported              `json:"sort"`
+	ETag                  supported              `json:"etag"`
+	AuthenticationSchemes []authenticationScheme `json:"authenticationSchemes"`
+	Meta                  discoveryMeta          `json:"meta"`
+}
+
+type resourceType struct {
+	Schemas     []string      `json:"schemas"`
+	ID          string        `json:"id"`
+	Name        string        `json:"name"`
+	Endpoint    string        `json:"endpoint"`
+	Description string        `json:"description"`
+	Schema      string        `json:"schema"`
+	Meta        discoveryMeta `json:"meta"`
+}
+
+type schemaAttribute struct {
+	Name          string            `json:"name"`
+	Type          string            `json:"type"`
+	MultiValued   bool              `json:"multiValued"`
+	Required      bool              `json:"required"`
+	CaseExact     bool              `json:"caseExact"`
+	Mutability    string            `json:"mutability"`
+	Returned      string            `json:"returned"`
+	Uniqueness    string            `json:"uniqueness"`
+	SubAttributes []schemaAttribute `json:"subAttributes,omitempty"`
+}
+
+type schema struct {
+	Schemas     []string          `json:"schemas"`
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Attributes  []schemaAttribute `json:"attributes"`
+	Meta        discoveryMeta     `json:"meta"`
+}
+
+// attr describes a single-valued, read-write attribute that is returned by default.
+func attr(name, kind string, required bool, uniqueness string) schemaAttribute {
+	return schemaAttribute{
+		Name:       name,
+		Type:       kind,
+		Required:   required,
+		Mutability: "readWrite",
+		Returned:   "default",
+		Uniqueness: uniqueness,
+	}
+}
+
+// userAttributes lists the User attributes accounts support.
+var userAttributes = []schemaAttribute{
+	attr("userName", "string", true, "server"),
+	{
+		Name: "name", Type: "complex", Mutability: "readWrite", Returned: "default", Uniqueness: "none",
+		SubAttributes: []schemaAttribute{
+			attr("formatted", "string", false, "none"),
+			attr("givenName", "string", false, "none"),
+			attr("familyName", "string", false, "none"),
+		},
+	},
+	attr("displayName", "string", false, "none"),
+	{
+		Name: "emails", Type: "complex", MultiValued: true, Required: true, Mutability: "readWrite",
+		Returned: "default", Uniqueness: "none",
+		SubAttributes: []schemaAttribute{
+			attr("value", "string", true, "server"),
+			attr("type", "string", false, "none"),
+			attr("primary", "boolean", false, "none"),
+		},
+	},
+	attr("active", "boolean", false, "none"),
+	{
+		Name: "password", Type: "string", Mutability: "writeOnly", Returned: "never", Uniqueness: "none",
+	},
+	attr("preferredLanguage", "string", false, "none"),
+	attr("timezone", "string", false, "none"),
+}
+
+func (h *Handler) ServiceProviderConfig(c *echo.Context) error {
+	root := rootURL(c)
+	return respond(c, http.StatusOK, serviceProviderConfig{
+		Schemas: []string{SchemaServiceProviderConfig},
+		Patch:   supported{Supported: true},
+		Bulk:    bulkSupport{},
+		Filter:  filterSupport{Supported: true, MaxResults: maxCount},
+		AuthenticationSchemes: []authenticationScheme{{
+			Type:        "oauthbearertoken",
+			Name:        "Bearer token",
+			Description: "Authentication with the SCIM bearer token configured on the server",
+			Primary:     true,
+		}},
+		Meta: discoveryMeta{ResourceType: "ServiceProviderConfig", Location: root + "/ServiceProviderConfig"},
+	})
+}
+
+func (h *Handler) ResourceTypes(c *echo.Context) error {
+	return respond(c, http.StatusOK, listOf([]any{userResourceType(rootURL(c))}))
+}
+
+func (h *Handler) ResourceType(c *echo.Context) error {
+	if c.Param("id") != resourceTypeUser {
+		return ErrNotFound
+	}
+	return respond(c, http.StatusOK, userResourceType(rootURL(c)))
+}
+
+func (h *Handler) Schemas(c *echo.Context) error {
+	return respond(c, http.StatusOK, listOf([]any{userSchema(rootURL(c))}))
+}
+
+func (h *Handler) Schema(c *echo.Context) error {
+	if c.Param("id") != SchemaUser {
+		return ErrNotFound
+	}
+	return respond(c, http.StatusOK, userSchema(rootURL(c)))
+}
+
+func userResourceType(root string) resourceType {
+	return resourceType{
+		Schemas:     []string{SchemaResourceType},
+		ID:          resourceTypeUser,
+		Name:        resourceTypeUser,
+		Endpoint:    "/Users",
+		Description: "User account",
+		Schema:      SchemaUser,
+		Meta:        discoveryMeta{ResourceType: "ResourceType", Location: root + "/ResourceTypes/" + resourceTypeUser},
+	}
+}
+
+func userSchema(root string) schema {
+	return schema{
+		Schemas:     []string{SchemaSchema},
+		ID:          SchemaUser,
+		Name:        resourceTypeUser,
+		Description: "User account",
+		Attributes:  userAttributes,
+		Meta:        discoveryMeta{ResourceType: "Schema", Location: root + "/Schemas/" + SchemaUser},
+	}
+}
+
+// listOf wraps discovery resources in a list response, which isn't paginated.
+func listOf(resources []any) map[string]any {
+	return map[string]any{
+		"schemas":      []string{SchemaListResponse},
+		"totalResults": len(resources),
+		"startIndex":   1,
+		"itemsPerPage": len(resources),
+		"Resources":    resources,
+	}
+}
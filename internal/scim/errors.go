@@ -0,0 +1,72 @@
+package scim
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// Error is a SCIM error (RFC 7644 section 3.12). Errors with the same status and scimType match each
+// other with errors.Is, so the sentinels below identify a class of errors whatever their detail.
+type Error struct {
+	Status   int
+	ScimType string
+	Detail   string
+}
+
+func (e *Error) Error() string {
+	if e.ScimType == "" {
+		return "scim: " + e.Detail
+	}
+	return "scim: " + e.ScimType + ": " + e.Detail
+}
+
+func (e *Error) Is(target error) bool {
+	var t *Error
+	return errors.As(target, &t) && t.Status == e.Status && t.ScimType == e.ScimType
+}
+
+var (
+	ErrUnauthorized   = &Error{Status: http.StatusUnauthorized, Detail: "Authentication required"}
+	ErrNotFound       = &Error{Status: http.StatusNotFound, Detail: "Resource not found"}
+	ErrUniqueness     = &Error{Status: http.StatusConflict, ScimType: "uniqueness", Detail: "Value is already in use"}
+	ErrInvalidFilter  = &Error{Status: http.StatusBadRequest, ScimType: "invalidFilter", Detail: "Invalid filter"}
+	ErrInvalidValue   = &Error{Status: http.StatusBadRequest, ScimType: "invalidValue", Detail: "Invalid value"}
+	ErrInvalidPath    = &Error{Status: http.StatusBadRequest, ScimType: "invalidPath", Detail: "Invalid path"}
+	ErrInvalidSyntax  = &Error{Status: http.StatusBadRequest, ScimType: "invalidSyntax", Detail: "Invalid request"}
+	ErrTooMany        = &Error{Status: http.StatusBadRequest, ScimType: "tooMany", Detail: "Too many results"}
+	ErrNotImplemented = &Error{Status: http.StatusNotImplemented, Detail: "Not implemented"}
+)
+
+func invalidFilter(detail string) error {
+	return &Error{Status: ErrInvalidFilter.Status, ScimType: ErrInvalidFilter.ScimType, Detail: detail}
+}
+
+func invalidValue(detail string) error {
+	return &Error{Status: ErrInvalidValue.Status, ScimType: ErrInvalidValue.ScimType, Detail: detail}
+}
+
+func invalidPath(detail string) error {
+	return &Error{Status: ErrInvalidPath.Status, ScimType: ErrInvalidPath.ScimType, Detail: detail}
+}
+
+func invalidSyntax(detail string) error {
+	return &Error{Status: ErrInvalidSyntax.Status, ScimType: ErrInvalidSyntax.ScimType, Detail: detail}
+}
+
+// errorResponse is the body of a SCIM error response; status is a string per the spec.
+type errorResponse struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+}
+
+func (e *Error) response() errorResponse {
+	return errorResponse{
+		Schemas:  []string{SchemaError},
+		Status:   strconv.Itoa(e.Status),
+		ScimType: e.ScimType,
+		Detail:   e.Detail,
+	}
+}
@@ -0,0 +1,502 @@
+package scim
+
+import (
+	"encoding/json"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Filter is a parsed SCIM filter expression (RFC 7644 section 3.4.2.2). It compiles to a SQL condition
+// for the Postgres store and evaluates directly for the in-memory one.
+type Filter interface {
+	// sql returns the condition, appending its arguments to args.
+	sql(args *[]any) string
+	match(r *Record) bool
+}
+
+type attrKind int
+
+const (
+	kindString attrKind = iota
+	kindBool
+	kindTime
+)
+
+// attribute is a User attribute filters can reference. For strings and times expr is the column, for
+// booleans it is a condition that holds when the attribute is true.
+type attribute struct {
+	expr      string
+	kind      attrKind
+	caseExact bool
+	value     func(r *Record) any
+}
+
+var filterAttributes = map[string]attribute{
+	"id": {expr: "id::text", kind: kindString, caseExact: true, value: func(r *Record) any {
+		return r.ID.String()
+	}},
+	"externalid": {expr: "external_id", kind: kindString, caseExact: true, value: func(r *Record) any {
+		return r.ExternalID
+	}},
+	"username":       {expr: "username", kind: kindString, value: func(r *Record) any { return r.Username }},
+	"displayname":    {expr: "name", kind: kindString, value: func(r *Record) any { return r.Name }},
+	"name.formatted": {expr: "name", kind: kindString, value: func(r *Record) any { return r.Name }},
+	"emails":         {expr: "email", kind: kindString, value: func(r *Record) any { return r.Email }},
+	"emails.value":   {expr: "email", kind: kindString, value: func(r *Record) any { return r.Email }},
+	// accounts have a single email, which is the primary work email
+	"emails.type": {expr: "'" + emailTypeWork + "'", kind: kindString, value: func(*Record) any {
+		return emailTypeWork
+	}},
+	"emails.primary": {expr: "TRUE", kind: kindBool, value: func(*Record) any { return true }},
+	"active": {expr: "status = 'active'", kind: kindBool, value: func(r *Record) any {
+		return r.Status == "active"
+	}},
+	"preferredlanguage": {expr: "language", kind: kindString, value: func(r *Record) any { return r.Language }},
+	"timezone":          {expr: "timezone", kind: kindString, value: func(r *Record) any { return r.Timezone }},
+	"meta.created":      {expr: "created_at", kind: kindTime, value: func(r *Record) any { return r.CreatedAt }},
+	"meta.lastmodified": {expr: "updated_at", kind: kindTime, value: func(r *Record) any { return r.UpdatedAt }},
+}
+
+// lookupAttribute resolves an attribute path, optionally qualified with the User schema URN.
+func lookupAttribute(path string) (attribute, bool) {
+	path = strings.ToLower(path)
+	path = strings.TrimPrefix(path, strings.ToLower(SchemaUser)+":")
+	attr, ok := filterAttributes[path]
+	return attr, ok
+}
+
+// ParseFilter parses a filter expression.
+//
+// Returns:
+// - ErrInvalidFilter if the expression is malformed or uses an unsupported attribute or operator
+func ParseFilter(expression string) (Filter, error) {
+	tokens, err := tokenize(expression)
+	if err != nil {
+		return nil, err
+	}
+	p := &filterParser{tokens: tokens}
+	filter, err := p.parseOr("")
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, invalidFilter("unexpected " + strconv.Quote(p.tokens[p.pos].text))
+	}
+	return filter, nil
+}
+
+type tokenKind int
+
+const (
+	tokenWord tokenKind = iota
+	tokenString
+	tokenOpen
+	tokenClose
+	tokenOpenBracket
+	tokenCloseBracket
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+func tokenize(s string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(s); {
+		switch c := s[i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{tokenOpen, "("})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{tokenClose, ")"})
+			i++
+		case c == '[':
+			tokens = append(tokens, token{tokenOpenBracket, "["})
+			i++
+		case c == ']':
+			tokens = append(tokens, token{tokenCloseBracket, "]"})
+			i++
+		case c == '"':
+			end := i + 1
+			for end < len(s) && s[end] != '"' {
+				if s[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(s) {
+				return nil, invalidFilter("unterminated string")
+			}
+			var value string
+			if err := json.Unmarshal([]byte(s[i:end+1]), &value); err != nil {
+				return nil, invalidFilter("invalid string " + s[i:end+1])
+			}
+			tokens = append(tokens, token{tokenString, value})
+			i = end + 1
+		default:
+			end := i
+			for end < len(s) && !strings.ContainsRune(" \t\n\r()[]\"", rune(s[end])) {
+				end++
+			}
+			tokens = append(tokens, token{tokenWord, s[i:end]})
+			i = end
+		}
+	}
+	if len(tokens) == 0 {
+		return nil, invalidFilter("empty filter")
+	}
+	return tokens, nil
+}
+
+type filterParser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *filterParser) peek() (token, bool) {
+	if p.pos >= len(p.tokens) {
+		return token{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *filterParser) next() (token, error) {
+	t, ok := p.peek()
+	if !ok {
+		return token{}, invalidFilter("unexpected end of filter")
+	}
+	p.pos++
+	return t, nil
+}
+
+// keyword reports whether the next token is the case-insensitive keyword and consumes it.
+func (p *filterParser) keyword(keyword string) bool {
+	if t, ok := p.peek(); ok && t.kind == tokenWord && strings.EqualFold(t.text, keyword) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *filterParser) expect(kind tokenKind, text string) error {
+	t, err := p.next()
+	if err != nil {
+		return err
+	}
+	if t.kind != kind {
+		return invalidFilter("expected " + text + ", got " + strconv.Quote(t.text))
+	}
+	return nil
+}
+
+// parseOr parses a whole expression; prefix qualifies attributes inside a value path like emails[...].
+func (p *filterParser) parseOr(prefix string) (Filter, error) {
+	left, err := p.parseAnd(prefix)
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("or") {
+		right, err := p.parseAnd(prefix)
+		if err != nil {
+			return nil, err
+		}
+		left = logicalFilter{and: false, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd(prefix string) (Filter, error) {
+	left, err := p.parseUnary(prefix)
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("and") {
+		right, err := p.parseUnary(prefix)
+		if err != nil {
+			return nil, err
+		}
+		left = logicalFilter{and: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseUnary(prefix string) (Filter, error) {
+	if p.keyword("not") {
+		if err := p.expect(tokenOpen, "("); err != nil {
+			return nil, err
+		}
+		inner, err := p.parseGroup(prefix)
+		if err != nil {
+			return nil, err
+		}
+		return notFilter{inner}, nil
+	}
+	if t, ok := p.peek(); ok && t.kind == tokenOpen {
+		p.pos++
+		return p.parseGroup(prefix)
+	}
+	return p.parseComparison(prefix)
+}
+
+// parseGroup parses the rest of a parenthesized expression after its opening parenthesis.
+func (p *filterParser) parseGroup(prefix string) (Filter, error) {
+	inner, err := p.parseOr(prefix)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(tokenClose, ")"); err != nil {
+		return nil, err
+	}
+	return inner, nil
+}
+
+func (p *filterParser) parseComparison(prefix string) (Filter, error) {
+	t, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	if t.kind != tokenWord {
+		return nil, invalidFilter("expected attribute, got " + strconv.Quote(t.text))
+	}
+	path := prefix + t.text
+
+	if next, ok := p.peek(); ok && next.kind == tokenOpenBracket {
+		if prefix != "" {
+			return nil, invalidFilter("nested value paths are not supported")
+		}
+		p.pos++
+		inner, err := p.parseOr(path + ".")
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokenCloseBracket, "]"); err != nil {
+			return nil, err
+		}
+		return inner, nil
+	}
+
+	attr, ok := lookupAttribute(path)
+	if !ok {
+		return nil, invalidFilter("unsupported attribute " + strconv.Quote(path))
+	}
+
+	opToken, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	op := strings.ToLower(opToken.text)
+	if opToken.kind != tokenWord {
+		return nil, invalidFilter("expected operator, got " + strconv.Quote(opToken.text))
+	}
+	if op == "pr" {
+		return comparison{attr: attr, op: op}, nil
+	}
+
+	valueToken, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	value, err := comparisonValue(attr, op, valueToken)
+	if err != nil {
+		return nil, err
+	}
+	return comparison{attr: attr, op: op, value: value}, nil
+}
+
+// comparisonValue validates the operator for the attribute's type and converts the literal.
+func comparisonValue(attr attribute, op string, t token) (any, error) {
+	switch attr.kind {
+	case kindString:
+		if !slices.Contains([]string{"eq", "ne", "co", "sw", "ew", "gt", "ge", "lt", "le"}, op) {
+			return nil, invalidFilter("unsupported operator " + strconv.Quote(op))
+		}
+		if t.kind != tokenString {
+			return nil, invalidFilter("expected a string, got " + strconv.Quote(t.text))
+		}
+		return t.text, nil
+	case kindBool:
+		if op != "eq" && op != "ne" {
+			return nil, invalidFilter("unsupported operator " + strconv.Quote(op) + " for a boolean")
+		}
+		value, err := strconv.ParseBool(strings.ToLower(t.text))
+		if t.kind != tokenWord || err != nil {
+			return nil, invalidFilter("expected true or false, got " + strconv.Quote(t.text))
+		}
+		return value, nil
+	case kindTime:
+		if !slices.Contains([]string{"eq", "ne", "gt", "ge", "lt", "le"}, op) {
+			return nil, invalidFilter("unsupported operator " + strconv.Quote(op) + " for a date")
+		}
+		value, err := time.Parse(time.RFC3339Nano, t.text)
+		if t.kind != tokenString || err != nil {
+			return nil, invalidFilter("expected an RFC 3339 date, got " + strconv.Quote(t.text))
+		}
+		return value, nil
+	default:
+		return nil, invalidFilter("unsupported attribute type")
+	}
+}
+
+type logicalFilter struct {
+	and         bool
+	left, right Filter
+}
+
+func (f logicalFilter) sql(args *[]any) string {
+	op := " OR "
+	if f.and {
+		op = " AND "
+	}
+	return "(" + f.left.sql(args) + op + f.right.sql(args) + ")"
+}
+
+func (f logicalFilter) match(r *Record) bool {
+	if f.and {
+		return f.left.match(r) && f.right.match(r)
+	}
+	return f.left.match(r) || f.right.match(r)
+}
+
+type notFilter struct {
+	inner Filter
+}
+
+func (f notFilter) sql(args *[]any) string {
+	return "NOT " + f.inner.sql(args)
+}
+
+func (f notFilter) match(r *Record) bool {
+	return !f.inner.match(r)
+}
+
+type comparison struct {
+	attr  attribute
+	op    string
+	value any
+}
+
+var sqlOperators = map[string]string{"eq": "=", "gt": ">", "ge": ">=", "lt": "<", "le": "<="}
+
+func (f comparison) sql(args *[]any) string {
+	placeholder := func(value any) string {
+		*args = append(*args, value)
+		return "$" + strconv.Itoa(len(*args))
+	}
+
+	switch f.attr.kind {
+	case kindBool:
+		if f.op == "pr" {
+			return "TRUE"
+		}
+		value, _ := f.value.(bool)
+		if value == (f.op == "eq") {
+			return "(" + f.attr.expr + ")"
+		}
+		return "NOT (" + f.attr.expr + ")"
+	case kindTime:
+		if f.op == "pr" {
+			return "(" + f.attr.expr + " IS NOT NULL)"
+		}
+		if f.op == "ne" {
+			return "(" + f.attr.expr + " IS DISTINCT FROM " + placeholder(f.value) + ")"
+		}
+		return "(" + f.attr.expr + " " + sqlOperators[f.op] + " " + placeholder(f.value) + ")"
+	case kindString:
+	}
+
+	column := f.attr.expr
+	value, _ := f.value.(string)
+	like := "ILIKE"
+	if f.attr.caseExact {
+		like = "LIKE"
+	} else {
+		column = "LOWER(" + column + ")"
+		value = strings.ToLower(value)
+	}
+
+	switch f.op {
+	case "pr":
+		return "(" + f.attr.expr + " IS NOT NULL AND " + f.attr.expr + " <> '')"
+	case "ne":
+		return "(" + column + " IS DISTINCT FROM " + placeholder(value) + ")"
+	case "co":
+		return "(" + f.attr.expr + " " + like + " " + placeholder("%"+escapeLike(value)+"%") + ")"
+	case "sw":
+		return "(" + f.attr.expr + " " + like + " " + placeholder(escapeLike(value)+"%") + ")"
+	case "ew":
+		return "(" + f.attr.expr + " " + like + " " + placeholder("%"+escapeLike(value)) + ")"
+	default:
+		return "(" + column + " " + sqlOperators[f.op] + " " + placeholder(value) + ")"
+	}
+}
+
+func (f comparison) match(r *Record) bool {
+	actual := f.attr.value(r)
+	if s, ok := actual.(*string); ok {
+		if s == nil {
+			actual = nil
+		} else {
+			actual = *s
+		}
+	}
+
+	switch v := actual.(type) {
+	case nil:
+		return f.op == "ne"
+	case bool:
+		if f.op == "pr" {
+			return true
+		}
+		return (v == f.value) == (f.op == "eq")
+	case time.Time:
+		return f.op == "pr" || compareOrdered(v.Compare(f.value.(time.Time)), f.op)
+	case string:
+		if f.op == "pr" {
+			return v != ""
+		}
+		want, _ := f.value.(string)
+		if !f.attr.caseExact {
+			v, want = strings.ToLower(v), strings.ToLower(want)
+		}
+		switch f.op {
+		case "co":
+			return strings.Contains(v, want)
+		case "sw":
+			return strings.HasPrefix(v, want)
+		case "ew":
+			return strings.HasSuffix(v, want)
+		default:
+			return compareOrdered(strings.Compare(v, want), f.op)
+		}
+	default:
+		return false
+	}
+}
+
+func compareOrdered(cmp int, op string) bool {
+	switch op {
+	case "eq":
+		return cmp == 0
+	case "ne":
+		return cmp != 0
+	case "gt":
+		return cmp > 0
+	case "ge":
+		return cmp >= 0
+	case "lt":
+		return cmp < 0
+	case "le":
+		return cmp <= 0
+	default:
+		return false
+	}
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
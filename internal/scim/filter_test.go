@@ -0,0 +1,130 @@
+package scim
+
+import (
+	"testing"
+	"time"
+
+	"monolith/internal/account"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFilter(t *testing.T) {
+	created := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+	record := &Record{
+		Account: account.Account{
+			ID:        uuid.MustParse("6f1c1a7e-3d4b-4c59-9d1e-8f8b1c2d3e4f"),
+			Username:  "Jane.Doe",
+			Email:     "jane@example.com",
+			Name:      new("Jane Doe"),
+			Status:    "active",
+			CreatedAt: created,
+			UpdatedAt: created,
+		},
+		ExternalID: new("ext-1"),
+	}
+
+	tests := []struct {
+		name      string
+		filter    string
+		wantSQL   string
+		wantArgs  []any
+		wantMatch bool
+		wantErr   error
+	}{
+		{
+			name:      "userName eq is case-insensitive",
+			filter:    `userName eq "jane.doe"`,
+			wantSQL:   `(LOWER(username) = $1)`,
+			wantArgs:  []any{"jane.doe"},
+			wantMatch: true,
+		},
+		{
+			name:      "schema qualified attribute",
+			filter:    `urn:ietf:params:scim:schemas:core:2.0:User:userName eq "Jane.Doe"`,
+			wantSQL:   `(LOWER(username) = $1)`,
+			wantArgs:  []any{"jane.doe"},
+			wantMatch: true,
+		},
+		{
+			name:      "externalId eq is case-sensitive",
+			filter:    `externalId eq "EXT-1"`,
+			wantSQL:   `(external_id = $1)`,
+			wantArgs:  []any{"EXT-1"},
+			wantMatch: false,
+		},
+		{
+			name:      "co escapes wildcards",
+			filter:    `emails co "100%_"`,
+			wantSQL:   `(email ILIKE $1)`,
+			wantArgs:  []any{`%100\%\_%`},
+			wantMatch: false,
+		},
+		{
+			name:   "value path with and, or and not",
+			filter: `emails[type eq "work" and value sw "JANE"] and not (displayName ew "smith" or active eq false)`,
+			wantSQL: `(((LOWER('work') = $1) AND (email ILIKE $2)) AND ` +
+				`NOT ((name ILIKE $3) OR NOT (status = 'active')))`,
+			wantArgs:  []any{"work", "jane%", "%smith"},
+			wantMatch: true,
+		},
+		{
+			name:      "ne matches a missing attribute",
+			filter:    `preferredLanguage ne "en"`,
+			wantSQL:   `(LOWER(language) IS DISTINCT FROM $1)`,
+			wantArgs:  []any{"en"},
+			wantMatch: true,
+		},
+		{
+			name:      "present",
+			filter:    `timezone pr`,
+			wantSQL:   `(timezone IS NOT NULL AND timezone <> '')`,
+			wantMatch: false,
+		},
+		{
+			name:      "dates compare as timestamps",
+			filter:    `meta.lastModified gt "2026-01-01T00:00:00Z"`,
+			wantSQL:   `(updated_at > $1)`,
+			wantArgs:  []any{time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)},
+			wantMatch: true,
+		},
+		{
+			name:    "unsupported attribute",
+			filter:  `nickName eq "jd"`,
+			wantErr: ErrInvalidFilter,
+		},
+		{
+			name:    "unsupported operator for a boolean",
+			filter:  `active gt true`,
+			wantErr: ErrInvalidFilter,
+		},
+		{
+			name:    "unbalanced parentheses",
+			filter:  `(userName eq "jane"`,
+			wantErr: ErrInvalidFilter,
+		},
+		{
+			name:    "trailing tokens",
+			filter:  `userName eq "jane" "doe"`,
+			wantErr: ErrInvalidFilter,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := ParseFilter(tt.filter)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			var args []any
+			assert.Equal(t, tt.wantSQL, filter.sql(&args))
+			assert.Equal(t, tt.wantArgs, args)
+			assert.Equal(t, tt.wantMatch, filter.match(record))
+		})
+	}
+}
@@ -0,0 +1,266 @@
+package scim
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"monolith/internal/logger"
+
+	"github.com/labstack/echo/v5"
+)
+
+const (
+	defaultCount = 100
+	// maxCount caps the page size of a list, as advertised in the ServiceProviderConfig.
+	maxCount = 200
+)
+
+// Handler serves the SCIM endpoints over a Store.
+type Handler struct {
+	store Store
+}
+
+func NewHandler(store Store) *Handler {
+	return &Handler{store: store}
+}
+
+// Mount registers the SCIM endpoints on g, authenticated with the bearer token.
+func Mount(g *echo.Group, store Store, token string) {
+	h := NewHandler(store)
+	g.Use(ErrorResponses(), BearerAuth(token))
+
+	g.GET("/Users", h.ListUsers)
+	g.POST("/Users", h.CreateUser)
+	g.GET("/Users/:id", h.GetUser)
+	g.PUT("/Users/:id", h.ReplaceUser)
+	g.PATCH("/Users/:id", h.PatchUser)
+	g.DELETE("/Users/:id", h.DeleteUser)
+
+	g.GET("/ServiceProviderConfig", h.ServiceProviderConfig)
+	g.GET("/ResourceTypes", h.ResourceTypes)
+	g.GET("/ResourceTypes/:id", h.ResourceType)
+	g.GET("/Schemas", h.Schemas)
+	g.GET("/Schemas/:id", h.Schema)
+}
+
+// BearerAuth rejects requests that don't carry token as a bearer token. Identity providers are
+// configured with a dedicated token rather than a user session.
+func BearerAuth(token string) echo.MiddlewareFunc {
+	want := sha256.Sum256([]byte(token))
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c *echo.Context) error {
+			header := c.Request().Header.Get(echo.HeaderAuthorization)
+			scheme, credentials, ok := strings.Cut(header, " ")
+			got := sha256.Sum256([]byte(credentials))
+			if !ok || !strings.EqualFold(scheme, "Bearer") || subtle.ConstantTimeCompare(got[:], want[:]) != 1 {
+				c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer realm="scim"`)
+				return ErrUnauthorized
+			}
+			return next(c)
+		}
+	}
+}
+
+// ErrorResponses renders errors as SCIM error bodies instead of the problem details the rest of the API
+// uses. Errors other than *Error keep their HTTP status; internal errors are logged and their cause
+// isn't sent.
+func ErrorResponses() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c *echo.Context) error {
+			err := next(c)
+			if err == nil {
+				return nil
+			}
+			if resp, _ := echo.UnwrapResponse(c.Response()); resp != nil && resp.Committed {
+				return err
+			}
+
+			var scimErr *Error
+			if !errors.As(err, &scimErr) {
+				status := echo.StatusCode(err)
+				if status == 0 {
+					status = http.StatusInternalServerError
+				}
+				scimErr = &Error{Status: status, Detail: http.StatusText(status)}
+			}
+			if scimErr.Status >= http.StatusInternalServerError {
+				ctx := c.Request().Context()
+				logger.FromContext(ctx).ErrorContext(ctx, "SCIM request failed", "status", scimErr.Status, "error", err)
+			}
+			return respond(c, scimErr.Status, scimErr.response())
+		}
+	}
+}
+
+// ListUsers returns a page of users, optionally filtered. startIndex is 1-based.
+func (h *Handler) ListUsers(c *echo.Context) error {
+	startIndex, err := intParam(c, "startIndex", 1)
+	if err != nil {
+		return err
+	}
+	count, err := intParam(c, "count", defaultCount)
+	if err != nil {
+		return err
+	}
+	startIndex = max(startIndex, 1)
+	count = min(max(count, 0), maxCount)
+
+	var filter Filter
+	if expression := c.QueryParam("filter"); expression != "" {
+		if filter, err = ParseFilter(expression); err != nil {
+			return err
+		}
+	}
+
+	records, total, err := h.store.List(c.Request().Context(), filter, startIndex-1, count)
+	if err != nil {
+		return err
+	}
+
+	base := usersURL(c)
+	users := make([]User, 0, len(records))
+	for i := range records {
+		users = append(users, toUser(&records[i], base+"/"+records[i].ID.String()))
+	}
+	return respond(c, http.StatusOK, ListResponse{
+		Schemas:      []string{SchemaListResponse},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(users),
+		Resources:    users,
+	})
+}
+
+func (h *Handler) GetUser(c *echo.Context) error {
+	record, err := h.record(c)
+	if err != nil {
+		return err
+	}
+	return respond(c, http.StatusOK, toUser(record, usersURL(c)+"/"+record.ID.String()))
+}
+
+// CreateUser provisions an account. Users without a password can only sign in through the identity provider.
+func (h *Handler) CreateUser(c *echo.Context) error {
+	var user User
+	if err := decode(c, &user); err != nil {
+		return err
+	}
+
+	var record Record
+	if err := applyUser(&record, &user); err != nil {
+		return err
+	}
+	if err := h.store.Create(c.Request().Context(), &record); err != nil {
+		return err
+	}
+
+	location := usersURL(c) + "/" + record.ID.String()
+	c.Response().Header().Set(echo.HeaderLocation, location)
+	return respond(c, http.StatusCreated, toUser(&record, location))
+}
+
+// ReplaceUser replaces every attribute of a user; omitted optional attributes are cleared.
+func (h *Handler) ReplaceUser(c *echo.Context) error {
+	record, err := h.record(c)
+	if err != nil {
+		return err
+	}
+	var user User
+	if err := decode(c, &user); err != nil {
+		return err
+	}
+
+	if err := applyUser(record, &user); err != nil {
+		return err
+	}
+	return h.update(c, record)
+}
+
+func (h *Handler) PatchUser(c *echo.Context) error {
+	record, err := h.record(c)
+	if err != nil {
+		return err
+	}
+	var req PatchRequest
+	if err := decode(c, &req); err != nil {
+		return err
+	}
+
+	if err := applyPatch(record, &req); err != nil {
+		return err
+	}
+	return h.update(c, record)
+}
+
+// DeleteUser deletes the account and revokes its sessions.
+func (h *Handler) DeleteUser(c *echo.Context) error {
+	id, err := parseID(c.Param("id"))
+	if err != nil {
+		return err
+	}
+	if err := h.store.Delete(c.Request().Context(), id); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *Handler) record(c *echo.Context) (*Record, error) {
+	id, err := parseID(c.Param("id"))
+	if err != nil {
+		return nil, err
+	}
+	return h.store.Get(c.Request().Context(), id)
+}
+
+func (h *Handler) update(c *echo.Context, record *Record) error {
+	if err := h.store.Update(c.Request().Context(), record); err != nil {
+		return err
+	}
+	return respond(c, http.StatusOK, toUser(record, usersURL(c)+"/"+record.ID.String()))
+}
+
+// rootURL returns the absolute URL the SCIM endpoints are mounted at.
+func rootURL(c *echo.Context) string {
+	path := c.Request().URL.Path
+	for _, endpoint := range []string{"/Users", "/ServiceProviderConfig", "/ResourceTypes", "/Schemas"} {
+		if i := strings.Index(path, endpoint); i >= 0 {
+			path = path[:i]
+			break
+		}
+	}
+	return c.Scheme() + "://" + c.Request().Host + path
+}
+
+func usersURL(c *echo.Context) string {
+	return rootURL(c) + "/Users"
+}
+
+func intParam(c *echo.Context, name string, fallback int) (int, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, invalidValue(name + " must be an integer")
+	}
+	return n, nil
+}
+
+// decode reads a JSON body. SCIM clients send application/scim+json, which echo's binder doesn't accept.
+func decode(c *echo.Context, v any) error {
+	if err := json.NewDecoder(c.Request().Body).Decode(v); err != nil {
+		return invalidSyntax("Request body is not valid JSON")
+	}
+	return nil
+}
+
+func respond(c *echo.Context, status int, body any) error {
+	c.Response().Header().Set(echo.HeaderContentType, MIMEApplicationSCIMJSON)
+	return c.JSON(status, body)
+}
@@ -0,0 +1,121 @@
+package scim
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type memoryStore struct {
+	mu      sync.Mutex
+	records []*Record
+}
+
+// NewMemoryStore returns a Store that keeps records in memory, for tests and local development.
+func NewMemoryStore() Store {
+	return &memoryStore{}
+}
+
+func (s *memoryStore) List(_ context.Context, filter Filter, offset, limit int) ([]Record, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var matched []Record
+	for _, r := range s.records {
+		if filter == nil || filter.match(r) {
+			matched = append(matched, *r)
+		}
+	}
+	total := len(matched)
+	start := min(offset, total)
+	end := min(start+max(limit, 0), total)
+	return slices.Clone(matched[start:end]), total, nil
+}
+
+func (s *memoryStore) Get(_ context.Context, id uuid.UUID) (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r := s.find(id)
+	if r == nil {
+		return nil, ErrNotFound
+	}
+	record := *r
+	return &record, nil
+}
+
+func (s *memoryStore) Create(_ context.Context, r *Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conflicts(r) {
+		return ErrUniqueness
+	}
+	r.ID = uuid.New()
+	r.CreatedAt = time.Now()
+	r.UpdatedAt = r.CreatedAt
+	record := *r
+	s.records = append(s.records, &record)
+	return nil
+}
+
+func (s *memoryStore) Update(_ context.Context, r *Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing := s.find(r.ID)
+	if existing == nil {
+		return ErrNotFound
+	}
+	if s.conflicts(r) {
+		return ErrUniqueness
+	}
+	if r.Password == "" {
+		r.Password = existing.Password
+	}
+	r.UpdatedAt = time.Now()
+	*existing = *r
+	return nil
+}
+
+func (s *memoryStore) Delete(_ context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, r := range s.records {
+		if r.ID == id {
+			s.records = slices.Delete(s.records, i, i+1)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (s *memoryStore) find(id uuid.UUID) *Record {
+	for _, r := range s.records {
+		if r.ID == id {
+			return r
+		}
+	}
+	return nil
+}
+
+// conflicts reports whether another record has r's username, email or external ID, matching the
+// unique constraints of the account table.
+func (s *memoryStore) conflicts(r *Record) bool {
+	for _, other := range s.records {
+		if other.ID == r.ID {
+			continue
+		}
+		if other.Username == r.Username || other.Email == r.Email {
+			return true
+		}
+		if r.ExternalID != nil && other.ExternalID != nil && *r.ExternalID == *other.ExternalID {
+			return true
+		}
+	}
+	return false
+}
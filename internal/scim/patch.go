@@ -0,0 +1,173 @@
+package scim
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// PatchRequest is a PATCH request body (RFC 7644 section 3.5.2).
+type PatchRequest struct {
+	Schemas    []string         `json:"schemas"`
+	Operations []PatchOperation `json:"Operations"`
+}
+
+// PatchOperation is one operation of a PatchRequest. Op is matched case-insensitively since some
+// identity providers send "Replace" rather than "replace".
+type PatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// emailValuePath matches the value of a filtered email, like emails[type eq "work"].value. Accounts
+// have a single email, so every filter selects it.
+var emailValuePath = regexp.MustCompile(`^emails\[[^\]]*\]\.value$`)
+
+// applyPatch applies the operations of req to r in order. Either every operation applies or r is left
+// unchanged.
+//
+// Returns:
+// - ErrInvalidSyntax if an operation is malformed
+// - ErrInvalidPath if an operation targets an attribute that doesn't exist or can't be changed
+// - ErrInvalidValue if a value has the wrong type or a required attribute is removed
+func applyPatch(r *Record, req *PatchRequest) error {
+	if len(req.Operations) == 0 {
+		return invalidSyntax("Operations is required")
+	}
+
+	user := toUser(r, "")
+	for i, op := range req.Operations {
+		kind := strings.ToLower(op.Op)
+		if kind != "add" && kind != "replace" && kind != "remove" {
+			return invalidSyntax("Operations[" + strconv.Itoa(i) + "] has unsupported op " + strconv.Quote(op.Op))
+		}
+
+		if op.Path != "" {
+			if err := applyPatchPath(&user, op.Path, kind == "remove", op.Value); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// without a path the value is an object of attributes to set
+		if kind == "remove" {
+			return invalidPath("Operations[" + strconv.Itoa(i) + "] remove requires a path")
+		}
+		var attributes map[string]json.RawMessage
+		if err := json.Unmarshal(op.Value, &attributes); err != nil {
+			return invalidValue("Operations[" + strconv.Itoa(i) + "] value must be an object")
+		}
+		for path, value := range attributes {
+			if strings.EqualFold(path, "schemas") {
+				continue
+			}
+			if err := applyPatchPath(&user, path, false, value); err != nil {
+				return err
+			}
+		}
+	}
+
+	patched := *r
+	if err := applyUser(&patched, &user); err != nil {
+		return err
+	}
+	*r = patched
+	return nil
+}
+
+// applyPatchPath sets or, when remove is set, clears the attribute at path.
+func applyPatchPath(u *User, path string, remove bool, value json.RawMessage) error {
+	normalized := strings.ToLower(path)
+	normalized = strings.TrimPrefix(normalized, strings.ToLower(SchemaUser)+":")
+
+	var text string
+	if !remove && normalized != "name" && normalized != "emails" && normalized != "active" {
+		if err := json.Unmarshal(value, &text); err != nil {
+			return invalidValue(path + " must be a string")
+		}
+	}
+
+	switch {
+	case normalized == "username":
+		u.UserName = text
+	case normalized == "displayname" || normalized == "name.formatted":
+		u.DisplayName = text
+		u.Name = nil
+	case normalized == "name.givenname" || normalized == "name.familyname":
+		name := Name{}
+		if u.Name != nil {
+			name = *u.Name
+		}
+		if normalized == "name.givenname" {
+			name.GivenName = text
+		} else {
+			name.FamilyName = text
+		}
+		name.Formatted = ""
+		u.Name = &name
+		u.DisplayName = ""
+	case normalized == "name":
+		u.Name = nil
+		u.DisplayName = ""
+		if !remove {
+			var name Name
+			if err := json.Unmarshal(value, &name); err != nil {
+				return invalidValue("name must be an object")
+			}
+			u.Name = &name
+		}
+	case normalized == "emails":
+		u.Emails = nil
+		if !remove {
+			if err := json.Unmarshal(value, &u.Emails); err != nil {
+				return invalidValue("emails must be an array")
+			}
+		}
+	case normalized == "emails.value" || emailValuePath.MatchString(normalized):
+		u.Emails = nil
+		if !remove {
+			u.Emails = []Email{{Value: text, Type: emailTypeWork, Primary: true}}
+		}
+	case normalized == "active":
+		u.Active = nil
+		if !remove {
+			active, err := boolValue(value)
+			if err != nil {
+				return err
+			}
+			u.Active = &active
+		}
+	case normalized == "externalid":
+		u.ExternalID = text
+	case normalized == "preferredlanguage":
+		u.PreferredLanguage = text
+	case normalized == "timezone":
+		u.Timezone = text
+	case normalized == "password":
+		if remove {
+			return invalidPath("password can't be removed")
+		}
+		u.Password = text
+	default:
+		return invalidPath("unsupported path " + strconv.Quote(path))
+	}
+	return nil
+}
+
+// boolValue decodes a boolean, also accepting the strings "True" and "False" some identity providers
+// send instead.
+func boolValue(value json.RawMessage) (bool, error) {
+	var b bool
+	if err := json.Unmarshal(value, &b); err == nil {
+		return b, nil
+	}
+	var s string
+	if err := json.Unmarshal(value, &s); err == nil {
+		if b, err := strconv.ParseBool(strings.ToLower(s)); err == nil {
+			return b, nil
+		}
+	}
+	return false, invalidValue("active must be a boolean")
+}
@@ -0,0 +1,202 @@
+// Package scim implements a SCIM 2.0 (RFC 7643, RFC 7644) service provider for provisioning accounts
+// from an identity provider.
+package scim
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"monolith/internal/account"
+
+	"github.com/google/uuid"
+)
+
+// MIMEApplicationSCIMJSON is the media type of SCIM requests and responses.
+const MIMEApplicationSCIMJSON = "application/scim+json"
+
+const (
+	SchemaUser                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SchemaListResponse          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	SchemaPatchOp               = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	SchemaError                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+	SchemaServiceProviderConfig = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	SchemaResourceType          = "urn:ietf:params:scim:schemas:core:2.0:ResourceType"
+	SchemaSchema                = "urn:ietf:params:scim:schemas:core:2.0:Schema"
+)
+
+const (
+	resourceTypeUser = "User"
+	emailTypeWork    = "work"
+)
+
+// User is the SCIM representation of an account. Active maps to the account status: active accounts are
+// active, every other status is inactive.
+type User struct {
+	Schemas           []string `json:"schemas"`
+	ID                string   `json:"id,omitempty"`
+	ExternalID        string   `json:"externalId,omitempty"`
+	UserName          string   `json:"userName"`
+	Name              *Name    `json:"name,omitempty"`
+	DisplayName       string   `json:"displayName,omitempty"`
+	Emails            []Email  `json:"emails,omitempty"`
+	Active            *bool    `json:"active,omitempty"`
+	Password          string   `json:"password,omitempty"`
+	PreferredLanguage string   `json:"preferredLanguage,omitempty"`
+	Timezone          string   `json:"timezone,omitempty"`
+	Meta              *Meta    `json:"meta,omitempty"`
+}
+
+type Name struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+type Email struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+type Meta struct {
+	ResourceType string    `json:"resourceType"`
+	Created      time.Time `json:"created"`
+	LastModified time.Time `json:"lastModified"`
+	Location     string    `json:"location,omitempty"`
+	Version      string    `json:"version,omitempty"`
+}
+
+// ListResponse is a page of query results. StartIndex is 1-based.
+type ListResponse struct {
+	Schemas      []string `json:"schemas"`
+	TotalResults int      `json:"totalResults"`
+	StartIndex   int      `json:"startIndex"`
+	ItemsPerPage int      `json:"itemsPerPage"`
+	Resources    []User   `json:"Resources"`
+}
+
+// Record is an account as stored for SCIM, with the identity provider's external ID.
+type Record struct {
+	account.Account
+	ExternalID *string
+}
+
+// toUser renders r; location is the URL of the resource.
+func toUser(r *Record, location string) User {
+	active := r.Status == "active"
+	user := User{
+		Schemas:  []string{SchemaUser},
+		ID:       r.ID.String(),
+		UserName: r.Username,
+		Emails:   []Email{{Value: r.Email, Type: emailTypeWork, Primary: true}},
+		Active:   &active,
+		Meta: &Meta{
+			ResourceType: resourceTypeUser,
+			Created:      r.CreatedAt,
+			LastModified: r.UpdatedAt,
+			Location:     location,
+			Version:      `W/"` + strconv.FormatInt(r.UpdatedAt.UnixNano(), 10) + `"`,
+		},
+	}
+	if r.ExternalID != nil {
+		user.ExternalID = *r.ExternalID
+	}
+	if r.Name != nil {
+		user.Name = &Name{Formatted: *r.Name}
+		user.DisplayName = *r.Name
+	}
+	if r.Language != nil {
+		user.PreferredLanguage = *r.Language
+	}
+	if r.Timezone != nil {
+		user.Timezone = *r.Timezone
+	}
+	return user
+}
+
+// applyUser copies the attributes of u onto r, as a create or a full replace. Omitting active keeps
+// the current status, or activates a new account.
+//
+// Returns:
+// - ErrInvalidValue if a required attribute is missing
+func applyUser(r *Record, u *User) error {
+	userName := strings.TrimSpace(u.UserName)
+	if userName == "" {
+		return invalidValue("userName is required")
+	}
+	email := primaryEmail(u.Emails)
+	if email == "" {
+		return invalidValue("an email is required")
+	}
+
+	r.Username = userName
+	r.Email = email
+	r.ExternalID = optional(u.ExternalID)
+	r.Name = optional(displayName(u))
+	r.Language = optional(u.PreferredLanguage)
+	r.Timezone = optional(u.Timezone)
+
+	switch {
+	case u.Active == nil && r.Status == "":
+		r.Status = "active"
+	case u.Active == nil:
+	case *u.Active:
+		r.Status = "active"
+	case r.Status == "" || r.Status == "active":
+		r.Status = "disabled"
+	}
+
+	if u.Password != "" {
+		hashed, err := account.HashPassword(u.Password)
+		if err != nil {
+			return err
+		}
+		r.Password = hashed
+	}
+	return nil
+}
+
+// displayName prefers displayName, then the formatted name, then given and family names.
+func displayName(u *User) string {
+	if u.DisplayName != "" {
+		return u.DisplayName
+	}
+	if u.Name == nil {
+		return ""
+	}
+	if u.Name.Formatted != "" {
+		return u.Name.Formatted
+	}
+	return strings.TrimSpace(u.Name.GivenName + " " + u.Name.FamilyName)
+}
+
+func primaryEmail(emails []Email) string {
+	for _, email := range emails {
+		if email.Primary && email.Value != "" {
+			return strings.TrimSpace(email.Value)
+		}
+	}
+	for _, email := range emails {
+		if email.Value != "" {
+			return strings.TrimSpace(email.Value)
+		}
+	}
+	return ""
+}
+
+func optional(value string) *string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+func parseID(id string) (uuid.UUID, error) {
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, ErrNotFound
+	}
+	return parsed, nil
+}
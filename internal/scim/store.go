@@ -0,0 +1,213 @@
+package scim
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"monolith/internal/database"
+	"monolith/internal/events"
+	"monolith/internal/logger"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/google/uuid"
+)
+
+// Store persists the accounts SCIM provisions. The Postgres store records the account events of each
+// change in its transaction.
+type Store interface {
+	// List returns up to limit records matching filter, skipping the first offset, and the number of
+	// records matching in total. A nil filter matches every record.
+	List(ctx context.Context, filter Filter, offset, limit int) ([]Record, int, error)
+	Get(ctx context.Context, id uuid.UUID) (*Record, error)
+	// Create inserts r, setting its ID and timestamps.
+	Create(ctx context.Context, r *Record) error
+	// Update writes every attribute of r, keeping the stored password when r has none. Deactivating
+	// an account revokes its sessions.
+	Update(ctx context.Context, r *Record) error
+	// Delete removes the record and revokes its sessions.
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// SessionRevoker revokes the sessions of an account, as auth.Service does.
+type SessionRevoker interface {
+	RevokeAllUserSessions(ctx context.Context, accountID uuid.UUID) error
+}
+
+type postgresStore struct {
+	db       *database.DB
+	sessions SessionRevoker
+}
+
+// NewPostgresStore returns a Store over the account table, revoking sessions through sessions.
+func NewPostgresStore(db *database.DB, sessions SessionRevoker) Store {
+	return &postgresStore{db: db, sessions: sessions}
+}
+
+const recordColumns = `id, username, email, name, avatar, is_admin, language, theme, timezone,
+	       last_seen_at, status, created_at, updated_at, external_id`
+
+func (s *postgresStore) List(ctx context.Context, filter Filter, offset, limit int) ([]Record, int, error) {
+	where := "TRUE"
+	var args []any
+	if filter != nil {
+		where = filter.sql(&args)
+	}
+
+	var total int
+	err := s.db.Conn(ctx).QueryRow(ctx, `SELECT COUNT(*) FROM account WHERE `+where, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	records := []Record{}
+	if limit > 0 {
+		err := pgxscan.Select(ctx, s.db.Conn(ctx), &records, `
+			SELECT `+recordColumns+`
+			FROM account
+			WHERE `+where+`
+			ORDER BY created_at, id
+			OFFSET `+strconv.Itoa(offset)+` LIMIT `+strconv.Itoa(limit), args...)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	return records, total, nil
+}
+
+func (s *postgresStore) Get(ctx context.Context, id uuid.UUID) (*Record, error) {
+	var record Record
+	err := pgxscan.Get(ctx, s.db.Conn(ctx), &record, `
+		SELECT `+recordColumns+`
+		FROM account
+		WHERE id = $1
+	`, id)
+	if err != nil {
+		if errors.Is(err, database.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (s *postgresStore) Create(ctx context.Context, r *Record) error {
+	err := s.db.WithTx(ctx, func(ctx context.Context) error {
+		tx := s.db.Conn(ctx)
+		err := tx.QueryRow(ctx, `
+			INSERT INTO account (username, email, name, password, language, timezone, status, external_id,
+			                     created_at, updated_at)
+			VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7, $8, NOW(), NOW())
+			RETURNING id, created_at, updated_at
+		`, r.Username, r.Email, r.Name, r.Password, r.Language, r.Timezone, r.Status, r.ExternalID).
+			Scan(&r.ID, &r.CreatedAt, &r.UpdatedAt)
+		if err != nil {
+			if database.IsUniqueViolation(err) {
+				return ErrUniqueness
+			}
+			return err
+		}
+		return events.Record(ctx, tx, events.AccountCreated{
+			AccountID: r.ID,
+			Username:  r.Username,
+			Email:     r.Email,
+			Name:      r.Name,
+			Status:    r.Status,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.FromContext(ctx).Info("Account provisioned", "new_account_id", r.ID, "username", r.Username)
+	return nil
+}
+
+func (s *postgresStore) Update(ctx context.Context, r *Record) error {
+	err := s.db.WithTx(ctx, func(ctx context.Context) error {
+		tx := s.db.Conn(ctx)
+		var previousStatus string
+		err := tx.QueryRow(ctx, `
+			UPDATE account
+			SET username = $1, email = $2, name = $3, password = COALESCE(NULLIF($4, ''), password),
+			    language = $5, timezone = $6, status = $7, external_id = $8, updated_at = NOW()
+			FROM (SELECT status FROM account WHERE id = $9 FOR UPDATE) AS previous
+			WHERE account.id = $9
+			RETURNING account.updated_at, previous.status
+		`, r.Username, r.Email, r.Name, r.Password, r.Language, r.Timezone, r.Status, r.ExternalID, r.ID).
+			Scan(&r.UpdatedAt, &previousStatus)
+		if err != nil {
+			switch {
+			case errors.Is(err, database.ErrNoRows):
+				return ErrNotFound
+			case database.IsUniqueViolation(err):
+				return ErrUniqueness
+			default:
+				return err
+			}
+		}
+
+		err = events.Record(ctx, tx, events.AccountUpdated{
+			AccountID: r.ID,
+			Username:  r.Username,
+			Email:     r.Email,
+			Name:      r.Name,
+		})
+		if err != nil {
+			return err
+		}
+		if err := recordStatusChange(ctx, tx, r, previousStatus); err != nil {
+			return err
+		}
+
+		if r.Status != "active" {
+			return s.sessions.RevokeAllUserSessions(ctx, r.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.FromContext(ctx).Info("Account updated by provisioning", "account_id", r.ID, "status", r.Status)
+	return nil
+}
+
+// recordStatusChange records the enabling or disabling of r if its status changed from previousStatus.
+func recordStatusChange(ctx context.Context, tx database.Querier, r *Record, previousStatus string) error {
+	if r.Status == previousStatus {
+		return nil
+	}
+	switch r.Status {
+	case "active":
+		return events.Record(ctx, tx, events.AccountEnabled{AccountID: r.ID})
+	case "disabled":
+		return events.Record(ctx, tx, events.AccountDisabled{AccountID: r.ID})
+	default:
+		return nil
+	}
+}
+
+func (s *postgresStore) Delete(ctx context.Context, id uuid.UUID) error {
+	err := s.db.WithTx(ctx, func(ctx context.Context) error {
+		// revoked first, since the sessions go with the account
+		if err := s.sessions.RevokeAllUserSessions(ctx, id); err != nil {
+			return err
+		}
+		tx := s.db.Conn(ctx)
+		tag, err := tx.Exec(ctx, `DELETE FROM account WHERE id = $1`, id)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return ErrNotFound
+		}
+		return events.Record(ctx, tx, events.AccountDeleted{AccountID: id})
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.FromContext(ctx).Info("Account deprovisioned", "account_id", id)
+	return nil
+}
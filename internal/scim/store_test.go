@@ -0,0 +1,217 @@
+package scim
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"monolith/internal/account"
+	"monolith/internal/database"
+	"monolith/internal/events"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresStore(t *testing.T) {
+	id := uuid.MustParse("6f1c1a7e-3d4b-4c59-9d1e-8f8b1c2d3e4f")
+	now := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+	columns := []string{
+		"id", "username", "email", "name", "avatar", "is_admin", "language", "theme", "timezone",
+		"last_seen_at", "status", "created_at", "updated_at", "external_id",
+	}
+	record := func(status string) *Record {
+		return &Record{
+			Account: account.Account{
+				ID:       id,
+				Username: "jane",
+				Email:    "jane@example.com",
+				Status:   status,
+			},
+			ExternalID: new("00u1"),
+		}
+	}
+
+	tests := []struct {
+		name        string
+		setupMock   func(mock pgxmock.PgxPoolIface)
+		run         func(t *testing.T, s Store) error
+		wantErr     error
+		wantRevoked []uuid.UUID
+	}{
+		{
+			name: "list applies the filter and page",
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery(`SELECT COUNT\(\*\) FROM account WHERE \(LOWER\(username\) = \$1\)`).
+					WithArgs("jane").
+					WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(3))
+				mock.ExpectQuery(`SELECT .+ FROM account\s+WHERE \(LOWER\(username\) = \$1\)\s+` +
+					`ORDER BY created_at, id\s+OFFSET 1 LIMIT 2`).
+					WithArgs("jane").
+					WillReturnRows(pgxmock.NewRows(columns).AddRow(id, "jane", "jane@example.com", nil, nil, false,
+						nil, nil, nil, (*time.Time)(nil), "active", now, now, new("00u1")))
+			},
+			run: func(t *testing.T, s Store) error {
+				filter, err := ParseFilter(`userName eq "Jane"`)
+				require.NoError(t, err)
+
+				records, total, err := s.List(context.Background(), filter, 1, 2)
+				require.NoError(t, err)
+				assert.Equal(t, 3, total)
+				require.Len(t, records, 1)
+				assert.Equal(t, "00u1", *records[0].ExternalID)
+				return nil
+			},
+		},
+		{
+			name: "get missing record",
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery(`SELECT .+ FROM account\s+WHERE id = \$1`).
+					WithArgs(id).
+					WillReturnError(database.ErrNoRows)
+			},
+			run: func(t *testing.T, s Store) error {
+				_, err := s.Get(context.Background(), id)
+				return err
+			},
+			wantErr: ErrNotFound,
+		},
+		{
+			name: "create duplicate",
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`INSERT INTO account`).
+					WithArgs("jane", "jane@example.com", (*string)(nil), "", (*string)(nil), (*string)(nil),
+						"active", new("00u1")).
+					WillReturnError(&pgconn.PgError{Code: "23505"})
+				mock.ExpectRollback()
+			},
+			run: func(t *testing.T, s Store) error {
+				return s.Create(context.Background(), record("active"))
+			},
+			wantErr: ErrUniqueness,
+		},
+		{
+			name: "create records the event",
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`INSERT INTO account`).
+					WithArgs("jane", "jane@example.com", (*string)(nil), "", (*string)(nil), (*string)(nil),
+						"active", new("00u1")).
+					WillReturnRows(pgxmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(id, now, now))
+				expectEvent(mock, events.TypeAccountCreated)
+				mock.ExpectCommit()
+			},
+			run: func(t *testing.T, s Store) error {
+				r := record("active")
+				require.NoError(t, s.Create(context.Background(), r))
+				assert.Equal(t, now, r.CreatedAt)
+				return nil
+			},
+		},
+		{
+			name: "deactivating revokes sessions",
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`UPDATE account`).
+					WithArgs("jane", "jane@example.com", (*string)(nil), "", (*string)(nil), (*string)(nil),
+						"disabled", new("00u1"), id).
+					WillReturnRows(pgxmock.NewRows([]string{"updated_at", "status"}).AddRow(now, "active"))
+				expectEvent(mock, events.TypeAccountUpdated)
+				expectEvent(mock, events.TypeAccountDisabled)
+				mock.ExpectCommit()
+			},
+			run: func(t *testing.T, s Store) error {
+				r := record("disabled")
+				require.NoError(t, s.Update(context.Background(), r))
+				assert.Equal(t, now, r.UpdatedAt)
+				return nil
+			},
+			wantRevoked: []uuid.UUID{id},
+		},
+		{
+			name: "unchanged status records only the update",
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`UPDATE account`).
+					WithArgs("jane", "jane@example.com", (*string)(nil), "", (*string)(nil), (*string)(nil),
+						"active", new("00u1"), id).
+					WillReturnRows(pgxmock.NewRows([]string{"updated_at", "status"}).AddRow(now, "active"))
+				expectEvent(mock, events.TypeAccountUpdated)
+				mock.ExpectCommit()
+			},
+			run: func(t *testing.T, s Store) error {
+				return s.Update(context.Background(), record("active"))
+			},
+		},
+		{
+			name: "delete records the event",
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectExec(`DELETE FROM account WHERE id = \$1`).
+					WithArgs(id).
+					WillReturnResult(pgxmock.NewResult("DELETE", 1))
+				expectEvent(mock, events.TypeAccountDeleted)
+				mock.ExpectCommit()
+			},
+			run: func(t *testing.T, s Store) error {
+				return s.Delete(context.Background(), id)
+			},
+			wantRevoked: []uuid.UUID{id},
+		},
+		{
+			name: "delete missing record",
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBegin()
+				mock.ExpectExec(`DELETE FROM account WHERE id = \$1`).
+					WithArgs(id).
+					WillReturnResult(pgxmock.NewResult("DELETE", 0))
+				mock.ExpectRollback()
+			},
+			run: func(t *testing.T, s Store) error {
+				return s.Delete(context.Background(), id)
+			},
+			wantErr:     ErrNotFound,
+			wantRevoked: []uuid.UUID{id},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mock.Close()
+
+			tt.setupMock(mock)
+			sessions := &fakeSessionRevoker{}
+			err = tt.run(t, NewPostgresStore(&database.DB{Pool: mock}, sessions))
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantRevoked, sessions.revoked)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// expectEvent expects an event of the given type to be written to the outbox.
+func expectEvent(mock pgxmock.PgxPoolIface, eventType events.Type) {
+	mock.ExpectExec(`INSERT INTO outbox`).
+		WithArgs(pgxmock.AnyArg(), string(eventType), pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+}
+
+// fakeSessionRevoker records the accounts whose sessions were revoked.
+type fakeSessionRevoker struct {
+	revoked []uuid.UUID
+}
+
+func (f *fakeSessionRevoker) RevokeAllUserSessions(_ context.Context, accountID uuid.UUID) error {
+	f.revoked = append(f.revoked, accountID)
+	return nil
+}
@@ -2,6 +2,7 @@ package testutil
 
 import (
 	"context"
+	"io"
 	"time"
 
 	"monolith/internal/account"
@@ -11,6 +12,7 @@ import (
 	"monolith/internal/login"
 
 	"github.com/google/uuid"
+	"github.com/labstack/echo/v5"
 	"github.com/pashagolub/pgxmock/v4"
 )
 
@@ -31,22 +33,36 @@ func NewTestSecurityConfig() config.SecurityConfig {
 }
 
 type MockAccountService struct {
-	ValidatePasswordFn  func(hashedPassword, password string) error
-	UserExistsFn        func(ctx context.Context, email, username string) (bool, error)
-	RegisterFn          func(ctx context.Context, req account.RegisterRequest) (*account.Account, error)
-	GetAccountByIDFn    func(ctx context.Context, accountID uuid.UUID) (*account.Account, error)
-	GetAccountByLoginFn func(ctx context.Context, login string) (*account.Account, error)
-	UpdatePreferencesFn func(ctx context.Context, accountID uuid.UUID, req account.UpdatePreferencesRequest) (*account.Account, error)
-	ChangePasswordFn    func(ctx context.Context, accountID uuid.UUID, req account.ChangePasswordRequest) error
-	UpdateLastSeenFn    func(ctx context.Context, accountID uuid.UUID) error
-	GetAccountsFn       func(ctx context.Context) ([]account.Account, error)
-	GetAccountFn        func(ctx context.Context, id uuid.UUID) (*account.Account, error)
-	CreateAccountFn     func(ctx context.Context, req account.CreateAccountRequest) (*account.Account, error)
-	UpdateAccountFn     func(ctx context.Context, id uuid.UUID, req account.UpdateAccountRequest) (*account.Account, error)
-	DisableAccountFn    func(ctx context.Context, id uuid.UUID) error
-	EnableAccountFn     func(ctx context.Context, id uuid.UUID) error
-	DeleteAccountFn     func(ctx context.Context, id uuid.UUID) error
-	InviteUsersFn       func(ctx context.Context, req account.InviteUsersRequest) (*account.InviteUsersResponse, error)
+	ValidatePasswordFn   func(hashedPassword, password string) error
+	UserExistsFn         func(ctx context.Context, email, username string) (bool, error)
+	RegisterFn           func(ctx context.Context, req account.RegisterRequest) (*account.Account, error)
+	GetAccountByIDFn     func(ctx context.Context, accountID uuid.UUID) (*account.Account, error)
+	GetAccountByLoginFn  func(ctx context.Context, login string) (*account.Account, error)
+	UpdatePreferencesFn  func(ctx context.Context, accountID uuid.UUID, req account.UpdatePreferencesRequest) (*account.Account, error)
+	ChangePasswordFn     func(ctx context.Context, accountID uuid.UUID, req account.ChangePasswordRequest) error
+	UpdateLastSeenFn     func(ctx context.Context, accountID uuid.UUID) error
+	GetAccountsFn        func(ctx context.Context, filter account.AccountFilter) ([]account.Account, error)
+	GetAccountFn         func(ctx context.Context, id uuid.UUID) (*account.Account, error)
+	CreateAccountFn      func(ctx context.Context, req account.CreateAccountRequest) (*account.Account, error)
+	UpdateAccountFn      func(ctx context.Context, id uuid.UUID, req account.UpdateAccountRequest) (*account.Account, error)
+	DisableAccountFn     func(ctx context.Context, id uuid.UUID) error
+	EnableAccountFn      func(ctx context.Context, id uuid.UUID) error
+	DeleteAccountFn      func(ctx context.Context, id uuid.UUID) error
+	InviteUsersFn        func(ctx context.Context, req account.InviteUsersRequest) (*account.InviteUsersResponse, error)
+	BulkUpdateAccountsFn func(
+		ctx context.Context,
+		actorID uuid.UUID,
+		req account.BulkAccountsRequest,
+	) (*account.BulkAccountsResponse, error)
+	ExportAccountsFn func(ctx context.Context, req account.ExportAccountsRequest, w io.Writer) error
+	PreviewImportFn  func(ctx context.Context, rows []account.ImportRow) (*account.ImportPreview, error)
+	StartImportFn    func(
+		ctx context.Context,
+		actorID uuid.UUID,
+		rows []account.ImportRow,
+	) (*account.AccountImport, error)
+	GetImportFn         func(ctx context.Context, id uuid.UUID) (*account.AccountImport, error)
+	WriteImportReportFn func(ctx context.Context, id uuid.UUID, w io.Writer) error
 }
 
 func (m *MockAccountService) ValidatePassword(hashedPassword, password string) error {
@@ -113,9 +129,9 @@ func (m *MockAccountService) UpdateLastSeen(ctx context.Context, accountID uuid.
 	return nil
 }
 
-func (m *MockAccountService) GetAccounts(ctx context.Context) ([]account.Account, error) {
+func (m *MockAccountService) GetAccounts(ctx context.Context, filter account.AccountFilter) ([]account.Account, error) {
 	if m.GetAccountsFn != nil {
-		return m.GetAccountsFn(ctx)
+		return m.GetAccountsFn(ctx, filter)
 	}
 	return []account.Account{*NewTestAccount()}, nil
 }
@@ -179,14 +195,75 @@ func (m *MockAccountService) InviteUsers(
 	return &account.InviteUsersResponse{Success: []account.Account{*NewTestAccount()}}, nil
 }
 
+func (m *MockAccountService) BulkUpdateAccounts(
+	ctx context.Context,
+	actorID uuid.UUID,
+	req account.BulkAccountsRequest,
+) (*account.BulkAccountsResponse, error) {
+	if m.BulkUpdateAccountsFn != nil {
+		return m.BulkUpdateAccountsFn(ctx, actorID, req)
+	}
+	return &account.BulkAccountsResponse{}, nil
+}
+
+func (m *MockAccountService) ExportAccounts(
+	ctx context.Context,
+	req account.ExportAccountsRequest,
+	w io.Writer,
+) error {
+	if m.ExportAccountsFn != nil {
+		return m.ExportAccountsFn(ctx, req, w)
+	}
+	return nil
+}
+
+func (m *MockAccountService) PreviewImport(
+	ctx context.Context,
+	rows []account.ImportRow,
+) (*account.ImportPreview, error) {
+	if m.PreviewImportFn != nil {
+		return m.PreviewImportFn(ctx, rows)
+	}
+	return &account.ImportPreview{}, nil
+}
+
+func (m *MockAccountService) StartImport(
+	ctx context.Context,
+	actorID uuid.UUID,
+	rows []account.ImportRow,
+) (*account.AccountImport, error) {
+	if m.StartImportFn != nil {
+		return m.StartImportFn(ctx, actorID, rows)
+	}
+	return &account.AccountImport{ID: uuid.New(), CreatedBy: actorID}, nil
+}
+
+func (m *MockAccountService) GetImport(ctx context.Context, id uuid.UUID) (*account.AccountImport, error) {
+	if m.GetImportFn != nil {
+		return m.GetImportFn(ctx, id)
+	}
+	return &account.AccountImport{ID: id}, nil
+}
+
+func (m *MockAccountService) WriteImportReport(ctx context.Context, id uuid.UUID, w io.Writer) error {
+	if m.WriteImportReportFn != nil {
+		return m.WriteImportReportFn(ctx, id, w)
+	}
+	return nil
+}
+
 type MockAuthService struct {
-	CreateSessionFn         func(ctx context.Context, req *auth.CreateSessionRequest) (*auth.Session, error)
-	GetAuthContextByTokenFn func(ctx context.Context, unhashedToken string) (*auth.AuthContext, error)
-	GetSessionByTokenFn     func(ctx context.Context, unhashedToken string) (*auth.Session, error)
-	RotateSessionFn         func(ctx context.Context, req *auth.RotateSessionRequest) (*auth.Session, error)
-	RevokeSessionFn         func(ctx context.Context, userID uuid.UUID, sessionID uuid.UUID) error
-	GetUserSessionsFn       func(ctx context.Context, userID uuid.UUID) ([]auth.UserSession, error)
-	RevokeAllUserSessionsFn func(ctx context.Context, accountID uuid.UUID) error
+	CreateSessionFn           func(ctx context.Context, req *auth.CreateSessionRequest) (*auth.Session, error)
+	GetAuthContextByTokenFn   func(ctx context.Context, unhashedToken string) (*auth.AuthContext, error)
+	GetSessionByTokenFn       func(ctx context.Context, unhashedToken string) (*auth.Session, error)
+	RotateSessionFn           func(ctx context.Context, req *auth.RotateSessionRequest) (*auth.Session, error)
+	RevokeSessionFn           func(ctx context.Context, userID uuid.UUID, sessionID uuid.UUID) error
+	GetUserSessionsFn         func(ctx context.Context, userID uuid.UUID) ([]auth.UserSession, error)
+	RevokeAllUserSessionsFn   func(ctx context.Context, accountID uuid.UUID) error
+	SetSessionCookiesFn       func(c *echo.Context, session *auth.Session)
+	ClearAuthCookiesFn        func(c *echo.Context)
+	RevokeSessionFromCookieFn func(c *echo.Context) error
+	RevokeOtherSessionsFn     func(ctx context.Context, accountID, currentSessionID uuid.UUID) error
 }
 
 func (m *MockAuthService) CreateSession(ctx context.Context, req *auth.CreateSessionRequest) (*auth.Session, error) {
@@ -238,6 +315,32 @@ func (m *MockAuthService) RevokeAllUserSessions(ctx context.Context, accountID u
 	return nil
 }
 
+func (m *MockAuthService) SetSessionCookies(c *echo.Context, session *auth.Session) {
+	if m.SetSessionCookiesFn != nil {
+		m.SetSessionCookiesFn(c, session)
+	}
+}
+
+func (m *MockAuthService) ClearAuthCookies(c *echo.Context) {
+	if m.ClearAuthCookiesFn != nil {
+		m.ClearAuthCookiesFn(c)
+	}
+}
+
+func (m *MockAuthService) RevokeSessionFromCookie(c *echo.Context) error {
+	if m.RevokeSessionFromCookieFn != nil {
+		return m.RevokeSessionFromCookieFn(c)
+	}
+	return nil
+}
+
+func (m *MockAuthService) RevokeOtherSessions(ctx context.Context, accountID, currentSessionID uuid.UUID) error {
+	if m.RevokeOtherSessionsFn != nil {
+		return m.RevokeOtherSessionsFn(ctx, accountID, currentSessionID)
+	}
+	return nil
+}
+
 type MockLoginService struct {
 	LoginFn func(ctx context.Context, req login.UserLoginRequest) (*account.Account, error)
 }
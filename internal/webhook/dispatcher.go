@@ -0,0 +1,212 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"monolith/internal/logger"
+	"monolith/internal/retry"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/google/uuid"
+)
+
+const (
+	HeaderID        = "Webhook-Id"
+	HeaderEvent     = "Webhook-Event"
+	HeaderTimestamp = "Webhook-Timestamp"
+	// HeaderSignature carries "v1=" and the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with the
+	// endpoint's secret.
+	HeaderSignature = "Webhook-Signature"
+
+	// batchSize is how many due deliveries are claimed at a time.
+	batchSize = 50
+)
+
+// retryBackoff spaces the attempts of a failing delivery.
+var retryBackoff = retry.Backoff{Base: 30 * time.Second, Max: 6 * time.Hour}
+
+// Sign returns the signature header value of body sent at timestamp.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Run delivers due events until ctx is canceled, polling the queue every poll interval.
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			sent, err := s.DeliverDue(ctx)
+			if err != nil && ctx.Err() == nil {
+				logger.FromContext(ctx).Warn("Failed to deliver webhooks", "error", err)
+			}
+			if err != nil || sent < batchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// dueDelivery is a claimed delivery with what's needed to send it.
+type dueDelivery struct {
+	ID        uuid.UUID
+	EventID   uuid.UUID
+	EventType string
+	Payload   json.RawMessage
+	Attempts  int
+	URL       string
+	Secret    string
+}
+
+// DeliverDue claims a batch of due deliveries to active endpoints and sends them concurrently, returning
+// how many were attempted. Claimed deliveries are leased for twice the request timeout, which every send of
+// the batch finishes within, so another instance only picks them up if this one dies mid-delivery.
+func (s *Service) DeliverDue(ctx context.Context) (int, error) {
+	var due []dueDelivery
+	err := pgxscan.Select(ctx, s.db.Pool, &due, `
+		WITH claimed AS (
+			SELECT d.id
+			FROM webhook_delivery d
+			JOIN webhook_endpoint e ON e.id = d.endpoint_id AND e.active
+			WHERE d.status = $1 AND d.next_attempt_at <= NOW()
+			ORDER BY d.next_attempt_at
+			LIMIT $2
+			FOR UPDATE OF d SKIP LOCKED
+		)
+		UPDATE webhook_delivery d
+		SET next_attempt_at = $3
+		FROM claimed, webhook_endpoint e
+		WHERE d.id = claimed.id AND e.id = d.endpoint_id
+		RETURNING d.id, d.event_id, d.event_type, d.payload, d.attempts, e.url, e.secret
+	`, DeliveryPending, batchSize, time.Now().Add(2*s.cfg.Timeout))
+	if err != nil {
+		return 0, err
+	}
+
+	errs := make([]error, len(due))
+	var wg sync.WaitGroup
+	for i := range due {
+		wg.Go(func() {
+			errs[i] = s.deliver(ctx, &due[i])
+		})
+	}
+	wg.Wait()
+	return len(due), errors.Join(errs...)
+}
+
+// deliver sends one delivery and records the outcome. Only an error recording the outcome is returned;
+// the delivery itself failing is recorded and retried.
+func (s *Service) deliver(ctx context.Context, d *dueDelivery) error {
+	log := logger.FromContext(ctx).With("delivery_id", d.ID, "event_type", d.EventType)
+
+	responseStatus, sendErr := s.send(ctx, d)
+	attempts := d.Attempts + 1
+
+	if sendErr == nil {
+		_, err := s.db.Pool.Exec(ctx, `
+			UPDATE webhook_delivery
+			SET status = $2, attempts = $3, response_status = $4, last_error = NULL, last_attempt_at = NOW(),
+			    next_attempt_at = NULL, delivered_at = NOW()
+			WHERE id = $1
+		`, d.ID, DeliverySucceeded, attempts, responseStatus)
+		if err == nil {
+			log.Debug("Webhook delivered", "attempts", attempts)
+		}
+		return err
+	}
+
+	status := DeliveryPending
+	var nextAttempt *time.Time
+	if attempts >= s.cfg.MaxAttempts {
+		status = DeliveryFailed
+		log.Warn("Webhook delivery failed, giving up", "attempts", attempts, "error", sendErr)
+	} else {
+		next := time.Now().Add(retryBackoff.Delay(attempts))
+		nextAttempt = &next
+		log.Info("Webhook delivery failed, will retry", "attempts", attempts, "retry_at", next, "error", sendErr)
+	}
+
+	var statusCode *int
+	if responseStatus != 0 {
+		statusCode = &responseStatus
+	}
+
+	_, err := s.db.Pool.Exec(ctx, `
+		UPDATE webhook_delivery
+		SET status = $2, attempts = $3, response_status = $4, last_error = $5, last_attempt_at = NOW(),
+		    next_attempt_at = $6
+		WHERE id = $1
+	`, d.ID, status, attempts, statusCode, retry.ErrorText(sendErr), nextAttempt)
+	return err
+}
+
+// PruneDeliveries deletes the finished deliveries older than the retention period from the delivery log.
+func (s *Service) PruneDeliveries(ctx context.Context) error {
+	tag, err := s.db.Pool.Exec(ctx, `
+		DELETE FROM webhook_delivery WHERE status <> $1 AND created_at < $2
+	`, DeliveryPending, time.Now().Add(-s.cfg.Retention))
+	if err != nil {
+		return err
+	}
+
+	logger.FromContext(ctx).Info("Webhook deliveries cleaned up", "deleted", tag.RowsAffected())
+	return nil
+}
+
+// send POSTs the signed payload. Any response other than 2xx is an error, including redirects, which
+// aren't followed.
+func (s *Service) send(ctx context.Context, d *dueDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "monolith-webhooks")
+	req.Header.Set(HeaderID, d.EventID.String())
+	req.Header.Set(HeaderEvent, d.EventType)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(HeaderSignature, Sign(d.Secret, now, d.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, &statusError{status: resp.StatusCode}
+	}
+	return resp.StatusCode, nil
+}
+
+type statusError struct {
+	status int
+}
+
+func (e *statusError) Error() string {
+	return "endpoint responded with " + strconv.Itoa(e.status) + " " + http.StatusText(e.status)
+}
@@ -0,0 +1,136 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"monolith/internal/config"
+	"monolith/internal/database"
+
+	"github.com/google/uuid"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSign(t *testing.T) {
+	timestamp := time.Unix(1767225600, 0)
+	signature := Sign("whsec_test", timestamp, []byte(`{"id":"1"}`))
+
+	assert.Equal(t, "v1=c9c43053c587d9a132baf3e87a7f5cd6b5e5e5acf4ecb038f8c897644f666304", signature)
+	assert.NotEqual(t, signature, Sign("whsec_other", timestamp, []byte(`{"id":"1"}`)))
+	assert.NotEqual(t, signature, Sign("whsec_test", timestamp.Add(time.Second), []byte(`{"id":"1"}`)))
+}
+
+func TestService_DeliverDue(t *testing.T) {
+	deliveryID := uuid.New()
+	eventID := uuid.New()
+	payload := json.RawMessage(`{"id":"` + eventID.String() + `","type":"account.created","data":{}}`)
+	secret := "whsec_test"
+	columns := []string{"id", "event_id", "event_type", "payload", "attempts", "url", "secret"}
+
+	tests := []struct {
+		name           string
+		responseStatus int
+		attempts       int
+		setupMock      func(mock pgxmock.PgxPoolIface)
+	}{
+		{
+			name:           "success",
+			responseStatus: http.StatusNoContent,
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectExec(`UPDATE webhook_delivery\s+SET status = \$2, attempts = \$3, response_status = \$4, `+
+					`last_error = NULL`).
+					WithArgs(deliveryID, DeliverySucceeded, 1, http.StatusNoContent).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+		},
+		{
+			name:           "failure is retried with backoff",
+			responseStatus: http.StatusInternalServerError,
+			attempts:       1,
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectExec(`UPDATE webhook_delivery\s+SET status = \$2, attempts = \$3, response_status = \$4, `+
+					`last_error = \$5`).
+					WithArgs(deliveryID, DeliveryPending, 2, new(http.StatusInternalServerError),
+						"endpoint responded with 500 Internal Server Error", pgxmock.AnyArg()).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+		},
+		{
+			name:           "last attempt fails the delivery",
+			responseStatus: http.StatusGone,
+			attempts:       2,
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectExec(`UPDATE webhook_delivery\s+SET status = \$2, attempts = \$3, response_status = \$4, `+
+					`last_error = \$5`).
+					WithArgs(deliveryID, DeliveryFailed, 3, new(http.StatusGone),
+						"endpoint responded with 410 Gone", (*time.Time)(nil)).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received *http.Request
+			var body []byte
+			receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received = r
+				body, _ = io.ReadAll(r.Body)
+				w.WriteHeader(tt.responseStatus)
+			}))
+			defer receiver.Close()
+
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mock.Close()
+
+			mock.ExpectQuery(`WITH claimed AS .+ FOR UPDATE OF d SKIP LOCKED .+ UPDATE webhook_delivery d`).
+				WithArgs(DeliveryPending, batchSize, pgxmock.AnyArg()).
+				WillReturnRows(pgxmock.NewRows(columns).
+					AddRow(deliveryID, eventID, "account.created", payload, tt.attempts, receiver.URL, secret))
+			tt.setupMock(mock)
+
+			s := NewService(&database.DB{Pool: mock}, config.WebhookConfig{
+				Timeout:              time.Second,
+				MaxAttempts:          3,
+				AllowPrivateNetworks: true,
+			})
+			sent, err := s.DeliverDue(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, 1, sent)
+
+			require.NotNil(t, received)
+			assert.JSONEq(t, string(payload), string(body))
+			assert.Equal(t, eventID.String(), received.Header.Get(HeaderID))
+			assert.Equal(t, "account.created", received.Header.Get(HeaderEvent))
+
+			timestamp, err := strconv.ParseInt(received.Header.Get(HeaderTimestamp), 10, 64)
+			require.NoError(t, err)
+			assert.Equal(t, Sign(secret, time.Unix(timestamp, 0), body), received.Header.Get(HeaderSignature))
+
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestService_PruneDeliveries(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	mock.ExpectExec(`DELETE FROM webhook_delivery WHERE status <> \$1 AND created_at < \$2`).
+		WithArgs(DeliveryPending, pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("DELETE", 4))
+
+	s := NewService(&database.DB{Pool: mock}, config.WebhookConfig{Retention: 24 * time.Hour})
+	require.NoError(t, s.PruneDeliveries(context.Background()))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
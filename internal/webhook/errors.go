@@ -0,0 +1,11 @@
+package webhook
+
+import "errors"
+
+var (
+	ErrUnknownEventType = errors.New("unknown webhook event type")
+	ErrDeliveryPending  = errors.New("webhook delivery is still pending")
+	// ErrPrivateURL is returned for endpoint URLs, and recorded for deliveries, pointing at an address
+	// that isn't public.
+	ErrPrivateURL = errors.New("webhook URL points at a private address")
+)
@@ -0,0 +1,72 @@
+package webhook
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"monolith/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsPublic(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"93.184.215.14", true},
+		{"2606:2800:21f:cb07:6820:80da:af6b:8b2c", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"::ffff:127.0.0.1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			assert.Equal(t, tt.want, isPublic(netip.MustParseAddr(tt.addr)))
+		})
+	}
+}
+
+func TestService_checkURL(t *testing.T) {
+	s := NewService(nil, config.WebhookConfig{})
+	require.NoError(t, s.checkURL("https://example.com/hook"))
+	require.ErrorIs(t, s.checkURL("http://localhost:8080/hook"), ErrPrivateURL)
+	require.ErrorIs(t, s.checkURL("http://api.localhost./hook"), ErrPrivateURL)
+	require.ErrorIs(t, s.checkURL("http://[::1]/hook"), ErrPrivateURL)
+	require.ErrorIs(t, s.checkURL("http://10.0.0.5/hook"), ErrPrivateURL)
+
+	allowed := NewService(nil, config.WebhookConfig{AllowPrivateNetworks: true})
+	require.NoError(t, allowed.checkURL("http://localhost:8080/hook"))
+}
+
+func TestNewClient(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/elsewhere", http.StatusFound)
+	}))
+	defer receiver.Close()
+
+	t.Run("refuses private addresses", func(t *testing.T) {
+		_, err := newClient(time.Second, false).Get(receiver.URL)
+		require.ErrorIs(t, err, ErrPrivateURL)
+	})
+
+	t.Run("doesn't follow redirects", func(t *testing.T) {
+		resp, err := newClient(time.Second, true).Get(receiver.URL)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusFound, resp.StatusCode)
+	})
+}
@@ -0,0 +1,105 @@
+package webhook
+
+import (
+	"encoding/json"
+	"time"
+
+	"monolith/internal/events"
+
+	"github.com/google/uuid"
+)
+
+// EventType identifies what happened; endpoints subscribe to event types. Webhook event types are the
+// domain event types they are delivered from.
+type EventType string
+
+const (
+	EventAccountCreated  = EventType(events.TypeAccountCreated)
+	EventAccountInvited  = EventType(events.TypeAccountInvited)
+	EventAccountUpdated  = EventType(events.TypeAccountUpdated)
+	EventAccountDisabled = EventType(events.TypeAccountDisabled)
+	EventAccountEnabled  = EventType(events.TypeAccountEnabled)
+	EventAccountDeleted  = EventType(events.TypeAccountDeleted)
+	EventSessionCreated  = EventType(events.TypeSessionCreated)
+	EventSessionRevoked  = EventType(events.TypeSessionRevoked)
+)
+
+// EventTypes lists every event type endpoints can subscribe to.
+var EventTypes = []EventType{
+	EventAccountCreated,
+	EventAccountInvited,
+	EventAccountUpdated,
+	EventAccountDisabled,
+	EventAccountEnabled,
+	EventAccountDeleted,
+	EventSessionCreated,
+	EventSessionRevoked,
+}
+
+// Event is the JSON body POSTed to endpoints, with the domain event as Data. ID is shared by every delivery
+// of the event, including redeliveries, so receivers can use it to drop duplicates.
+type Event struct {
+	ID        uuid.UUID       `json:"id"`
+	Type      EventType       `json:"type"`
+	CreatedAt time.Time       `json:"createdAt"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Endpoint is a URL events are delivered to. Secret is only returned when the endpoint is created.
+type Endpoint struct {
+	ID          uuid.UUID `json:"id"`
+	URL         string    `json:"url"`
+	Description *string   `json:"description"`
+	Secret      string    `json:"secret,omitempty"`
+	EventTypes  []string  `json:"eventTypes"`
+	Active      bool      `json:"active"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+type CreateEndpointRequest struct {
+	URL         string   `json:"url"         validate:"required,http_url"`
+	Description *string  `json:"description"`
+	EventTypes  []string `json:"eventTypes"  validate:"required,min=1"`
+	Active      *bool    `json:"active"`
+}
+
+// UpdateEndpointRequest replaces an endpoint's settings; omitting active keeps the current value.
+type UpdateEndpointRequest struct {
+	URL         string   `json:"url"         validate:"required,http_url"`
+	Description *string  `json:"description"`
+	EventTypes  []string `json:"eventTypes"  validate:"required,min=1"`
+	Active      *bool    `json:"active"`
+}
+
+// DeliveryStatus is the state of a delivery. Pending deliveries are retried until they succeed or run
+// out of attempts.
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	DeliveryFailed    DeliveryStatus = "failed"
+)
+
+// Delivery is one event sent to one endpoint, with the outcome of its latest attempt.
+type Delivery struct {
+	ID             uuid.UUID       `json:"id"`
+	EndpointID     uuid.UUID       `json:"endpointId"`
+	EventID        uuid.UUID       `json:"eventId"`
+	EventType      string          `json:"eventType"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         DeliveryStatus  `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  *time.Time      `json:"nextAttemptAt"`
+	LastAttemptAt  *time.Time      `json:"lastAttemptAt"`
+	ResponseStatus *int            `json:"responseStatus"`
+	LastError      *string         `json:"lastError"`
+	CreatedAt      time.Time       `json:"createdAt"`
+	DeliveredAt    *time.Time      `json:"deliveredAt"`
+}
+
+type ListDeliveriesRequest struct {
+	Status DeliveryStatus `query:"status" validate:"omitempty,oneof=pending succeeded failed"`
+	Limit  int            `query:"limit"  validate:"omitempty,min=1,max=500"`
+}
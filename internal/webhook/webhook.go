@@ -0,0 +1,257 @@
+// Package webhook delivers account and session events to admin-registered HTTP endpoints. Events come
+// from the outbox and are queued as rows in webhook_delivery, which doubles as the delivery log, and sent
+// by a background dispatcher that retries failures with exponential backoff.
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"monolith/internal/config"
+	"monolith/internal/database"
+	"monolith/internal/events"
+	"monolith/internal/logger"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/google/uuid"
+)
+
+const (
+	// secretPrefix marks signing secrets so they are recognizable when leaked.
+	secretPrefix = "whsec_"
+	// defaultDeliveryLimit is how many deliveries ListDeliveries returns unless asked otherwise.
+	defaultDeliveryLimit = 100
+)
+
+type Service struct {
+	db     *database.DB
+	cfg    config.WebhookConfig
+	client *http.Client
+}
+
+func NewService(db *database.DB, cfg config.WebhookConfig) *Service {
+	return &Service{
+		db:     db,
+		cfg:    cfg,
+		client: newClient(cfg.Timeout, cfg.AllowPrivateNetworks),
+	}
+}
+
+// HandleEvent queues a delivery of an outbox event to every active endpoint subscribed to its type. The
+// event ID is reused, and an event already queued for an endpoint isn't queued again, so the outbox
+// handing an event over twice doesn't deliver it twice.
+func (s *Service) HandleEvent(ctx context.Context, e events.Envelope) error {
+	event := Event{ID: e.ID, Type: EventType(e.Type), CreatedAt: e.CreatedAt.UTC(), Data: e.Payload}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	tag, err := s.db.Conn(ctx).Exec(ctx, `
+		INSERT INTO webhook_delivery (endpoint_id, event_id, event_type, payload)
+		SELECT e.id, $1, $2, $3
+		FROM webhook_endpoint e
+		WHERE e.active AND $2 = ANY(e.event_types)
+		  AND NOT EXISTS (
+		      SELECT 1 FROM webhook_delivery d WHERE d.event_id = $1 AND d.endpoint_id = e.id
+		  )
+	`, event.ID, string(event.Type), json.RawMessage(payload))
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() > 0 {
+		logger.FromContext(ctx).Debug("Webhook event queued",
+			"event_id", event.ID,
+			"event_type", event.Type,
+			"deliveries", tag.RowsAffected(),
+		)
+	}
+	return nil
+}
+
+func (s *Service) ListEndpoints(ctx context.Context) ([]Endpoint, error) {
+	endpoints := []Endpoint{}
+	err := pgxscan.Select(ctx, s.db.Conn(ctx), &endpoints, `
+		SELECT id, url, description, event_types, active, created_at, updated_at
+		FROM webhook_endpoint
+		ORDER BY created_at
+	`)
+	if err != nil {
+		return nil, err
+	}
+	return endpoints, nil
+}
+
+func (s *Service) GetEndpoint(ctx context.Context, id uuid.UUID) (*Endpoint, error) {
+	var endpoint Endpoint
+	err := pgxscan.Get(ctx, s.db.Conn(ctx), &endpoint, `
+		SELECT id, url, description, event_types, active, created_at, updated_at
+		FROM webhook_endpoint
+		WHERE id = $1
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	return &endpoint, nil
+}
+
+// CreateEndpoint registers an endpoint with a new signing secret, which is only returned here.
+//
+// Returns:
+// - ErrUnknownEventType if req subscribes to an event type that doesn't exist
+// - ErrPrivateURL if req.URL points at localhost or a private address
+func (s *Service) CreateEndpoint(ctx context.Context, req CreateEndpointRequest) (*Endpoint, error) {
+	if err := validateEventTypes(req.EventTypes); err != nil {
+		return nil, err
+	}
+	if err := s.checkURL(req.URL); err != nil {
+		return nil, err
+	}
+	secret, err := newSecret()
+	if err != nil {
+		return nil, err
+	}
+	active := req.Active == nil || *req.Active
+
+	var endpoint Endpoint
+	err = pgxscan.Get(ctx, s.db.Conn(ctx), &endpoint, `
+		INSERT INTO webhook_endpoint (url, description, secret, event_types, active)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, url, description, secret, event_types, active, created_at, updated_at
+	`, req.URL, req.Description, secret, req.EventTypes, active)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.FromContext(ctx).Info("Webhook endpoint created", "endpoint_id", endpoint.ID, "events", endpoint.EventTypes)
+	return &endpoint, nil
+}
+
+// UpdateEndpoint replaces the settings of an endpoint, keeping its secret.
+//
+// Returns:
+// - ErrUnknownEventType if req subscribes to an event type that doesn't exist
+// - ErrPrivateURL if req.URL points at localhost or a private address
+func (s *Service) UpdateEndpoint(ctx context.Context, id uuid.UUID, req UpdateEndpointRequest) (*Endpoint, error) {
+	if err := validateEventTypes(req.EventTypes); err != nil {
+		return nil, err
+	}
+	if err := s.checkURL(req.URL); err != nil {
+		return nil, err
+	}
+
+	var endpoint Endpoint
+	err := pgxscan.Get(ctx, s.db.Conn(ctx), &endpoint, `
+		UPDATE webhook_endpoint
+		SET url = $2, description = $3, event_types = $4, active = COALESCE($5, active), updated_at = NOW()
+		WHERE id = $1
+		RETURNING id, url, description, event_types, active, created_at, updated_at
+	`, id, req.URL, req.Description, req.EventTypes, req.Active)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.FromContext(ctx).Info("Webhook endpoint updated", "endpoint_id", endpoint.ID, "active", endpoint.Active)
+	return &endpoint, nil
+}
+
+// DeleteEndpoint removes an endpoint along with its delivery log.
+func (s *Service) DeleteEndpoint(ctx context.Context, id uuid.UUID) error {
+	tag, err := s.db.Conn(ctx).Exec(ctx, `DELETE FROM webhook_endpoint WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return database.ErrNoRows
+	}
+
+	logger.FromContext(ctx).Info("Webhook endpoint deleted", "endpoint_id", id)
+	return nil
+}
+
+// ListDeliveries returns the most recent deliveries to an endpoint, newest first.
+func (s *Service) ListDeliveries(
+	ctx context.Context,
+	endpointID uuid.UUID,
+	req ListDeliveriesRequest,
+) ([]Delivery, error) {
+	if _, err := s.GetEndpoint(ctx, endpointID); err != nil {
+		return nil, err
+	}
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultDeliveryLimit
+	}
+
+	deliveries := []Delivery{}
+	err := pgxscan.Select(ctx, s.db.Conn(ctx), &deliveries, `
+		SELECT id, endpoint_id, event_id, event_type, payload, status, attempts, next_attempt_at,
+		       last_attempt_at, response_status, last_error, created_at, delivered_at
+		FROM webhook_delivery
+		WHERE endpoint_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY created_at DESC
+		LIMIT $3
+	`, endpointID, string(req.Status), limit)
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// Redeliver queues the event of a finished delivery again as a new delivery, keeping the original in the
+// log. The event keeps its ID.
+//
+// Returns:
+// - ErrDeliveryPending if the delivery hasn't finished yet
+func (s *Service) Redeliver(ctx context.Context, deliveryID uuid.UUID) (*Delivery, error) {
+	var delivery Delivery
+	err := pgxscan.Get(ctx, s.db.Conn(ctx), &delivery, `
+		INSERT INTO webhook_delivery (endpoint_id, event_id, event_type, payload)
+		SELECT endpoint_id, event_id, event_type, payload
+		FROM webhook_delivery
+		WHERE id = $1 AND status <> $2
+		RETURNING id, endpoint_id, event_id, event_type, payload, status, attempts, next_attempt_at,
+		          last_attempt_at, response_status, last_error, created_at, delivered_at
+	`, deliveryID, DeliveryPending)
+	if err == nil {
+		logger.FromContext(ctx).Info("Webhook delivery requeued",
+			"delivery_id", deliveryID,
+			"new_delivery_id", delivery.ID,
+		)
+		return &delivery, nil
+	}
+	if !errors.Is(err, database.ErrNoRows) {
+		return nil, err
+	}
+
+	// tell a pending delivery apart from a missing one
+	var status DeliveryStatus
+	if err := s.db.Conn(ctx).QueryRow(ctx, `SELECT status FROM webhook_delivery WHERE id = $1`, deliveryID).
+		Scan(&status); err != nil {
+		return nil, err
+	}
+	return nil, ErrDeliveryPending
+}
+
+func validateEventTypes(eventTypes []string) error {
+	for _, eventType := range eventTypes {
+		if !slices.Contains(EventTypes, EventType(eventType)) {
+			return fmt.Errorf("%w: %q", ErrUnknownEventType, eventType)
+		}
+	}
+	return nil
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return secretPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,173 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"monolith/internal/config"
+	"monolith/internal/database"
+	"monolith/internal/events"
+
+	"github.com/google/uuid"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var fixedTime = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestService_HandleEvent(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	eventID := uuid.New()
+	mock.ExpectExec(`INSERT INTO webhook_delivery .+ WHERE e.active AND \$2 = ANY\(e.event_types\)\s+AND NOT EXISTS`).
+		WithArgs(eventID, string(EventAccountDisabled), json.RawMessage(`{"id":"`+eventID.String()+
+			`","type":"account.disabled","createdAt":"2026-01-01T00:00:00Z","data":{"accountId":"1"}}`)).
+		WillReturnResult(pgxmock.NewResult("INSERT", 2))
+
+	s := NewService(&database.DB{Pool: mock}, config.WebhookConfig{})
+	err = s.HandleEvent(context.Background(), events.Envelope{
+		Position:  1,
+		ID:        eventID,
+		Type:      events.TypeAccountDisabled,
+		Payload:   json.RawMessage(`{"accountId":"1"}`),
+		CreatedAt: fixedTime,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestService_CreateEndpoint(t *testing.T) {
+	tests := []struct {
+		name       string
+		url        string
+		eventTypes []string
+		setupMock  func(mock pgxmock.PgxPoolIface)
+		wantErr    error
+	}{
+		{
+			name:       "success",
+			url:        "https://example.com/hook",
+			eventTypes: []string{"account.created", "session.created"},
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery(`INSERT INTO webhook_endpoint`).
+					WithArgs("https://example.com/hook", (*string)(nil), pgxmock.AnyArg(),
+						[]string{"account.created", "session.created"}, true).
+					WillReturnRows(pgxmock.NewRows([]string{
+						"id", "url", "description", "secret", "event_types", "active", "created_at", "updated_at",
+					}).AddRow(uuid.New(), "https://example.com/hook", (*string)(nil), "whsec_secret",
+						[]string{"account.created", "session.created"}, true, fixedTime, fixedTime))
+			},
+		},
+		{
+			name:       "unknown event type",
+			url:        "https://example.com/hook",
+			eventTypes: []string{"account.created", "account.renamed"},
+			setupMock:  func(_ pgxmock.PgxPoolIface) {},
+			wantErr:    ErrUnknownEventType,
+		},
+		{
+			name:       "private address",
+			url:        "http://169.254.169.254/latest/meta-data",
+			eventTypes: []string{"account.created"},
+			setupMock:  func(_ pgxmock.PgxPoolIface) {},
+			wantErr:    ErrPrivateURL,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mock.Close()
+			tt.setupMock(mock)
+
+			s := NewService(&database.DB{Pool: mock}, config.WebhookConfig{})
+			endpoint, err := s.CreateEndpoint(context.Background(), CreateEndpointRequest{
+				URL:        tt.url,
+				EventTypes: tt.eventTypes,
+			})
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, "whsec_secret", endpoint.Secret)
+			}
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestService_Redeliver(t *testing.T) {
+	deliveryID := uuid.New()
+
+	tests := []struct {
+		name      string
+		setupMock func(mock pgxmock.PgxPoolIface)
+		wantErr   error
+	}{
+		{
+			name: "success",
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery(`INSERT INTO webhook_delivery .+ WHERE id = \$1 AND status <> \$2`).
+					WithArgs(deliveryID, DeliveryPending).
+					WillReturnRows(pgxmock.NewRows([]string{
+						"id", "endpoint_id", "event_id", "event_type", "payload", "status", "attempts",
+						"next_attempt_at", "last_attempt_at", "response_status", "last_error", "created_at",
+						"delivered_at",
+					}).AddRow(uuid.New(), uuid.New(), uuid.New(), "account.created", []byte(`{}`),
+						DeliveryPending, 0, &fixedTime, (*time.Time)(nil), (*int)(nil), (*string)(nil), fixedTime,
+						(*time.Time)(nil)))
+			},
+		},
+		{
+			name: "still pending",
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery(`INSERT INTO webhook_delivery`).
+					WithArgs(deliveryID, DeliveryPending).
+					WillReturnError(database.ErrNoRows)
+				mock.ExpectQuery(`SELECT status FROM webhook_delivery`).
+					WithArgs(deliveryID).
+					WillReturnRows(pgxmock.NewRows([]string{"status"}).AddRow(DeliveryPending))
+			},
+			wantErr: ErrDeliveryPending,
+		},
+		{
+			name: "not found",
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery(`INSERT INTO webhook_delivery`).
+					WithArgs(deliveryID, DeliveryPending).
+					WillReturnError(database.ErrNoRows)
+				mock.ExpectQuery(`SELECT status FROM webhook_delivery`).
+					WithArgs(deliveryID).
+					WillReturnError(database.ErrNoRows)
+			},
+			wantErr: database.ErrNoRows,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mock.Close()
+			tt.setupMock(mock)
+
+			s := NewService(&database.DB{Pool: mock}, config.WebhookConfig{})
+			_, err = s.Redeliver(context.Background(), deliveryID)
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE idempotency_key
+(
+    scope                 TEXT                      NOT NULL,
+    key                   TEXT                      NOT NULL,
+    fingerprint           TEXT                      NOT NULL,
+    response_status       INTEGER,
+    response_content_type TEXT,
+    response_body         BYTEA,
+    created_at            TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    expires_at            TIMESTAMPTZ               NOT NULL,
+    PRIMARY KEY (scope, key)
+);
+
+CREATE INDEX idempotency_key_expires_at_idx ON idempotency_key (expires_at);
+
+-- +goose Down
+DROP TABLE idempotency_key;
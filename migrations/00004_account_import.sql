@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE account_import
+(
+    id          UUID        DEFAULT gen_random_uuid() PRIMARY KEY,
+    created_by  UUID                      NOT NULL,
+    status      TEXT                      NOT NULL,
+    total       INTEGER                   NOT NULL,
+    created     INTEGER     DEFAULT 0     NOT NULL,
+    failed      INTEGER     DEFAULT 0     NOT NULL,
+    skipped     INTEGER     DEFAULT 0     NOT NULL,
+    results     JSONB                     NOT NULL,
+    created_at  TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    finished_at TIMESTAMPTZ
+);
+
+-- +goose Down
+DROP TABLE account_import;
@@ -0,0 +1,6 @@
+-- +goose Up
+-- external_id is the identifier an identity provider assigns to accounts it provisions over SCIM.
+ALTER TABLE account ADD COLUMN external_id TEXT UNIQUE;
+
+-- +goose Down
+ALTER TABLE account DROP COLUMN external_id;
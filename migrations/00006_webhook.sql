@@ -0,0 +1,40 @@
+-- +goose Up
+CREATE TABLE webhook_endpoint
+(
+    id          UUID        DEFAULT gen_random_uuid() PRIMARY KEY,
+    url         TEXT                      NOT NULL,
+    description TEXT,
+    secret      TEXT                      NOT NULL,
+    event_types TEXT[]                    NOT NULL,
+    active      BOOLEAN     DEFAULT TRUE  NOT NULL,
+    created_at  TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at  TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+-- webhook_delivery is both the delivery log and the retry queue: pending rows are sent once
+-- next_attempt_at has passed.
+CREATE TABLE webhook_delivery
+(
+    id              UUID        DEFAULT gen_random_uuid() PRIMARY KEY,
+    endpoint_id     UUID                      NOT NULL REFERENCES webhook_endpoint (id) ON DELETE CASCADE,
+    event_id        UUID                      NOT NULL,
+    event_type      TEXT                      NOT NULL,
+    payload         JSONB                     NOT NULL,
+    status          TEXT        DEFAULT 'pending' NOT NULL,
+    attempts        INTEGER     DEFAULT 0     NOT NULL,
+    next_attempt_at TIMESTAMPTZ DEFAULT NOW(),
+    last_attempt_at TIMESTAMPTZ,
+    response_status INTEGER,
+    last_error      TEXT,
+    created_at      TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    delivered_at    TIMESTAMPTZ
+);
+
+CREATE INDEX webhook_delivery_due_idx ON webhook_delivery (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX webhook_delivery_endpoint_idx ON webhook_delivery (endpoint_id, created_at DESC);
+-- Finished deliveries are pruned from the delivery log once they are past the retention period.
+CREATE INDEX webhook_delivery_finished_idx ON webhook_delivery (created_at) WHERE status <> 'pending';
+
+-- +goose Down
+DROP TABLE webhook_delivery;
+DROP TABLE webhook_endpoint;
@@ -0,0 +1,33 @@
+-- +goose Up
+-- outbox holds domain events, written in the same transaction as the change they describe. txid orders
+-- events by the transaction that wrote them, so dispatchers can wait until every earlier transaction has
+-- finished instead of skipping an id whose transaction commits late.
+CREATE TABLE outbox
+(
+    id         BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    txid       XID8        DEFAULT pg_current_xact_id() NOT NULL,
+    event_id   UUID                                      NOT NULL,
+    type       TEXT                                      NOT NULL,
+    payload    JSONB                                     NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW()                 NOT NULL
+);
+
+CREATE INDEX outbox_order_idx ON outbox (txid, id);
+CREATE INDEX outbox_created_at_idx ON outbox (created_at);
+
+-- outbox_checkpoint records the last event each subscriber has handled.
+CREATE TABLE outbox_checkpoint
+(
+    subscriber TEXT PRIMARY KEY,
+    txid       XID8        DEFAULT '0'   NOT NULL,
+    position   BIGINT      DEFAULT 0     NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+-- webhook deliveries are queued from the outbox, which may hand an event over more than once.
+CREATE INDEX webhook_delivery_event_idx ON webhook_delivery (event_id);
+
+-- +goose Down
+DROP INDEX webhook_delivery_event_idx;
+DROP TABLE outbox_checkpoint;
+DROP TABLE outbox;
@@ -0,0 +1,29 @@
+-- +goose Up
+-- job is the background job queue. Workers claim due pending jobs with FOR UPDATE SKIP LOCKED and hold a
+-- lease on them through locked_until, so a job whose worker died is claimed again once the lease expires.
+CREATE TABLE job
+(
+    id           UUID        DEFAULT gen_random_uuid() PRIMARY KEY,
+    kind         TEXT                          NOT NULL,
+    payload      JSONB                         NOT NULL,
+    status       TEXT        DEFAULT 'pending' NOT NULL,
+    unique_key   TEXT,
+    attempts     INTEGER     DEFAULT 0         NOT NULL,
+    max_attempts INTEGER                       NOT NULL,
+    run_at       TIMESTAMPTZ DEFAULT NOW()     NOT NULL,
+    locked_until TIMESTAMPTZ,
+    last_error   TEXT,
+    created_at   TIMESTAMPTZ DEFAULT NOW()     NOT NULL,
+    updated_at   TIMESTAMPTZ DEFAULT NOW()     NOT NULL,
+    finished_at  TIMESTAMPTZ
+);
+
+CREATE INDEX job_due_idx ON job (run_at) WHERE status = 'pending';
+CREATE INDEX job_lease_idx ON job (locked_until) WHERE status = 'running';
+CREATE INDEX job_finished_at_idx ON job (finished_at) WHERE finished_at IS NOT NULL;
+-- a unique job can only be queued once until it finishes
+CREATE UNIQUE INDEX job_unique_key_idx ON job (kind, unique_key)
+    WHERE unique_key IS NOT NULL AND status IN ('pending', 'running');
+
+-- +goose Down
+DROP TABLE job;
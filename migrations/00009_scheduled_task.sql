@@ -0,0 +1,18 @@
+-- +goose Up
+-- scheduled_task holds the state of the periodic tasks shared by all instances. An instance fires a task by
+-- advancing next_run_at and taking a lease through locked_until in one update, so each firing runs once
+-- across the cluster and a task never overlaps itself.
+CREATE TABLE scheduled_task
+(
+    name             TEXT PRIMARY KEY,
+    schedule         TEXT        NOT NULL,
+    next_run_at      TIMESTAMPTZ NOT NULL,
+    locked_until     TIMESTAMPTZ,
+    last_started_at  TIMESTAMPTZ,
+    last_finished_at TIMESTAMPTZ,
+    last_duration_ms BIGINT,
+    last_error       TEXT
+);
+
+-- +goose Down
+DROP TABLE scheduled_task;
@@ -0,0 +1,50 @@
+-- +goose Up
+-- Instances cache auth contexts in memory. These triggers announce every change to what an auth context holds
+-- on the auth_change channel, with the account ID as payload, so each instance drops the cached sessions of that
+-- account. NOTIFY is delivered on commit, so instances never reload the state from before the change.
+
+-- +goose StatementBegin
+CREATE FUNCTION notify_auth_change() RETURNS TRIGGER AS
+$$
+BEGIN
+    IF TG_TABLE_NAME = 'account' THEN
+        PERFORM pg_notify('auth_change', OLD.id::TEXT);
+    ELSE
+        PERFORM pg_notify('auth_change', OLD.account_id::TEXT);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER account_auth_change
+    AFTER UPDATE ON account
+    FOR EACH ROW
+    WHEN (OLD.status IS DISTINCT FROM NEW.status
+        OR OLD.is_admin IS DISTINCT FROM NEW.is_admin
+        OR OLD.email IS DISTINCT FROM NEW.email
+        OR OLD.language IS DISTINCT FROM NEW.language)
+EXECUTE FUNCTION notify_auth_change();
+
+CREATE TRIGGER account_auth_delete
+    AFTER DELETE ON account
+    FOR EACH ROW
+EXECUTE FUNCTION notify_auth_change();
+
+CREATE TRIGGER auth_session_auth_change
+    AFTER UPDATE ON auth_session
+    FOR EACH ROW
+    WHEN (OLD.token IS DISTINCT FROM NEW.token OR OLD.revoked_at IS DISTINCT FROM NEW.revoked_at)
+EXECUTE FUNCTION notify_auth_change();
+
+CREATE TRIGGER auth_session_auth_delete
+    AFTER DELETE ON auth_session
+    FOR EACH ROW
+EXECUTE FUNCTION notify_auth_change();
+
+-- +goose Down
+DROP TRIGGER auth_session_auth_delete ON auth_session;
+DROP TRIGGER auth_session_auth_change ON auth_session;
+DROP TRIGGER account_auth_delete ON account;
+DROP TRIGGER account_auth_change ON account;
+DROP FUNCTION notify_auth_change();
@@ -1,8 +1,11 @@
 package migrations
 
 import (
+	"context"
 	"database/sql"
 	"embed"
+	"errors"
+	"fmt"
 
 	"github.com/pressly/goose/v3"
 )
@@ -29,3 +32,37 @@ func Down(db *sql.DB) {
 func SetDialect(dialect string) error {
 	return goose.SetDialect(dialect)
 }
+
+// ErrPendingMigrations is returned by AppliedChecker when the database is behind the embedded migrations.
+var ErrPendingMigrations = errors.New("database has pending migrations")
+
+// AppliedChecker verifies that every embedded migration has been applied to the database. The embedded
+// migrations are read once, when it is created, so a check only queries the database's version.
+type AppliedChecker struct {
+	provider *goose.Provider
+	target   int64
+}
+
+func NewAppliedChecker(db *sql.DB) (*AppliedChecker, error) {
+	provider, err := goose.NewProvider(goose.DialectPostgres, db, embedMigrations)
+	if err != nil {
+		return nil, err
+	}
+
+	checker := &AppliedChecker{provider: provider}
+	if sources := provider.ListSources(); len(sources) > 0 {
+		checker.target = sources[len(sources)-1].Version
+	}
+	return checker, nil
+}
+
+func (c *AppliedChecker) Check(ctx context.Context) error {
+	current, err := c.provider.GetDBVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if current < c.target {
+		return fmt.Errorf("%w: at version %d, expected %d", ErrPendingMigrations, current, c.target)
+	}
+	return nil
+}
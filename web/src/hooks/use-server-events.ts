@@ -0,0 +1,64 @@
+import { useQueryClient } from "@tanstack/react-query";
+import { useEffect } from "react";
+import { toast } from "sonner";
+
+import { accountKeys } from "@/features/profile/api/queries";
+import { useAuth } from "@/hooks/use-auth";
+
+type AccountChange = {
+  accountId: string;
+  change: "updated" | "disabled" | "enabled" | "deleted";
+};
+
+type Notification = {
+  title: string;
+  body: string;
+};
+
+function endSession() {
+  // the session is already gone on the server, so there is nothing to log out of
+  useAuth.setState({ isLoggedIn: false, user: null });
+  window.location.replace("/login");
+}
+
+// useServerEvents listens to the server's event stream so the app reacts to changes made elsewhere,
+// such as an admin revoking this session, without waiting for the next API call. EventSource
+// reconnects on its own and sends Last-Event-ID, so events missed in between are replayed.
+export function useServerEvents() {
+  const queryClient = useQueryClient();
+  const fetchUser = useAuth((s) => s.fetchUser);
+
+  useEffect(() => {
+    if (typeof EventSource === "undefined") {
+      return;
+    }
+    const source = new EventSource("/api/v1/events", { withCredentials: true });
+
+    source.addEventListener("session.revoked", () => {
+      source.close();
+      endSession();
+    });
+
+    source.addEventListener("account.updated", (event: MessageEvent<string>) => {
+      const data = JSON.parse(event.data) as AccountChange;
+      if (data.change === "disabled" || data.change === "deleted") {
+        source.close();
+        endSession();
+        return;
+      }
+      void queryClient.invalidateQueries({ queryKey: accountKeys.all });
+      void fetchUser();
+    });
+
+    source.addEventListener("notification", (event: MessageEvent<string>) => {
+      const data = JSON.parse(event.data) as Notification | null;
+      if (data) {
+        toast(data.title, { description: data.body });
+      }
+    });
+
+    return () => {
+      source.close();
+    };
+  }, [queryClient, fetchUser]);
+}
@@ -14,9 +14,9 @@ export class AuthError extends Error {
 // Use absolute URL to support both browser and Node.js test environments
 function getApiBase(): string {
   if (typeof window !== "undefined" && window.location?.origin) {
-    return `${window.location.origin}/api`;
+    return `${window.location.origin}/api/v1`;
   }
-  return "/api";
+  return "/api/v1";
 }
 
 class HttpClient {
@@ -5,6 +5,7 @@ import { AppSidebar } from "@/components/sidebar";
 import { SidebarInset, SidebarProvider } from "@/components/ui/sidebar";
 import { useAuth } from "@/hooks/use-auth";
 import { usePreferences } from "@/hooks/use-preferences";
+import { useServerEvents } from "@/hooks/use-server-events";
 import { useSessionRotation } from "@/hooks/use-session-rotation";
 
 export const Route = createFileRoute("/_authenticated")({
@@ -27,6 +28,7 @@ export const Route = createFileRoute("/_authenticated")({
 
 function AuthenticatedLayout() {
   useSessionRotation();
+  useServerEvents();
   const { user } = useAuth();
   const syncPreferences = usePreferences((s) => s.syncPreferences);
 